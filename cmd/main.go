@@ -28,10 +28,7 @@ func main() {
 		log.Fatal(err)
 	}
 
-	storage, err := core.NewStorage(cfg.StoragePath)
-	if err != nil {
-		log.Fatal(err)
-	}
+	storage := node.Storage()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -45,6 +42,12 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	if err := api.SetUploadLimits(cfg.MaxUploadSize, cfg.MaxBundleSize); err != nil {
+		log.Fatal(err)
+	}
+	if err := api.SetLODs(cfg.LODRatios, cfg.GenerateLODs); err != nil {
+		log.Fatal(err)
+	}
 
 	// Start API server
 	go func() {
@@ -1,19 +1,35 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/url"
+	"path"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
 	"go.uber.org/zap"
 
+	"github.com/3FT-io/3DS/pkg/config"
 	"github.com/3FT-io/3DS/pkg/core"
+	"github.com/3FT-io/3DS/pkg/exporters"
+	"github.com/3FT-io/3DS/pkg/importers"
 	"github.com/3FT-io/3DS/pkg/p2p"
 )
 
@@ -23,6 +39,23 @@ type API struct {
 	storage *core.Storage
 	logger  *zap.Logger
 	server  *http.Server
+
+	// maxUploadSize bounds the request body of an upload and
+	// maxBundleSize the unpacked size of a bundle
+	maxUploadSize int64
+	maxBundleSize int64
+
+	// lodRatios are the triangle fractions of the simplified levels of
+	// detail, which are built at upload if generateLODs is set
+	lodRatios    []float64
+	generateLODs bool
+	// lodBuilds tracks the levels of detail being built in the background
+	// after uploads, at most cap(lodSlots) at a time. lodCtx is cancelled
+	// when the API stops.
+	lodBuilds  sync.WaitGroup
+	lodSlots   chan struct{}
+	lodCtx     context.Context
+	cancelLODs context.CancelFunc
 }
 
 type APIResponse struct {
@@ -40,7 +73,14 @@ func NewAPI(node *core.Node, network *p2p.Network, storage *core.Storage, port i
 		network: network,
 		storage: storage,
 		logger:  logger,
+
+		maxUploadSize: config.DefaultConfig().MaxUploadSize,
+		maxBundleSize: config.DefaultConfig().MaxBundleSize,
+		lodRatios:     config.DefaultConfig().LODRatios,
+		// Simplification is CPU bound
+		lodSlots: make(chan struct{}, runtime.GOMAXPROCS(0)),
 	}
+	api.lodCtx, api.cancelLODs = context.WithCancel(context.Background())
 
 	router := mux.NewRouter()
 	api.setupRoutes(router)
@@ -48,9 +88,9 @@ func NewAPI(node *core.Node, network *p2p.Network, storage *core.Storage, port i
 	// Setup CORS
 	corsHandler := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Content-Type", "Authorization"},
-		ExposedHeaders:   []string{"Content-Length"},
+		ExposedHeaders:   []string{"Content-Length", "X-Dropped-Features"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	})
@@ -76,6 +116,12 @@ func (api *API) setupRoutes(router *mux.Router) {
 	router.HandleFunc("/models/{id}", api.GetModel).Methods("GET")
 	router.HandleFunc("/models/{id}", api.DeleteModel).Methods("DELETE")
 	router.HandleFunc("/models/{id}/metadata", api.GetModelMetadata).Methods("GET")
+	router.HandleFunc("/models/{id}/metadata", api.PatchModelMetadata).Methods("PATCH")
+	router.HandleFunc("/models/{id}/validation", api.GetModelValidation).Methods("GET")
+	router.HandleFunc("/models/{id}/files/{path:.+}", api.GetModelFile).Methods("GET")
+	router.HandleFunc("/models/{id}/textures/{path:.+}", api.GetModelTexture).Methods("GET")
+	router.HandleFunc("/models/{id}/convert", api.ConvertModel).Methods("GET")
+	router.HandleFunc("/models/{id}/lod/{n}", api.GetModelLOD).Methods("GET")
 
 	// Network status
 	router.HandleFunc("/network/status", api.GetNetworkStatus).Methods("GET")
@@ -85,13 +131,53 @@ func (api *API) setupRoutes(router *mux.Router) {
 	router.HandleFunc("/storage/status", api.GetStorageStatus).Methods("GET")
 }
 
+// SetUploadLimits configures the largest upload request body and the
+// largest unpacked size of a bundle, in bytes
+func (api *API) SetUploadLimits(maxUpload, maxBundle int64) error {
+	if maxUpload <= 0 || maxBundle <= 0 {
+		return fmt.Errorf("upload limits must be positive: %d, %d", maxUpload, maxBundle)
+	}
+	api.maxUploadSize = maxUpload
+	api.maxBundleSize = maxBundle
+	return nil
+}
+
+// SetLODs configures the triangle fractions of the simplified levels of
+// detail and whether they are built when a model is uploaded
+func (api *API) SetLODs(ratios []float64, onUpload bool) error {
+	for _, ratio := range ratios {
+		if ratio <= 0 || ratio >= 1 {
+			return fmt.Errorf("level of detail ratio must be between 0 and 1: %g", ratio)
+		}
+	}
+	api.lodRatios = ratios
+	api.generateLODs = onUpload
+	return nil
+}
+
 func (api *API) Start() error {
 	api.logger.Info("Starting API server", zap.String("addr", api.server.Addr))
 	return api.server.ListenAndServe()
 }
 
+// Stop shuts the server down and waits for levels of detail still being
+// built in the background. Builds still running when ctx is done are
+// cancelled.
 func (api *API) Stop(ctx context.Context) error {
-	return api.server.Shutdown(ctx)
+	defer api.cancelLODs()
+
+	err := api.server.Shutdown(ctx)
+	done := make(chan struct{})
+	go func() {
+		api.lodBuilds.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return err
 }
 
 // Health check handler
@@ -107,8 +193,14 @@ func (api *API) HealthCheck(w http.ResponseWriter, r *http.Request) {
 
 // Model upload handler
 func (api *API) UploadModel(w http.ResponseWriter, r *http.Request) {
-	// Parse multipart form
+	// Parse multipart form, keeping up to 32 MB in memory
+	r.Body = http.MaxBytesReader(w, r.Body, api.maxUploadSize)
 	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			api.sendError(w, fmt.Sprintf("Upload is larger than %d bytes", api.maxUploadSize), http.StatusRequestEntityTooLarge)
+			return
+		}
 		api.sendError(w, "Failed to parse form", http.StatusBadRequest)
 		return
 	}
@@ -125,13 +217,127 @@ func (api *API) UploadModel(w http.ResponseWriter, r *http.Request) {
 	if format == "" {
 		format = getFormatFromFilename(header.Filename)
 	}
+	if format == "" {
+		// Fall back to sniffing the file contents
+		format, err = detectFormat(file, header.Size)
+		if err != nil {
+			api.sendError(w, "Failed to read model file", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Zip archives and uploads with additional files parts are bundles,
+	// stored as one zip archive and typed by their model file
+	var bundle *importers.Bundle
+	var mainFile string
+	if extra := r.MultipartForm.File["files"]; format == "zip" || len(extra) > 0 {
+		bundle, mainFile, err = readBundle(file, header, extra, r.FormValue("main"), api.maxBundleSize)
+		if errors.Is(err, importers.ErrBundleTooLarge) {
+			api.sendError(w, fmt.Sprintf("Bundle unpacks to more than %d bytes", api.maxBundleSize), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			api.sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		format = importers.FormatFromPath(mainFile)
+	}
+
+	// Parse optional expiry
+	expiresAt, err := parseExpiry(r.FormValue("ttl"), r.FormValue("expires_at"))
+	if err != nil {
+		api.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	owner := r.FormValue("owner")
+
+	generateLODs := api.generateLODs
+	if value := r.FormValue("lods"); value != "" {
+		if generateLODs, err = strconv.ParseBool(value); err != nil {
+			api.sendError(w, fmt.Sprintf("invalid lods value: %q", value), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Parse optional user-defined metadata
+	patch, err := parseUploadMetadata(r.MultipartForm.Value["tag"], r.FormValue("attributes"))
+	if err != nil {
+		api.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Import the geometry for its statistics and validation report. Models
+	// that fail to import are still stored, without statistics.
+	var geometry *importers.MeshStats
+	var validation *importers.ValidationReport
+	var textureRefs map[string][]string
+	if bundle != nil {
+		geometry, validation, textureRefs, err = analyzeBundle(bundle, mainFile)
+	} else {
+		geometry, validation, err = analyzeModel(file, format)
+	}
+	if err != nil {
+		api.logger.Warn("Failed to analyze model", zap.String("format", format), zap.Error(err))
+	}
+
+	name, content := header.Filename, io.Reader(file)
+	if bundle != nil {
+		data, err := bundle.Zip()
+		if err != nil {
+			api.sendError(w, "Failed to store model", http.StatusInternalServerError)
+			return
+		}
+		name, content = strings.TrimSuffix(name, filepath.Ext(name))+".zip", bytes.NewReader(data)
+	} else if _, err := file.Seek(0, io.SeekStart); err != nil {
+		api.sendError(w, "Failed to read model file", http.StatusBadRequest)
+		return
+	}
 
 	// Store the model
-	metadata, err := api.storage.StoreModel(r.Context(), header.Filename, format, file)
+	metadata, err := api.storage.StoreModel(r.Context(), name, format, content)
+	if err != nil {
+		api.sendError(w, "Failed to store model", http.StatusInternalServerError)
+		return
+	}
+
+	var textures []core.TextureAsset
+	if bundle != nil {
+		textures = api.storeTextures(r.Context(), bundle, textureRefs)
+	}
+
+	metadata, err = api.storage.UpdateModel(r.Context(), metadata.ID, func(m *core.ModelMetadata) error {
+		m.ExpiresAt = expiresAt
+		m.Owner = owner
+		m.Geometry = geometry
+		m.Validation = validation
+		if bundle != nil {
+			m.Files = bundleManifest(bundle)
+			m.MainFile = mainFile
+			m.Textures = textures
+		}
+		patch.Apply(m)
+		return nil
+	})
 	if err != nil {
 		api.sendError(w, "Failed to store model", http.StatusInternalServerError)
 		return
 	}
+	if generateLODs {
+		// Simplifying large models takes longer than a request may, so the
+		// levels are built after responding
+		api.lodBuilds.Add(1)
+		go func(model core.ModelMetadata) {
+			defer api.lodBuilds.Done()
+			select {
+			case api.lodSlots <- struct{}{}:
+				defer func() { <-api.lodSlots }()
+			case <-api.lodCtx.Done():
+				return
+			}
+			api.buildLODs(api.lodCtx, &model)
+		}(*metadata)
+	}
+	api.announceModel(r.Context(), metadata)
 
 	api.sendResponse(w, APIResponse{
 		Success: true,
@@ -141,15 +347,40 @@ func (api *API) UploadModel(w http.ResponseWriter, r *http.Request) {
 
 // List models handler
 func (api *API) ListModels(w http.ResponseWriter, r *http.Request) {
-	models, err := api.storage.ListModels(r.Context())
+	// Without query parameters all models are listed as a plain array, as
+	// before search and pagination were added
+	if len(r.URL.Query()) == 0 {
+		models, err := api.storage.ListModels(r.Context())
+		if err != nil {
+			api.sendError(w, "Failed to list models", http.StatusInternalServerError)
+			return
+		}
+		api.sendResponse(w, APIResponse{
+			Success: true,
+			Data:    models,
+		})
+		return
+	}
+
+	query, err := parseModelQuery(r.URL.Query())
+	if err != nil {
+		api.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := api.storage.SearchModels(r.Context(), query)
 	if err != nil {
+		if errors.Is(err, core.ErrInvalidCursor) || errors.Is(err, core.ErrInvalidSort) {
+			api.sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		api.sendError(w, "Failed to list models", http.StatusInternalServerError)
 		return
 	}
 
 	api.sendResponse(w, APIResponse{
 		Success: true,
-		Data:    models,
+		Data:    page,
 	})
 }
 
@@ -166,7 +397,11 @@ func (api *API) GetModel(w http.ResponseWriter, r *http.Request) {
 
 	// Set appropriate headers for file download
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", model.Name))
-	w.Header().Set("Content-Type", getContentType(model.Format))
+	if len(model.Files) > 0 {
+		w.Header().Set("Content-Type", "application/zip")
+	} else {
+		w.Header().Set("Content-Type", getContentType(model.Format))
+	}
 
 	if err := api.storage.StreamModel(r.Context(), modelID, w); err != nil {
 		api.logger.Error("Failed to stream model", zap.Error(err))
@@ -191,6 +426,243 @@ func (api *API) GetModelMetadata(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// Get model validation handler. Models stored without a report are
+// validated on first request.
+func (api *API) GetModelValidation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	modelID := vars["id"]
+
+	model, err := api.storage.GetModel(r.Context(), modelID)
+	if err != nil {
+		api.sendError(w, "Model not found", http.StatusNotFound)
+		return
+	}
+
+	report := model.Validation
+	if report == nil {
+		var buf bytes.Buffer
+		if err := api.storage.StreamModel(r.Context(), modelID, &buf); err != nil {
+			api.sendError(w, "Failed to read model", http.StatusInternalServerError)
+			return
+		}
+
+		var geometry *importers.MeshStats
+		if model.MainFile != "" {
+			bundle, err := importers.OpenBundleLimit(buf.Bytes(), api.maxBundleSize)
+			if err != nil {
+				api.sendError(w, "Failed to read model", http.StatusInternalServerError)
+				return
+			}
+			geometry, report, _, _ = analyzeBundle(bundle, model.MainFile)
+		} else {
+			geometry, report, _ = analyzeModel(&buf, model.Format)
+		}
+		_, err = api.storage.UpdateModel(r.Context(), modelID, func(m *core.ModelMetadata) error {
+			m.Validation = report
+			if m.Geometry == nil {
+				m.Geometry = geometry
+			}
+			return nil
+		})
+		if err != nil {
+			api.sendError(w, "Model not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	api.sendResponse(w, APIResponse{
+		Success: true,
+		Data:    report,
+	})
+}
+
+// Get model file handler. Serves one member of a bundle upload.
+func (api *API) GetModelFile(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	modelID := vars["id"]
+
+	model, err := api.storage.GetModel(r.Context(), modelID)
+	if err != nil {
+		api.sendError(w, "Model not found", http.StatusNotFound)
+		return
+	}
+	if len(model.Files) == 0 {
+		api.sendError(w, "Model is not a bundle", http.StatusNotFound)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := api.storage.StreamModel(r.Context(), modelID, &buf); err != nil {
+		api.sendError(w, "Failed to read model", http.StatusInternalServerError)
+		return
+	}
+	bundle, err := importers.OpenBundleLimit(buf.Bytes(), api.maxBundleSize)
+	if err != nil {
+		api.sendError(w, "Failed to read model", http.StatusInternalServerError)
+		return
+	}
+
+	data, ok := bundle.File(vars["path"])
+	if !ok {
+		api.sendError(w, "File not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", path.Base(vars["path"])))
+	w.Header().Set("Content-Type", getFileContentType(vars["path"]))
+	w.Write(data)
+}
+
+// Get model texture handler. Serves the largest stored size of a bundle
+// texture that fits in the optional max_size query parameter.
+func (api *API) GetModelTexture(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	modelID := vars["id"]
+
+	maxSize := 0
+	if v := r.URL.Query().Get("max_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			api.sendError(w, fmt.Sprintf("invalid max_size: %s", v), http.StatusBadRequest)
+			return
+		}
+		maxSize = n
+	}
+
+	model, err := api.storage.GetModel(r.Context(), modelID)
+	if err != nil {
+		api.sendError(w, "Model not found", http.StatusNotFound)
+		return
+	}
+
+	name := path.Clean(strings.TrimPrefix(vars["path"], "/"))
+	var texture *core.TextureAsset
+	for i := range model.Textures {
+		if model.Textures[i].Path == name {
+			texture = &model.Textures[i]
+			break
+		}
+	}
+	if texture == nil {
+		api.sendError(w, "Texture not found", http.StatusNotFound)
+		return
+	}
+
+	variant := texture.Variant(maxSize)
+	data, err := api.storage.GetAsset(r.Context(), variant.Hash)
+	if err != nil {
+		api.sendError(w, "Failed to read texture", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/"+texture.Format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", path.Base(name)))
+	w.Write(data)
+}
+
+// Convert model handler. Converts a model to the format named by the to
+// query parameter. The result is cached as an artifact derived from the
+// model; results of several files are served as a zip archive.
+func (api *API) ConvertModel(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	modelID := vars["id"]
+
+	to := r.URL.Query().Get("to")
+	if !slices.Contains(exporters.Formats, to) {
+		api.sendError(w, fmt.Sprintf("unsupported target format: %q", to), http.StatusBadRequest)
+		return
+	}
+
+	model, err := api.storage.GetModel(r.Context(), modelID)
+	if err != nil {
+		api.sendError(w, "Model not found", http.StatusNotFound)
+		return
+	}
+
+	artifact, err := api.lodArtifact(r.Context(), model, 0, to, api.modelSource(r.Context(), model))
+	if err != nil {
+		api.sendDerivedError(w, err)
+		return
+	}
+	api.sendArtifact(w, r, artifact, to)
+}
+
+// Get model LOD handler. Serves level n of the model's level of detail
+// chain in the format named by the format query parameter, GLB by default.
+// Level 0 is the full model and every further level is simplified to the
+// next configured fraction of its triangles. Levels are built on first
+// request unless they were built at upload.
+func (api *API) GetModelLOD(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	modelID := vars["id"]
+
+	level, err := strconv.Atoi(vars["n"])
+	if err != nil || level < 0 {
+		api.sendError(w, fmt.Sprintf("invalid level: %s", vars["n"]), http.StatusBadRequest)
+		return
+	}
+	if level > len(api.lodRatios) {
+		api.sendError(w, fmt.Sprintf("level %d does not exist, the last level is %d", level, len(api.lodRatios)), http.StatusNotFound)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "glb"
+	}
+	if !slices.Contains(exporters.Formats, format) {
+		api.sendError(w, fmt.Sprintf("unsupported format: %q", format), http.StatusBadRequest)
+		return
+	}
+
+	model, err := api.storage.GetModel(r.Context(), modelID)
+	if err != nil {
+		api.sendError(w, "Model not found", http.StatusNotFound)
+		return
+	}
+
+	artifact, err := api.lodArtifact(r.Context(), model, level, format, api.modelSource(r.Context(), model))
+	if err != nil {
+		api.sendDerivedError(w, err)
+		return
+	}
+
+	w.Header().Set("X-LOD-Level", strconv.Itoa(level))
+	w.Header().Set("X-LOD-Triangles", strconv.Itoa(artifact.Triangles))
+	w.Header().Set("X-LOD-Error", strconv.FormatFloat(artifact.Error, 'g', -1, 64))
+	api.sendArtifact(w, r, artifact, format)
+}
+
+// Patch model metadata handler
+func (api *API) PatchModelMetadata(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	modelID := vars["id"]
+
+	var patch core.MetadataPatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		api.sendError(w, "Invalid metadata patch", http.StatusBadRequest)
+		return
+	}
+	if err := patch.Validate(); err != nil {
+		api.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	metadata, err := api.storage.UpdateModel(r.Context(), modelID, func(m *core.ModelMetadata) error {
+		patch.Apply(m)
+		return nil
+	})
+	if err != nil {
+		api.sendError(w, "Model not found", http.StatusNotFound)
+		return
+	}
+	api.announceModel(r.Context(), metadata)
+
+	api.sendResponse(w, APIResponse{
+		Success: true,
+		Data:    metadata,
+	})
+}
+
 // Delete model handler
 func (api *API) DeleteModel(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -256,6 +728,12 @@ func (api *API) GetStorageStatus(w http.ResponseWriter, r *http.Request) {
 }
 
 // Helper functions
+func (api *API) announceModel(ctx context.Context, metadata *core.ModelMetadata) {
+	if err := api.node.AnnounceModel(ctx, metadata); err != nil {
+		api.logger.Debug("Failed to announce model", zap.String("id", metadata.ID), zap.Error(err))
+	}
+}
+
 func (api *API) sendResponse(w http.ResponseWriter, response APIResponse) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
@@ -270,6 +748,17 @@ func (api *API) sendError(w http.ResponseWriter, message string, status int) {
 	})
 }
 
+// sendErrorData sends an error response with details in its data field
+func (api *API) sendErrorData(w http.ResponseWriter, message string, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(APIResponse{
+		Success: false,
+		Error:   message,
+		Data:    data,
+	})
+}
+
 func getContentType(format string) string {
 	switch format {
 	case "gltf":
@@ -280,11 +769,491 @@ func getContentType(format string) string {
 		return "text/plain"
 	case "fbx":
 		return "application/octet-stream"
+	case "stl":
+		return "model/stl"
+	case "3mf":
+		return "model/3mf"
+	case "dae":
+		return "model/vnd.collada+xml"
 	default:
 		return "application/octet-stream"
 	}
 }
 
+// getFileContentType returns the content type of a bundle member by its
+// extension
+func getFileContentType(name string) string {
+	if format := importers.FormatFromPath(name); format != "" {
+		return getContentType(format)
+	}
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// parseModelQuery builds a model query from list endpoint parameters
+func parseModelQuery(values url.Values) (core.ModelQuery, error) {
+	query := core.ModelQuery{
+		Name:       values.Get("name"),
+		NamePrefix: values.Get("name_prefix"),
+		Format:     values.Get("format"),
+		Owner:      values.Get("owner"),
+		Tags:       values["tag"],
+		SortBy:     values.Get("sort"),
+		Cursor:     values.Get("cursor"),
+	}
+
+	// Attribute filters are passed as attr.<key>=<value>
+	for param := range values {
+		if key, ok := strings.CutPrefix(param, "attr."); ok && key != "" {
+			if query.Attributes == nil {
+				query.Attributes = make(map[string]string)
+			}
+			query.Attributes[key] = values.Get(param)
+		}
+	}
+
+	switch order := values.Get("order"); order {
+	case "", "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		return query, fmt.Errorf("invalid order: %s", order)
+	}
+
+	for param, target := range map[string]*int64{
+		"min_size": &query.MinSize,
+		"max_size": &query.MaxSize,
+	} {
+		if v := values.Get(param); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || n < 0 {
+				return query, fmt.Errorf("invalid %s: %s", param, v)
+			}
+			*target = n
+		}
+	}
+
+	for param, target := range map[string]*int{
+		"min_vertices":  &query.MinVertices,
+		"max_vertices":  &query.MaxVertices,
+		"min_triangles": &query.MinTriangles,
+		"max_triangles": &query.MaxTriangles,
+	} {
+		if v := values.Get(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return query, fmt.Errorf("invalid %s: %s", param, v)
+			}
+			*target = n
+		}
+	}
+
+	for param, target := range map[string]*time.Time{
+		"created_after":  &query.CreatedAfter,
+		"created_before": &query.CreatedBefore,
+	} {
+		if v := values.Get(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return query, fmt.Errorf("invalid %s: %s", param, v)
+			}
+			*target = t
+		}
+	}
+
+	if v := values.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return query, fmt.Errorf("invalid limit: %s", v)
+		}
+		query.Limit = n
+	}
+
+	return query, nil
+}
+
+// parseUploadMetadata builds a metadata patch from upload form fields:
+// repeated tag fields and an optional JSON object of string attributes
+func parseUploadMetadata(tags []string, attributes string) (*core.MetadataPatch, error) {
+	patch := &core.MetadataPatch{}
+	if len(tags) > 0 {
+		patch.Tags = &tags
+	}
+
+	if attributes != "" {
+		if err := json.Unmarshal([]byte(attributes), &patch.Attributes); err != nil {
+			return nil, errors.New("attributes must be a JSON object of strings")
+		}
+	}
+
+	if err := patch.Validate(); err != nil {
+		return nil, err
+	}
+
+	return patch, nil
+}
+
+// parseExpiry turns a TTL duration (e.g. "168h") or an RFC 3339 timestamp
+// into an expiry time
+func parseExpiry(ttl, expiresAt string) (*time.Time, error) {
+	switch {
+	case ttl != "" && expiresAt != "":
+		return nil, errors.New("ttl and expires_at are mutually exclusive")
+	case ttl != "":
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid ttl: %s", ttl)
+		}
+		t := time.Now().Add(d)
+		return &t, nil
+	case expiresAt != "":
+		t, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expires_at: %s", expiresAt)
+		}
+		return &t, nil
+	}
+	return nil, nil
+}
+
+// detectFormat sniffs the format of an uploaded file and rewinds it
+func detectFormat(file io.ReadSeeker, size int64) (string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return importers.DetectFormat(head[:n], size), nil
+}
+
+// analyzeModel imports a model to compute its mesh statistics, counting
+// embedded materials as well as those referenced by faces, and to validate
+// it. A model that fails to import gets a report holding the import error.
+// Meshes with NaN or infinite values get no statistics, as their bounds
+// could not be encoded as JSON.
+func analyzeModel(file io.Reader, format string) (*importers.MeshStats, *importers.ValidationReport, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vertices := importers.NewVertexImporter()
+	if err := vertices.Import(format, bytes.NewReader(data), importers.ImportOptions{}); err != nil {
+		return nil, importers.ImportErrorReport(err), err
+	}
+	report := vertices.Validate()
+	if report.HasError("non_finite_value") {
+		return nil, report, nil
+	}
+	stats := vertices.Stats()
+
+	materials := importers.NewMaterialImporter()
+	if err := materials.Import(format, bytes.NewReader(data), nil); err == nil {
+		stats.Materials = max(stats.Materials, len(materials.GetMaterials()))
+	}
+	return &stats, report, nil
+}
+
+// analyzeBundle is analyzeModel for the model file of a bundle. Referenced
+// files missing from the bundle are reported as warnings. It also returns
+// the textures found, mapped to the materials using them.
+func analyzeBundle(bundle *importers.Bundle, main string) (*importers.MeshStats, *importers.ValidationReport, map[string][]string, error) {
+	result, err := bundle.Import(main, importers.ImportOptions{})
+	if err != nil {
+		report := importers.ImportErrorReport(err)
+		report.AddMissingFiles(result.Missing)
+		return nil, report, nil, err
+	}
+
+	report := result.Vertices.Validate()
+	report.AddMissingFiles(result.Missing)
+	if report.HasError("non_finite_value") {
+		return nil, report, result.Textures, nil
+	}
+	stats := result.Vertices.Stats()
+	stats.Materials = max(stats.Materials, len(result.Materials.GetMaterials()))
+	return &stats, report, result.Textures, nil
+}
+
+// storeTextures stores the textures of a bundle as assets with their
+// mipmaps. Textures that are not PNG or JPEG images are left out.
+func (api *API) storeTextures(ctx context.Context, bundle *importers.Bundle, refs map[string][]string) []core.TextureAsset {
+	paths := make([]string, 0, len(refs))
+	for name := range refs {
+		paths = append(paths, name)
+	}
+	sort.Strings(paths)
+
+	var assets []core.TextureAsset
+	for _, name := range paths {
+		data, _ := bundle.File(name)
+		asset, err := api.storage.StoreTexture(ctx, name, data)
+		if err != nil {
+			api.logger.Warn("Failed to process texture", zap.String("path", name), zap.Error(err))
+			continue
+		}
+		asset.Materials = refs[name]
+		assets = append(assets, *asset)
+	}
+	return assets
+}
+
+// errImportFailed marks models that could not be imported to derive an
+// artifact from
+var errImportFailed = errors.New("failed to import model")
+
+// modelSource returns a function importing a stored model for export on
+// its first call and returning the same result afterwards
+func (api *API) modelSource(ctx context.Context, model *core.ModelMetadata) func() (*exporters.Model, importers.ResourceResolver, error) {
+	var (
+		loaded   bool
+		source   *exporters.Model
+		resolver importers.ResourceResolver
+		err      error
+	)
+	return func() (*exporters.Model, importers.ResourceResolver, error) {
+		if loaded {
+			return source, resolver, err
+		}
+		loaded = true
+
+		var buf bytes.Buffer
+		if err = api.storage.StreamModel(ctx, model.ID, &buf); err != nil {
+			return nil, nil, err
+		}
+		source, resolver, err = importStoredModel(model, buf.Bytes(), api.maxBundleSize)
+		if err != nil {
+			err = fmt.Errorf("%w: %v", errImportFailed, err)
+		}
+		return source, resolver, err
+	}
+}
+
+// lodArtifact returns level n of a model's level of detail chain in the
+// given format, building and caching it on first use. Level 0 is the full
+// model, which is also what the convert endpoint serves.
+func (api *API) lodArtifact(ctx context.Context, model *core.ModelMetadata, level int, format string, source func() (*exporters.Model, importers.ResourceResolver, error)) (*core.DerivedArtifact, error) {
+	kind := "convert/" + format
+	var ratio float64
+	if level > 0 {
+		ratio = api.lodRatios[level-1]
+		kind = fmt.Sprintf("lod/%g/%s", ratio, format)
+	}
+	if artifact, err := api.storage.GetDerived(ctx, model.Hash, kind); err == nil {
+		return artifact, nil
+	}
+
+	model3d, resolver, err := source()
+	if err != nil {
+		return nil, err
+	}
+	name := modelBaseName(model)
+	var simplificationError float64
+	if level > 0 {
+		target := int(math.Ceil(ratio * float64(model3d.Mesh.TriangleCount())))
+		model3d, simplificationError = model3d.Simplify(target)
+		name = fmt.Sprintf("%s_lod%d", name, level)
+	}
+
+	files, err := exporters.Export(format, name, model3d, exporters.ExportOptions{Resolver: resolver})
+	if err != nil {
+		return nil, err
+	}
+	name, data, err := packageFiles(files)
+	if err != nil {
+		return nil, err
+	}
+	return api.storage.StoreDerived(ctx, core.DerivedArtifact{
+		Kind:      kind,
+		Source:    model.Hash,
+		Name:      name,
+		Triangles: model3d.Mesh.TriangleCount(),
+		Error:     simplificationError,
+		Dropped:   exporters.DroppedFeatures(format, model3d),
+	}, data)
+}
+
+// buildLODs builds the simplified levels of a model as GLB files. Builds
+// stop quietly when cancelled or when the model was deleted meanwhile.
+func (api *API) buildLODs(ctx context.Context, model *core.ModelMetadata) {
+	source := api.modelSource(ctx, model)
+	for level := 1; level <= len(api.lodRatios); level++ {
+		_, err := api.lodArtifact(ctx, model, level, "glb", source)
+		if errors.Is(err, context.Canceled) || errors.Is(err, core.ErrModelNotFound) {
+			return
+		}
+		if err != nil {
+			api.logger.Warn("Failed to generate level of detail", zap.String("id", model.ID), zap.Int("level", level), zap.Error(err))
+			return
+		}
+	}
+}
+
+// sendDerivedError reports why an artifact could not be derived from a
+// model. Models that cannot be imported or represented in the target
+// format are unprocessable.
+func (api *API) sendDerivedError(w http.ResponseWriter, err error) {
+	var unsupported *exporters.UnsupportedFeatureError
+	switch {
+	case errors.As(err, &unsupported):
+		api.sendErrorData(w, err.Error(), http.StatusUnprocessableEntity, unsupported)
+	case errors.Is(err, errImportFailed):
+		api.sendError(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		api.logger.Error("Failed to derive model", zap.Error(err))
+		api.sendError(w, "Failed to convert model", http.StatusInternalServerError)
+	}
+}
+
+// sendArtifact serves a derived model file, or a zip archive of several.
+// Features the format left out are listed in the X-Dropped-Features header.
+func (api *API) sendArtifact(w http.ResponseWriter, r *http.Request, artifact *core.DerivedArtifact, format string) {
+	data, err := api.storage.GetAsset(r.Context(), artifact.Hash)
+	if err != nil {
+		api.sendError(w, "Failed to read converted model", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", artifact.Name))
+	if len(artifact.Dropped) > 0 {
+		w.Header().Set("X-Dropped-Features", strings.Join(artifact.Dropped, ", "))
+	}
+	if path.Ext(artifact.Name) == ".zip" {
+		w.Header().Set("Content-Type", "application/zip")
+	} else {
+		w.Header().Set("Content-Type", getContentType(format))
+	}
+	w.Write(data)
+}
+
+// importStoredModel imports the stored data of a model for export. The
+// model file of a bundle is imported with its material libraries, and the
+// returned resolver loads the other members, such as textures.
+func importStoredModel(model *core.ModelMetadata, data []byte, maxBundleSize int64) (*exporters.Model, importers.ResourceResolver, error) {
+	if model.MainFile != "" {
+		bundle, err := importers.OpenBundleLimit(data, maxBundleSize)
+		if err != nil {
+			return nil, nil, err
+		}
+		result, err := bundle.Import(model.MainFile, importers.ImportOptions{})
+		if err != nil {
+			return nil, nil, err
+		}
+		return exporters.NewModel(result.Vertices, result.Materials), bundle.Resolver(model.MainFile), nil
+	}
+
+	vertices := importers.NewVertexImporter()
+	if err := vertices.Import(model.Format, bytes.NewReader(data), importers.ImportOptions{}); err != nil {
+		return nil, nil, err
+	}
+	// Materials are optional; formats without embedded ones fail here
+	materials := importers.NewMaterialImporter()
+	if err := materials.Import(model.Format, bytes.NewReader(data), nil); err != nil {
+		materials = nil
+	}
+	return exporters.NewModel(vertices, materials), nil, nil
+}
+
+// modelBaseName returns the name of a model's file without its extension
+func modelBaseName(model *core.ModelMetadata) string {
+	name := model.Name
+	if model.MainFile != "" {
+		name = path.Base(model.MainFile)
+	}
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+// packageFiles returns a single exported file as it is and several as a
+// zip archive named after the first
+func packageFiles(files []exporters.File) (string, []byte, error) {
+	if len(files) == 1 {
+		return files[0].Name, files[0].Data, nil
+	}
+
+	members := make(map[string][]byte, len(files))
+	for _, file := range files {
+		members[file.Name] = file.Data
+	}
+	bundle, err := importers.NewBundle(members)
+	if err != nil {
+		return "", nil, err
+	}
+	data, err := bundle.Zip()
+	if err != nil {
+		return "", nil, err
+	}
+	return strings.TrimSuffix(files[0].Name, path.Ext(files[0].Name)) + ".zip", data, nil
+}
+
+// readBundle builds a bundle from an uploaded zip archive, or from the
+// model part and the additional files parts of a multi-part upload. The
+// model file is the one named by main if given, else the model part of a
+// multi-part upload or the best candidate in the archive.
+func readBundle(file multipart.File, header *multipart.FileHeader, extra []*multipart.FileHeader, main string, maxSize int64) (*importers.Bundle, string, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, "", errors.New("failed to read model file")
+	}
+
+	var bundle *importers.Bundle
+	if len(extra) == 0 {
+		bundle, err = importers.OpenBundleLimit(data, maxSize)
+	} else {
+		files := map[string][]byte{header.Filename: data}
+		for _, part := range extra {
+			if _, ok := files[part.Filename]; ok {
+				return nil, "", fmt.Errorf("duplicate bundle file: %s", part.Filename)
+			}
+			if files[part.Filename], err = readFormFile(part); err != nil {
+				return nil, "", fmt.Errorf("failed to read bundle file: %s", part.Filename)
+			}
+		}
+		bundle, err = importers.NewBundle(files)
+		if main == "" {
+			main = header.Filename
+		}
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if main == "" {
+		main, _, err = bundle.MainFile()
+		return bundle, main, err
+	}
+	main = path.Clean(strings.TrimPrefix(main, "/"))
+	if _, ok := bundle.File(main); !ok || importers.FormatFromPath(main) == "" {
+		return nil, "", fmt.Errorf("main file is not a model file in the bundle: %s", main)
+	}
+	return bundle, main, nil
+}
+
+// readFormFile reads an uploaded file part
+func readFormFile(header *multipart.FileHeader) ([]byte, error) {
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// bundleManifest lists the files of a bundle with their sizes
+func bundleManifest(bundle *importers.Bundle) []core.ModelFile {
+	paths := bundle.Paths()
+	files := make([]core.ModelFile, len(paths))
+	for i, name := range paths {
+		data, _ := bundle.File(name)
+		files[i] = core.ModelFile{Path: name, Size: int64(len(data))}
+	}
+	return files
+}
+
 func getFormatFromFilename(filename string) string {
 	ext := filepath.Ext(filename)
 	if ext == "" {
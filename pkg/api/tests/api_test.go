@@ -1,17 +1,29 @@
 package api_test
 
 import (
+	"archive/zip"
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/3FT-io/3DS/pkg/api"
 	"github.com/3FT-io/3DS/pkg/core"
+	"github.com/3FT-io/3DS/pkg/importers"
 	"github.com/3FT-io/3DS/pkg/p2p"
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -42,12 +54,48 @@ func setupTestAPI(t *testing.T) (*api.API, func()) {
 
 	// Return cleanup function
 	cleanup := func() {
+		apiInstance.Stop(context.Background())
 		os.RemoveAll(tmpDir)
 	}
 
 	return apiInstance, cleanup
 }
 
+// uploadPart is a file part of an upload request
+type uploadPart struct{ field, name, content string }
+
+// uploadModel posts the file parts and form fields to the upload handler
+func uploadModel(t *testing.T, apiInstance *api.API, parts []uploadPart, fields map[string]string) *httptest.ResponseRecorder {
+	var b bytes.Buffer
+	writer := multipart.NewWriter(&b)
+	for _, part := range parts {
+		fileWriter, err := writer.CreateFormFile(part.field, part.name)
+		require.NoError(t, err)
+		_, err = fileWriter.Write([]byte(part.content))
+		require.NoError(t, err)
+	}
+	for key, value := range fields {
+		require.NoError(t, writer.WriteField(key, value))
+	}
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest("POST", "/models", &b)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	apiInstance.UploadModel(w, req)
+	return w
+}
+
+// uploadedModel returns the metadata of a successful upload
+func uploadedModel(t *testing.T, w *httptest.ResponseRecorder) core.ModelMetadata {
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data core.ModelMetadata `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	return response.Data
+}
+
 func TestHealthCheck(t *testing.T) {
 	api, cleanup := setupTestAPI(t)
 	defer cleanup()
@@ -72,32 +120,576 @@ func TestUploadModel(t *testing.T) {
 	api, cleanup := setupTestAPI(t)
 	defer cleanup()
 
-	// Create test file
-	var b bytes.Buffer
-	writer := multipart.NewWriter(&b)
+	// Upload a test file
+	w := uploadModel(t, api, []uploadPart{{"model", "test.gltf", "test model content"}}, nil)
 
-	fileWriter, err := writer.CreateFormFile("model", "test.gltf")
-	require.NoError(t, err)
+	// Assert response
+	assert.Equal(t, http.StatusOK, w.Code)
 
-	_, err = fileWriter.Write([]byte("test model content"))
+	var response APIResponse
+	err := json.NewDecoder(w.Body).Decode(&response)
 	require.NoError(t, err)
+	assert.True(t, response.Success)
+}
 
-	err = writer.Close()
-	require.NoError(t, err)
+func TestUploadModelWithTTL(t *testing.T) {
+	api, cleanup := setupTestAPI(t)
+	defer cleanup()
 
-	// Create test request
-	req := httptest.NewRequest("POST", "/models", &b)
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	upload := func(fields map[string]string) *httptest.ResponseRecorder {
+		return uploadModel(t, api, []uploadPart{{"model", "preview.glb", "test model content"}}, fields)
+	}
+
+	w := upload(map[string]string{"ttl": "168h", "owner": "ci"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Success bool               `json:"success"`
+		Data    core.ModelMetadata `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.True(t, response.Success)
+	assert.Equal(t, "ci", response.Data.Owner)
+	require.NotNil(t, response.Data.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(168*time.Hour), *response.Data.ExpiresAt, time.Minute)
+
+	w = upload(map[string]string{"ttl": "soon"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUploadModelDetectsFormat(t *testing.T) {
+	api, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	stl := "solid part\n facet normal 0 0 1\n  outer loop\n   vertex 0 0 0\n   vertex 1 0 0\n   vertex 0 1 0\n  endloop\n endfacet\nendsolid part\n"
+
+	model := uploadedModel(t, uploadModel(t, api, []uploadPart{{"model", "part", stl}}, nil))
+	assert.Equal(t, "stl", model.Format)
+	assert.Equal(t, int64(len(stl)), model.Size)
+	require.NotNil(t, model.Geometry)
+	assert.Equal(t, 1, model.Geometry.Triangles)
+	assert.Equal(t, 0.5, model.Geometry.SurfaceArea)
+	assert.False(t, model.Geometry.Closed)
+
+	for query, total := range map[string]int{
+		"max_triangles=10": 1,
+		"min_triangles=2":  0,
+		"min_vertices=3":   1,
+	} {
+		req := httptest.NewRequest("GET", "/models?"+query, nil)
+		w := httptest.NewRecorder()
+		api.ListModels(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var listed struct {
+			Data core.ModelPage `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&listed))
+		assert.Equal(t, total, listed.Data.Total, query)
+	}
+}
+
+func TestListModelsQuery(t *testing.T) {
+	api, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	for _, name := range []string{"chair.obj", "table.obj", "robot.glb"} {
+		w := uploadModel(t, api, []uploadPart{{"model", name, "test model content"}}, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/models?format=obj&sort=name&order=desc&limit=1", nil)
 	w := httptest.NewRecorder()
+	api.ListModels(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
 
-	// Call upload handler
-	api.UploadModel(w, req)
+	var response struct {
+		Success bool           `json:"success"`
+		Data    core.ModelPage `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.True(t, response.Success)
+	assert.Equal(t, 2, response.Data.Total)
+	require.Len(t, response.Data.Models, 1)
+	assert.Equal(t, "table.obj", response.Data.Models[0].Name)
+	assert.NotEmpty(t, response.Data.NextCursor)
 
-	// Assert response
+	// Without parameters every model is listed as an array
+	req = httptest.NewRequest("GET", "/models", nil)
+	w = httptest.NewRecorder()
+	api.ListModels(w, req)
 	assert.Equal(t, http.StatusOK, w.Code)
+	var all struct {
+		Data []core.ModelMetadata `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&all))
+	assert.Len(t, all.Data, 3)
 
-	var response APIResponse
-	err = json.NewDecoder(w.Body).Decode(&response)
+	req = httptest.NewRequest("GET", "/models?min_size=abc", nil)
+	w = httptest.NewRecorder()
+	api.ListModels(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPatchModelMetadataConcurrentReads(t *testing.T) {
+	api, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	uploaded := uploadedModel(t, uploadModel(t, api, []uploadPart{{"model", "chair.obj", "test model content"}}, nil))
+
+	// Responses are encoded from copies while patches replace the stored
+	// tags and attributes; run with -race
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				body := fmt.Sprintf(`{"attributes": {"writer": "%d", "pass": "%d"}, "tags": ["t%d", "p%d"]}`, i, j, i, j)
+				req := httptest.NewRequest("PATCH", "/models/"+uploaded.ID+"/metadata", strings.NewReader(body))
+				req = mux.SetURLVars(req, map[string]string{"id": uploaded.ID})
+				w := httptest.NewRecorder()
+				api.PatchModelMetadata(w, req)
+				assert.Equal(t, http.StatusOK, w.Code)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				req := httptest.NewRequest("GET", "/models/"+uploaded.ID+"/metadata", nil)
+				req = mux.SetURLVars(req, map[string]string{"id": uploaded.ID})
+				w := httptest.NewRecorder()
+				api.GetModelMetadata(w, req)
+				assert.Equal(t, http.StatusOK, w.Code)
+
+				w = httptest.NewRecorder()
+				api.ListModels(w, httptest.NewRequest("GET", "/models", nil))
+				assert.Equal(t, http.StatusOK, w.Code)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestPatchModelMetadata(t *testing.T) {
+	api, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	uploaded := uploadedModel(t, uploadModel(t, api, []uploadPart{{"model", "chair.obj", "test model content"}}, map[string]string{
+		"tag":        "furniture",
+		"attributes": `{"artist": "dana", "engine": "unity"}`,
+	}))
+	assert.Equal(t, []string{"furniture"}, uploaded.Tags)
+	assert.Equal(t, map[string]string{"artist": "dana", "engine": "unity"}, uploaded.Attributes)
+
+	body := `{"attributes": {"engine": null, "lod": "1"}, "tags": ["furniture", "lod"]}`
+	req := httptest.NewRequest("PATCH", "/models/"+uploaded.ID+"/metadata", strings.NewReader(body))
+	req = mux.SetURLVars(req, map[string]string{"id": uploaded.ID})
+	w := httptest.NewRecorder()
+	api.PatchModelMetadata(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var patched struct {
+		Data core.ModelMetadata `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&patched))
+	assert.Equal(t, []string{"furniture", "lod"}, patched.Data.Tags)
+	assert.Equal(t, map[string]string{"artist": "dana", "lod": "1"}, patched.Data.Attributes)
+
+	req = httptest.NewRequest("GET", "/models?attr.lod=1", nil)
+	w = httptest.NewRecorder()
+	api.ListModels(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var listed struct {
+		Data core.ModelPage `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&listed))
+	require.Len(t, listed.Data.Models, 1)
+	assert.Equal(t, uploaded.ID, listed.Data.Models[0].ID)
+
+	req = httptest.NewRequest("PATCH", "/models/missing/metadata", strings.NewReader(`{"tags": ["x"]}`))
+	req = mux.SetURLVars(req, map[string]string{"id": "missing"})
+	w = httptest.NewRecorder()
+	api.PatchModelMetadata(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetModelValidation(t *testing.T) {
+	api, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	upload := func(name, content string) string {
+		return uploadedModel(t, uploadModel(t, api, []uploadPart{{"model", name, content}}, nil)).ID
+	}
+
+	validation := func(id string) (int, importers.ValidationReport) {
+		req := httptest.NewRequest("GET", "/models/"+id+"/validation", nil)
+		req = mux.SetURLVars(req, map[string]string{"id": id})
+		w := httptest.NewRecorder()
+		api.GetModelValidation(w, req)
+
+		var response struct {
+			Data importers.ValidationReport `json:"data"`
+		}
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		}
+		return w.Code, response.Data
+	}
+
+	triangle := "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\n"
+	code, report := validation(upload("triangle.obj", triangle))
+	require.Equal(t, http.StatusOK, code)
+	assert.True(t, report.Valid)
+	require.Len(t, report.Warnings, 1)
+	assert.Equal(t, "open_boundary", report.Warnings[0].Code)
+
+	code, report = validation(upload("broken.stl", "solid broken\n facet normal 0 0 1\n"))
+	require.Equal(t, http.StatusOK, code)
+	assert.False(t, report.Valid)
+	assert.Equal(t, "import_failed", report.Errors[0].Code)
+
+	// NaN positions are reported, and leave the model without statistics
+	// rather than with bounds that cannot be encoded
+	nan := upload("nan.obj", "v nan 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\n")
+	code, report = validation(nan)
+	require.Equal(t, http.StatusOK, code)
+	assert.False(t, report.Valid)
+	assert.Equal(t, "non_finite_value", report.Errors[0].Code)
+	req := httptest.NewRequest("GET", "/models/"+nan+"/metadata", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": nan})
+	w := httptest.NewRecorder()
+	api.GetModelMetadata(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data core.ModelMetadata `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Nil(t, response.Data.Geometry)
+
+	w = httptest.NewRecorder()
+	api.ListModels(w, httptest.NewRequest("GET", "/models", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	code, _ = validation("missing")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestUploadBundle(t *testing.T) {
+	api, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	// Multi-part bundle: the model part plus repeated files parts
+	model := uploadedModel(t, uploadModel(t, api, []uploadPart{
+		{"model", "crate.obj", "mtllib crate.mtl\nv 0 0 0\nv 1 0 0\nv 0 1 0\nusemtl Crate\nf 1 2 3\n"},
+		{"files", "crate.mtl", "newmtl Crate\nmap_Kd crate.png\nmap_Bump crate_normal.png\n"},
+		{"files", "crate.png", "png"},
+	}, nil))
+	assert.Equal(t, "crate.zip", model.Name)
+	assert.Equal(t, "obj", model.Format)
+	assert.Equal(t, "crate.obj", model.MainFile)
+	assert.Equal(t, []core.ModelFile{
+		{Path: "crate.mtl", Size: 56},
+		{Path: "crate.obj", Size: 62},
+		{Path: "crate.png", Size: 3},
+	}, model.Files)
+
+	// The missing normal map is reported
+	req := httptest.NewRequest("GET", "/models/"+model.ID+"/validation", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": model.ID})
+	w := httptest.NewRecorder()
+	api.GetModelValidation(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var validation struct {
+		Data importers.ValidationReport `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&validation))
+	var missing []string
+	for _, issue := range validation.Data.Warnings {
+		if issue.Code == "missing_file" {
+			missing = issue.Files
+		}
+	}
+	assert.Equal(t, []string{"crate_normal.png"}, missing)
+
+	getFile := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/models/"+model.ID+"/files/"+path, nil)
+		req = mux.SetURLVars(req, map[string]string{"id": model.ID, "path": path})
+		w := httptest.NewRecorder()
+		api.GetModelFile(w, req)
+		return w
+	}
+
+	w = getFile("crate.png")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, "png", w.Body.String())
+
+	assert.Equal(t, http.StatusNotFound, getFile("crate_normal.png").Code)
+	assert.Equal(t, http.StatusNotFound, getFile("../crate.png").Code)
+
+	// The whole bundle downloads as a zip archive
+	req = httptest.NewRequest("GET", "/models/"+model.ID, nil)
+	req = mux.SetURLVars(req, map[string]string{"id": model.ID})
+	w = httptest.NewRecorder()
+	api.GetModel(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	bundle, err := importers.OpenBundle(w.Body.Bytes())
 	require.NoError(t, err)
-	assert.True(t, response.Success)
+	assert.Equal(t, []string{"crate.mtl", "crate.obj", "crate.png"}, bundle.Paths())
+}
+
+func TestUploadLimits(t *testing.T) {
+	api, cleanup := setupTestAPI(t)
+	defer cleanup()
+	require.NoError(t, api.SetUploadLimits(64<<10, 32<<10))
+	assert.Error(t, api.SetUploadLimits(0, 1))
+
+	upload := func(name string, content []byte) *httptest.ResponseRecorder {
+		return uploadModel(t, api, []uploadPart{{"model", name, string(content)}}, nil)
+	}
+
+	// The request body is limited
+	assert.Equal(t, http.StatusRequestEntityTooLarge, upload("big.stl", make([]byte, 65<<10)).Code)
+
+	// So is the unpacked size of a bundle, which compresses far better
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	member, err := zw.Create("zeros.obj")
+	require.NoError(t, err)
+	_, err = member.Write(make([]byte, 33<<10))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.Less(t, archive.Len(), 64<<10)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, upload("zeros.zip", archive.Bytes()).Code)
+
+	assert.Equal(t, http.StatusOK, upload("triangle.obj", []byte("v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\n")).Code)
+}
+
+func TestGetModelTexture(t *testing.T) {
+	api, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	var texture bytes.Buffer
+	require.NoError(t, png.Encode(&texture, image.NewNRGBA(image.Rect(0, 0, 8, 4))))
+
+	model := uploadedModel(t, uploadModel(t, api, []uploadPart{
+		{"model", "crate.obj", "mtllib crate.mtl\nv 0 0 0\nv 1 0 0\nv 0 1 0\nusemtl Crate\nf 1 2 3\n"},
+		{"files", "crate.mtl", "newmtl Crate\nmap_Kd crate.png\n"},
+		{"files", "crate.png", texture.String()},
+	}, nil))
+	require.Len(t, model.Textures, 1)
+	assert.Equal(t, "crate.png", model.Textures[0].Path)
+	assert.Equal(t, []string{"Crate"}, model.Textures[0].Materials)
+	assert.Equal(t, 4, model.Textures[0].Channels)
+	assert.Len(t, model.Textures[0].Variants, 4) // 8x4, 4x2, 2x1, 1x1
+
+	getTexture := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/models/"+model.ID+"/textures/crate.png"+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"id": model.ID, "path": "crate.png"})
+		w := httptest.NewRecorder()
+		api.GetModelTexture(w, req)
+		return w
+	}
+
+	w := getTexture("?max_size=4")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	config, err := png.DecodeConfig(w.Body)
+	require.NoError(t, err)
+	assert.Equal(t, 4, config.Width)
+	assert.Equal(t, 2, config.Height)
+
+	w = getTexture("")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, texture.Bytes(), w.Body.Bytes())
+
+	assert.Equal(t, http.StatusBadRequest, getTexture("?max_size=big").Code)
+}
+
+func TestConvertModel(t *testing.T) {
+	api, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	var texture bytes.Buffer
+	require.NoError(t, png.Encode(&texture, image.NewNRGBA(image.Rect(0, 0, 2, 2))))
+
+	upload := func(parts []uploadPart) string {
+		return uploadedModel(t, uploadModel(t, api, parts, nil)).ID
+	}
+
+	convert := func(id, to string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/models/"+id+"/convert?to="+to, nil)
+		req = mux.SetURLVars(req, map[string]string{"id": id})
+		w := httptest.NewRecorder()
+		api.ConvertModel(w, req)
+		return w
+	}
+
+	crate := upload([]uploadPart{
+		{"model", "crate.obj", "mtllib crate.mtl\nv 0 0 0\nv 1 0 0\nv 0 1 0\nvt 0 0\nvt 1 0\nvt 0 1\nusemtl Crate\nf 1/1 2/2 3/3\n"},
+		{"files", "crate.mtl", "newmtl Crate\nKd 1 0.5 0\nmap_Kd crate.png\n"},
+		{"files", "crate.png", texture.String()},
+	})
+
+	// GLB embeds the bundle's textures
+	w := convert(crate, "glb")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "model/gltf-binary", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "crate.glb")
+	glb := w.Body.Bytes()
+	vertices := importers.NewVertexImporter()
+	require.NoError(t, vertices.ImportFromGLB(bytes.NewReader(glb), nil))
+	assert.Equal(t, 1, vertices.GetMesh().TriangleCount())
+	materials := importers.NewMaterialImporter()
+	require.NoError(t, materials.ImportFromGLB(bytes.NewReader(glb), nil))
+	require.Contains(t, materials.GetMaterials(), "Crate")
+	assert.Equal(t, "crate.png", materials.GetMaterials()["Crate"].PBR.BaseColorTexture)
+
+	// The second request is served from the cached artifact
+	w = convert(crate, "glb")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, glb, w.Body.Bytes())
+
+	// OBJ comes with its material library and textures in a zip archive
+	w = convert(crate, "obj")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "crate.zip")
+	bundle, err := importers.OpenBundle(w.Body.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"crate.mtl", "crate.obj", "crate.png"}, bundle.Paths())
+
+	// Features STL cannot hold are reported, also for the cached artifact
+	for i := 0; i < 2; i++ {
+		w = convert(crate, "stl")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, importers.IsBinarySTL(w.Body.Bytes()))
+		assert.Equal(t, "materials, texture coordinates", w.Header().Get("X-Dropped-Features"))
+	}
+	assert.Empty(t, convert(crate, "glb").Header().Get("X-Dropped-Features"))
+
+	// Point clouds have no STL representation
+	points := upload([]uploadPart{
+		{"model", "points.ply", "ply\nformat ascii 1.0\nelement vertex 2\nproperty float x\nproperty float y\nproperty float z\nend_header\n0 0 0\n1 1 1\n"},
+	})
+	w = convert(points, "stl")
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var response struct {
+		Error string `json:"error"`
+		Data  struct {
+			Format   string   `json:"format"`
+			Features []string `json:"features"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, "stl", response.Data.Format)
+	assert.Equal(t, []string{"point clouds"}, response.Data.Features)
+	assert.Equal(t, http.StatusOK, convert(points, "ply").Code)
+
+	assert.Equal(t, http.StatusBadRequest, convert(crate, "blend").Code)
+	assert.Equal(t, http.StatusNotFound, convert("missing", "glb").Code)
+}
+
+func TestGetModelLOD(t *testing.T) {
+	api, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	// A closed latitude-longitude sphere of 16 rings and 32 segments
+	var obj strings.Builder
+	const rings, segments = 16, 32
+	obj.WriteString("v 0 0 1\n")
+	for ring := 1; ring < rings; ring++ {
+		theta := math.Pi * float64(ring) / rings
+		for segment := 0; segment < segments; segment++ {
+			phi := 2 * math.Pi * float64(segment) / segments
+			fmt.Fprintf(&obj, "v %g %g %g\n", math.Sin(theta)*math.Cos(phi), math.Sin(theta)*math.Sin(phi), math.Cos(theta))
+		}
+	}
+	obj.WriteString("v 0 0 -1\n")
+	vertex := func(ring, segment int) int {
+		switch ring {
+		case 0:
+			return 1
+		case rings:
+			return 2 + (rings-1)*segments
+		}
+		return 2 + (ring-1)*segments + segment%segments
+	}
+	for ring := 0; ring < rings; ring++ {
+		for segment := 0; segment < segments; segment++ {
+			a, b := vertex(ring, segment), vertex(ring+1, segment)
+			c, d := vertex(ring+1, segment+1), vertex(ring, segment+1)
+			if ring > 0 {
+				fmt.Fprintf(&obj, "f %d %d %d\n", a, b, d)
+			}
+			if ring < rings-1 {
+				fmt.Fprintf(&obj, "f %d %d %d\n", b, c, d)
+			}
+		}
+	}
+	const triangles = 2 * (rings - 1) * segments
+
+	w := uploadModel(t, api, []uploadPart{{"model", "sphere.obj", obj.String()}}, map[string]string{"lods": "true"})
+	sphere := uploadedModel(t, w).ID
+
+	// The levels are built after the upload responds
+	require.NoError(t, api.Stop(context.Background()))
+	req := httptest.NewRequest("GET", "/storage/status", nil)
+	w = httptest.NewRecorder()
+	api.GetStorageStatus(w, req)
+	var status struct {
+		Data core.StorageStatus `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&status))
+	assert.Positive(t, status.Data.AssetSize)
+
+	lod := func(id, n, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/models/"+id+"/lod/"+n+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"id": id, "n": n})
+		w := httptest.NewRecorder()
+		api.GetModelLOD(w, req)
+		return w
+	}
+
+	// Level 0 is the full model and every further level is coarser
+	previous := -1.0
+	for level, ratio := range []float64{1, 0.5, 0.25, 0.1} {
+		w := lod(sphere, strconv.Itoa(level), "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "model/gltf-binary", w.Header().Get("Content-Type"))
+		assert.Equal(t, strconv.Itoa(level), w.Header().Get("X-LOD-Level"))
+
+		vertices := importers.NewVertexImporter()
+		require.NoError(t, vertices.ImportFromGLB(bytes.NewReader(w.Body.Bytes()), nil))
+		count := vertices.GetMesh().TriangleCount()
+		assert.Equal(t, strconv.Itoa(count), w.Header().Get("X-LOD-Triangles"))
+		assert.InDelta(t, ratio*triangles, count, 1)
+
+		lodError, err := strconv.ParseFloat(w.Header().Get("X-LOD-Error"), 64)
+		require.NoError(t, err)
+		assert.Greater(t, lodError, previous)
+		assert.Less(t, lodError, 0.2)
+		previous = lodError
+	}
+
+	w = lod(sphere, "2", "?format=stl")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "sphere_lod2.stl")
+	assert.True(t, importers.IsBinarySTL(w.Body.Bytes()))
+
+	assert.Equal(t, http.StatusBadRequest, lod(sphere, "first", "").Code)
+	assert.Equal(t, http.StatusBadRequest, lod(sphere, "1", "?format=blend").Code)
+	assert.Equal(t, http.StatusNotFound, lod(sphere, "4", "").Code)
+	assert.Equal(t, http.StatusNotFound, lod("missing", "1", "").Code)
+
+	// Ratios must leave something to simplify
+	assert.Error(t, api.SetLODs([]float64{0.5, 1}, false))
+	assert.Error(t, api.SetLODs([]float64{0}, false))
+	require.NoError(t, api.SetLODs([]float64{0.2}, false))
+	w = lod(sphere, "1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, strconv.Itoa(int(math.Ceil(0.2*triangles))), w.Header().Get("X-LOD-Triangles"))
 }
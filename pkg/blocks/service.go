@@ -3,7 +3,6 @@ package blocks
 import (
 	"context"
 	"encoding/binary"
-	"errors"
 	"io"
 	"math"
 
@@ -26,23 +25,20 @@ func NewService(store *Store) *Service {
 func (s *Service) ProcessModelData(ctx context.Context, format string, reader io.Reader) ([]string, error) {
 	// Import vertices based on format
 	importer := importers.NewVertexImporter()
-	var err error
-
-	switch format {
-	case "obj":
-		err = importer.ImportFromOBJ(reader)
-	case "fbx":
-		err = importer.ImportFromFBX(reader)
-	default:
-		return nil, errors.New("unsupported format")
-	}
-
-	if err != nil {
+	if err := importer.Import(format, reader, importers.ImportOptions{}); err != nil {
 		return nil, err
 	}
 
-	// Get vertices and store them as blocks
-	vertices := importer.GetVertices()
+	// Expand the welded triangles to their corners in draw order; point
+	// clouds have no indices and keep their vertices as is
+	mesh := importer.GetMesh()
+	vertices := mesh.Vertices
+	if mesh.Primitive == importers.PrimitiveTriangles {
+		vertices = make([]importers.Vertex, len(mesh.Indices))
+		for i, index := range mesh.Indices {
+			vertices[i] = mesh.Vertices[index]
+		}
+	}
 	blocks := make([]string, 0, len(vertices))
 
 	// Store each vertex as a separate block
@@ -77,7 +73,11 @@ func (s *Service) GetModelBlocks(ctx context.Context, hashes []string) ([]*Block
 func encodeVertex(vertex importers.Vertex) []byte {
 	// Simple encoding: just concatenate all float64 values
 	// In a real implementation, you'd want to use a proper serialization format
-	data := make([]byte, 8*8) // 8 float64s (3 position + 3 normal + 2 texcoord)
+	size := 8 * 8 // 8 float64s (3 position + 3 normal + 2 texcoord)
+	if vertex.HasColor {
+		size += 4 * 8 // RGBA color
+	}
+	data := make([]byte, size)
 	binary.LittleEndian.PutUint64(data[0:8], math.Float64bits(vertex.Position[0]))
 	binary.LittleEndian.PutUint64(data[8:16], math.Float64bits(vertex.Position[1]))
 	binary.LittleEndian.PutUint64(data[16:24], math.Float64bits(vertex.Position[2]))
@@ -86,6 +86,11 @@ func encodeVertex(vertex importers.Vertex) []byte {
 	binary.LittleEndian.PutUint64(data[40:48], math.Float64bits(vertex.Normal[2]))
 	binary.LittleEndian.PutUint64(data[48:56], math.Float64bits(vertex.TexCoords[0]))
 	binary.LittleEndian.PutUint64(data[56:64], math.Float64bits(vertex.TexCoords[1]))
+	if vertex.HasColor {
+		for i, c := range vertex.Color {
+			binary.LittleEndian.PutUint64(data[64+i*8:], math.Float64bits(c))
+		}
+	}
 	return data
 }
 
@@ -5,9 +5,14 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
+
+	"github.com/3FT-io/3DS/pkg/config"
+	"github.com/3FT-io/3DS/pkg/tiering"
 )
 
 // Block represents a chunk of 3D model data
@@ -19,21 +24,53 @@ type Block struct {
 
 // Store manages the storage of model data blocks
 type Store struct {
-	basePath string
-	mu       sync.RWMutex
+	tiers   []config.StorageTier
+	tracker *tiering.Tracker
+	policy  tiering.Policy
+	mu      sync.RWMutex
 }
 
 // NewStore creates a new block store instance
 func NewStore(basePath string) (*Store, error) {
-	if err := os.MkdirAll(basePath, 0755); err != nil {
-		return nil, err
+	return NewTieredStore([]config.StorageTier{{Name: "default", Path: basePath}})
+}
+
+// NewTieredStore creates a block store spread over the given tiers,
+// ordered from hottest to coldest
+func NewTieredStore(tiers []config.StorageTier) (*Store, error) {
+	if len(tiers) == 0 {
+		return nil, errors.New("at least one storage tier is required")
+	}
+
+	for _, tier := range tiers {
+		if err := os.MkdirAll(tier.Path, 0755); err != nil {
+			return nil, err
+		}
 	}
 
 	return &Store{
-		basePath: basePath,
+		tiers:   tiers,
+		tracker: tiering.NewTracker(),
+		policy: tiering.Policy{
+			PromoteThreshold: 3,
+			DemoteAfter:      24 * time.Hour,
+		},
 	}, nil
 }
 
+// SetTierPolicy sets the policy used to promote and demote blocks
+func (s *Store) SetTierPolicy(policy tiering.Policy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.policy = policy
+}
+
+// Tracker returns the access tracker used for tiering decisions
+func (s *Store) Tracker() *tiering.Tracker {
+	return s.tracker
+}
+
 // StoreBlock stores a block of data and returns its hash
 func (s *Store) StoreBlock(ctx context.Context, data []byte) (string, error) {
 	s.mu.Lock()
@@ -41,17 +78,17 @@ func (s *Store) StoreBlock(ctx context.Context, data []byte) (string, error) {
 
 	// Calculate hash
 	hash := calculateHash(data)
-	blockPath := s.getBlockPath(hash)
 
-	// Check if block already exists
-	if _, err := os.Stat(blockPath); err == nil {
+	// Check if block already exists in any tier
+	if _, ok := s.locateBlock(hash); ok {
 		return hash, nil
 	}
 
-	// Create block file
-	if err := os.WriteFile(blockPath, data, 0644); err != nil {
+	// Create block file in the hottest tier
+	if err := os.WriteFile(s.getBlockPath(0, hash), data, 0644); err != nil {
 		return "", err
 	}
+	s.tracker.Seed(hash)
 
 	return hash, nil
 }
@@ -61,14 +98,16 @@ func (s *Store) GetBlock(ctx context.Context, hash string) (*Block, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	blockPath := s.getBlockPath(hash)
-	data, err := os.ReadFile(blockPath)
+	tier, ok := s.locateBlock(hash)
+	if !ok {
+		return nil, errors.New("block not found")
+	}
+
+	data, err := os.ReadFile(s.getBlockPath(tier, hash))
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, errors.New("block not found")
-		}
 		return nil, err
 	}
+	s.tracker.Touch(hash)
 
 	return &Block{
 		Hash: hash,
@@ -82,12 +121,86 @@ func (s *Store) DeleteBlock(ctx context.Context, hash string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	blockPath := s.getBlockPath(hash)
-	return os.Remove(blockPath)
+	tier, ok := s.locateBlock(hash)
+	if !ok {
+		return errors.New("block not found")
+	}
+	s.tracker.Forget(hash)
+
+	return os.Remove(s.getBlockPath(tier, hash))
+}
+
+// Rebalance promotes frequently read blocks to hotter tiers and demotes
+// idle ones to colder tiers
+func (s *Store) Rebalance(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.tracker.ResetCounts()
+
+	if len(s.tiers) < 2 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	moved := 0
+	visited := make(map[string]bool)
+
+	for current := range s.tiers {
+		entries, err := os.ReadDir(s.tiers[current].Path)
+		if err != nil {
+			return moved, err
+		}
+
+		for _, entry := range entries {
+			select {
+			case <-ctx.Done():
+				return moved, ctx.Err()
+			default:
+			}
+
+			if entry.IsDir() {
+				continue
+			}
+
+			hash := entry.Name()
+			if visited[hash] {
+				continue
+			}
+			visited[hash] = true
+
+			// Blocks written before this process started have no history yet
+			s.tracker.Seed(hash)
+
+			target := s.policy.Target(current, len(s.tiers), s.tracker.Stats(hash), now)
+			if target == current {
+				continue
+			}
+
+			if err := tiering.CopyPath(s.getBlockPath(current, hash), s.getBlockPath(target, hash)); err != nil {
+				return moved, fmt.Errorf("failed to move block %s to tier %s: %w", hash, s.tiers[target].Name, err)
+			}
+			if err := os.Remove(s.getBlockPath(current, hash)); err != nil {
+				return moved, err
+			}
+			moved++
+		}
+	}
+
+	return moved, nil
+}
+
+// locateBlock returns the index of the tier holding the given block
+func (s *Store) locateBlock(hash string) (int, bool) {
+	for i := range s.tiers {
+		if tiering.Exists(s.getBlockPath(i, hash)) {
+			return i, true
+		}
+	}
+	return 0, false
 }
 
-func (s *Store) getBlockPath(hash string) string {
-	return filepath.Join(s.basePath, hash)
+func (s *Store) getBlockPath(tier int, hash string) string {
+	return filepath.Join(s.tiers[tier].Path, hash)
 }
 
 func calculateHash(data []byte) string {
@@ -0,0 +1,48 @@
+package blocks_test
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/3FT-io/3DS/pkg/blocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessModelDataStoresTriangleCorners(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "3ds-blocks-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	store, err := blocks.NewStore(tmpDir)
+	require.NoError(t, err)
+	service := blocks.NewService(store)
+	ctx := context.Background()
+
+	// A quad of four welded vertices is stored as two triangles of
+	// three corners each
+	quad := "v 0 0 0\nv 1 0 0\nv 1 1 0\nv 0 1 0\nf 1 2 3 4\n"
+	hashes, err := service.ProcessModelData(ctx, "obj", strings.NewReader(quad))
+	require.NoError(t, err)
+	require.Len(t, hashes, 6)
+	assert.Equal(t, hashes[0], hashes[3])
+	assert.Equal(t, hashes[2], hashes[4])
+
+	stored, err := service.GetModelBlocks(ctx, hashes)
+	require.NoError(t, err)
+	require.Len(t, stored, 6)
+	position := func(block *blocks.Block) [3]float64 {
+		var p [3]float64
+		for i := range p {
+			p[i] = math.Float64frombits(binary.LittleEndian.Uint64(block.Data[i*8:]))
+		}
+		return p
+	}
+	assert.Equal(t, [3]float64{0, 0, 0}, position(stored[0]))
+	assert.Equal(t, [3]float64{1, 1, 0}, position(stored[2]))
+	assert.Equal(t, [3]float64{0, 1, 0}, position(stored[5]))
+}
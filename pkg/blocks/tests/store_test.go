@@ -0,0 +1,57 @@
+package blocks_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/3FT-io/3DS/pkg/blocks"
+	"github.com/3FT-io/3DS/pkg/config"
+	"github.com/3FT-io/3DS/pkg/tiering"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTieredStoreTracksBlockReads(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "3ds-blocks-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	tiers := []config.StorageTier{
+		{Name: "hot", Path: filepath.Join(tmpDir, "hot")},
+		{Name: "cold", Path: filepath.Join(tmpDir, "cold")},
+	}
+	store, err := blocks.NewTieredStore(tiers)
+	require.NoError(t, err)
+	store.SetTierPolicy(tiering.Policy{PromoteThreshold: 2, DemoteAfter: time.Hour})
+
+	ctx := context.Background()
+
+	// Pretend the block was stored two hours ago
+	store.Tracker().SetClock(func() time.Time { return time.Now().Add(-2 * time.Hour) })
+	hash, err := store.StoreBlock(ctx, []byte("block data"))
+	require.NoError(t, err)
+	store.Tracker().SetClock(time.Now)
+
+	moved, err := store.Rebalance(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, moved)
+	assert.NoFileExists(t, filepath.Join(tiers[0].Path, hash))
+	assert.FileExists(t, filepath.Join(tiers[1].Path, hash))
+
+	// Reads stay transparent and count towards promotion
+	for i := 0; i < 2; i++ {
+		block, err := store.GetBlock(ctx, hash)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("block data"), block.Data)
+	}
+	assert.Equal(t, 2, store.Tracker().Stats(hash).Count)
+
+	moved, err = store.Rebalance(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, moved)
+	assert.FileExists(t, filepath.Join(tiers[0].Path, hash))
+	assert.NoFileExists(t, filepath.Join(tiers[1].Path, hash))
+}
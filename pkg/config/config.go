@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 type Config struct {
 	// Node configuration
 	NodeID        string
@@ -10,19 +12,69 @@ type Config struct {
 	StoragePath string
 	MaxSize     int64
 
+	// Storage tiering configuration. StorageTiers are ordered from hottest
+	// to coldest; when empty, StoragePath is used as the only tier.
+	StorageTiers         []StorageTier
+	TierPromoteThreshold int
+	TierDemoteAfter      time.Duration
+
+	// Maintenance configuration
+	MaintenanceInterval time.Duration
+	RetentionRules      []RetentionRule
+
 	// P2P configuration
 	BootstrapPeers []string
 
-	// API configuration
-	APIPort int
+	// API configuration. MaxUploadSize bounds the request body of an
+	// upload and MaxBundleSize the unpacked size of a bundle.
+	APIPort       int
+	MaxUploadSize int64
+	MaxBundleSize int64
+
+	// Level of detail configuration. LODRatios are the fractions of the
+	// triangles kept by levels 1 and up; level 0 is the full model. Levels
+	// are built on first request unless GenerateLODs builds them at upload.
+	LODRatios    []float64
+	GenerateLODs bool
+}
+
+// StorageTier describes a storage directory and its capacity
+type StorageTier struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	MaxSize int64  `json:"max_size"` // 0 means unlimited
+}
+
+// RetentionRule limits how long models are kept. Empty Owner and Format
+// fields match any model.
+type RetentionRule struct {
+	Owner       string        `json:"owner,omitempty"`
+	Format      string        `json:"format,omitempty"`
+	MaxVersions int           `json:"max_versions,omitempty"` // newest models kept per name, 0 means unlimited
+	MaxAge      time.Duration `json:"max_age,omitempty"`      // 0 means unlimited
 }
 
 func DefaultConfig() *Config {
 	return &Config{
-		ListenAddress: "0.0.0.0",
-		Port:          4001,
-		StoragePath:   "./storage",
-		MaxSize:       1024 * 1024 * 1024 * 100, // 100GB
-		APIPort:       8080,
+		ListenAddress:        "0.0.0.0",
+		Port:                 4001,
+		StoragePath:          "./storage",
+		MaxSize:              1024 * 1024 * 1024 * 100, // 100GB
+		TierPromoteThreshold: 3,
+		TierDemoteAfter:      24 * time.Hour,
+		MaintenanceInterval:  time.Minute,
+		APIPort:              8080,
+		MaxUploadSize:        64 << 20,
+		MaxBundleSize:        128 << 20,
+		LODRatios:            []float64{0.5, 0.25, 0.1},
+	}
+}
+
+// Tiers returns the configured storage tiers, falling back to a single
+// tier rooted at StoragePath
+func (c *Config) Tiers() []StorageTier {
+	if len(c.StorageTiers) > 0 {
+		return c.StorageTiers
 	}
+	return []StorageTier{{Name: "default", Path: c.StoragePath, MaxSize: c.MaxSize}}
 }
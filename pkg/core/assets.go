@@ -0,0 +1,437 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/3FT-io/3DS/pkg/textures"
+)
+
+// ErrAssetNotFound is returned for unknown asset hashes
+var ErrAssetNotFound = errors.New("asset not found")
+
+// assetGracePeriod is how long an asset stays after it was last stored
+// before it may be removed while unreferenced, so that uploads storing
+// assets before linking them to their model do not lose them
+const assetGracePeriod = time.Hour
+
+// TextureAsset is a texture image of a bundle, stored as a content-addressed
+// asset along with its mipmaps
+type TextureAsset struct {
+	// Path is the texture's file in the bundle
+	Path string `json:"path"`
+	// Materials names the materials using the texture
+	Materials []string `json:"materials"`
+	textures.Info
+	// Variants holds the original image followed by its mipmaps, largest
+	// first
+	Variants []TextureVariant `json:"variants"`
+}
+
+// TextureVariant is one stored size of a texture
+type TextureVariant struct {
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Hash   string `json:"hash"`
+	Size   int64  `json:"size"`
+}
+
+// Variant returns the largest variant whose width and height fit in
+// maxSize, or the smallest one if none does. A maxSize of zero selects the
+// original image.
+func (t *TextureAsset) Variant(maxSize int) TextureVariant {
+	if maxSize <= 0 {
+		return t.Variants[0]
+	}
+	for _, variant := range t.Variants {
+		if variant.Width <= maxSize && variant.Height <= maxSize {
+			return variant
+		}
+	}
+	return t.Variants[len(t.Variants)-1]
+}
+
+// assetPath returns the file holding the asset with the given hash
+func (s *Storage) assetPath(hash string) (string, error) {
+	if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != sha256.Size {
+		return "", fmt.Errorf("%w: %s", ErrAssetNotFound, hash)
+	}
+	return filepath.Join(s.basePath, "assets", hash[:2], hash), nil
+}
+
+// StoreAsset stores data under its SHA-256 hash and returns the hash.
+// Identical data is stored once.
+func (s *Storage) StoreAsset(ctx context.Context, data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	path, err := s.assetPath(hash)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := os.Stat(path); err == nil {
+		// Restart the grace period for the new reference
+		now := time.Now()
+		return hash, os.Chtimes(path, now, now)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+
+	// Write to a temporary file first so readers never see partial data
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	s.assetSize += int64(len(data))
+	return hash, nil
+}
+
+// walkAssets calls fn for every stored asset
+func (s *Storage) walkAssets(fn func(hash string, info os.FileInfo) error) error {
+	dirs, err := os.ReadDir(filepath.Join(s.basePath, "assets"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(s.basePath, "assets", dir.Name()))
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if _, err := s.assetPath(entry.Name()); err != nil {
+				continue // temporary files
+			}
+			info, err := entry.Info()
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := fn(entry.Name(), info); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// modelAssets returns the hashes of the texture variants and derived
+// artifacts of a model
+func (s *Storage) modelAssets(model *ModelMetadata) []string {
+	var hashes []string
+	for _, texture := range model.Textures {
+		for _, variant := range texture.Variants {
+			hashes = append(hashes, variant.Hash)
+		}
+	}
+	if model.Hash == "" {
+		return hashes
+	}
+	links, _ := filepath.Glob(filepath.Join(s.basePath, "derived", model.Hash, "*.json"))
+	for _, link := range links {
+		if artifact, err := readDerived(link); err == nil {
+			hashes = append(hashes, artifact.Hash)
+		}
+	}
+	return hashes
+}
+
+// modelHashes returns the content hashes of the stored models
+func (s *Storage) modelHashes() map[string]bool {
+	hashes := make(map[string]bool, len(s.metadata))
+	for _, model := range s.metadata {
+		if model.Hash != "" {
+			hashes[model.Hash] = true
+		}
+	}
+	return hashes
+}
+
+// referencedAssets returns the hashes of the assets used by any model's
+// textures or derived artifacts. Artifacts derived from models that no
+// longer exist do not count.
+func (s *Storage) referencedAssets() (map[string]bool, error) {
+	refs := make(map[string]bool)
+	models := s.modelHashes()
+	for _, model := range s.metadata {
+		for _, texture := range model.Textures {
+			for _, variant := range texture.Variants {
+				refs[variant.Hash] = true
+			}
+		}
+	}
+
+	links, err := filepath.Glob(filepath.Join(s.basePath, "derived", "*", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		if !models[filepath.Base(filepath.Dir(link))] {
+			continue
+		}
+		artifact, err := readDerived(link)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		refs[artifact.Hash] = true
+	}
+	return refs, nil
+}
+
+// removeAssets removes those of the given assets that are no longer
+// referenced and were last stored before the given time. It returns the
+// number of assets removed.
+func (s *Storage) removeAssets(hashes []string, before time.Time) (int, error) {
+	if len(hashes) == 0 {
+		return 0, nil
+	}
+	refs, err := s.referencedAssets()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, hash := range hashes {
+		if refs[hash] {
+			continue
+		}
+		path, err := s.assetPath(hash)
+		if err != nil {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Before(before) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		s.assetSize -= info.Size()
+		removed++
+	}
+	return removed, nil
+}
+
+// CollectAssets removes the assets that no model references anymore, such
+// as artifacts replaced by newer ones, once they are older than the grace
+// period. Links to artifacts derived from models that no longer exist are
+// removed as well. It returns the number of assets removed.
+func (s *Storage) CollectAssets(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dirs, err := os.ReadDir(filepath.Join(s.basePath, "derived"))
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	models := s.modelHashes()
+	for _, dir := range dirs {
+		if dir.IsDir() && !models[dir.Name()] {
+			if err := os.RemoveAll(filepath.Join(s.basePath, "derived", dir.Name())); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	var hashes []string
+	err = s.walkAssets(func(hash string, info os.FileInfo) error {
+		hashes = append(hashes, hash)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return s.removeAssets(hashes, time.Now().Add(-assetGracePeriod))
+}
+
+// GetAsset returns the data stored under the given hash
+func (s *Storage) GetAsset(ctx context.Context, hash string) ([]byte, error) {
+	path, err := s.assetPath(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrAssetNotFound, hash)
+	}
+	return data, err
+}
+
+// StoreTexture decodes a PNG or JPEG texture and stores it together with
+// its mipmaps, encoded in the format of the original
+func (s *Storage) StoreTexture(ctx context.Context, path string, data []byte) (*TextureAsset, error) {
+	img, info, err := textures.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := s.StoreAsset(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	asset := &TextureAsset{
+		Path:     path,
+		Info:     info,
+		Variants: []TextureVariant{{Width: info.Width, Height: info.Height, Hash: hash, Size: int64(len(data))}},
+	}
+
+	for _, level := range textures.Mipmaps(img) {
+		encoded, err := textures.Encode(level, info.Format)
+		if err != nil {
+			return nil, err
+		}
+		hash, err := s.StoreAsset(ctx, encoded)
+		if err != nil {
+			return nil, err
+		}
+		asset.Variants = append(asset.Variants, TextureVariant{
+			Width:  level.Bounds().Dx(),
+			Height: level.Bounds().Dy(),
+			Hash:   hash,
+			Size:   int64(len(encoded)),
+		})
+	}
+
+	return asset, nil
+}
+
+// DerivedArtifact is a file generated from a model, such as a conversion
+// to another format, stored as an asset and linked to the model's hash
+type DerivedArtifact struct {
+	// Kind names how the artifact was made, e.g. "convert/glb"
+	Kind string `json:"kind"`
+	// Source is the hash of the model it was derived from
+	Source    string    `json:"source"`
+	Name      string    `json:"name"`
+	Hash      string    `json:"hash"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	// Triangles is the triangle count of the derived mesh and Error the
+	// simplification error of a level of detail, in model units
+	Triangles int     `json:"triangles,omitempty"`
+	Error     float64 `json:"error,omitempty"`
+	// Dropped lists the features of the model the format cannot hold
+	Dropped []string `json:"dropped,omitempty"`
+}
+
+// derivedPath returns the file linking a model hash to its artifact of the
+// given kind
+func (s *Storage) derivedPath(source, kind string) (string, error) {
+	if decoded, err := hex.DecodeString(source); err != nil || len(decoded) != sha256.Size {
+		return "", fmt.Errorf("%w: %s", ErrAssetNotFound, source)
+	}
+	return filepath.Join(s.basePath, "derived", source, url.PathEscape(kind)+".json"), nil
+}
+
+// StoreDerived stores data as an asset and links it to the artifact's
+// source model hash under its kind, replacing an earlier artifact of the
+// same kind. The hash, size and creation time are filled in. It fails with
+// ErrModelNotFound once no model has the source hash, so that artifacts
+// built in the background are not linked after their model was deleted.
+func (s *Storage) StoreDerived(ctx context.Context, artifact DerivedArtifact, data []byte) (*DerivedArtifact, error) {
+	path, err := s.derivedPath(artifact.Source, artifact.Kind)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if artifact.Hash, err = s.StoreAsset(ctx, data); err != nil {
+		return nil, err
+	}
+	artifact.Size = int64(len(data))
+	artifact.CreatedAt = time.Now()
+
+	link, err := json.Marshal(artifact)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The asset stored above is left to the collector in this case
+	if !s.modelHashes()[artifact.Source] {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotFound, artifact.Source)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	replaced, _ := readDerived(path)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, link, 0644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	if replaced != nil && replaced.Hash != artifact.Hash {
+		if _, err := s.removeAssets([]string{replaced.Hash}, time.Now().Add(-assetGracePeriod)); err != nil {
+			return nil, err
+		}
+	}
+	return &artifact, nil
+}
+
+// GetDerived returns the artifact of the given kind derived from the
+// source model hash
+func (s *Storage) GetDerived(ctx context.Context, source, kind string) (*DerivedArtifact, error) {
+	path, err := s.derivedPath(source, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	artifact, err := readDerived(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s %s", ErrAssetNotFound, kind, source)
+	}
+	return artifact, err
+}
+
+// readDerived reads the artifact link stored at path
+func readDerived(path string) (*DerivedArtifact, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var artifact DerivedArtifact
+	if err := json.Unmarshal(data, &artifact); err != nil {
+		return nil, err
+	}
+	return &artifact, nil
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// EventType identifies the kind of storage event
+type EventType string
+
+const (
+	EventModelExpired EventType = "model_expired"
+)
+
+// Event describes something that happened to a stored model
+type Event struct {
+	Type    EventType `json:"type"`
+	ModelID string    `json:"model_id"`
+	Name    string    `json:"name,omitempty"`
+	Reason  string    `json:"reason,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// EventBus fans out events to subscribers
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers []chan Event
+}
+
+// NewEventBus creates a new event bus instance
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe returns a channel receiving all future events. Events are
+// dropped for subscribers whose buffer is full.
+func (b *EventBus) Subscribe(buffer int) <-chan Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, buffer)
+	b.subscribers = append(b.subscribers, ch)
+	return ch
+}
+
+// Publish sends an event to all subscribers without blocking
+func (b *EventBus) Publish(event Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
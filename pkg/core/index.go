@@ -0,0 +1,384 @@
+package core
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+// Sort orders supported by model queries
+const (
+	SortByName      = "name"
+	SortBySize      = "size"
+	SortByCreatedAt = "created_at"
+)
+
+const (
+	DefaultQueryLimit = 100
+	MaxQueryLimit     = 1000
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidSort   = errors.New("invalid sort field")
+)
+
+// ModelQuery describes a filtered, sorted and paginated model listing.
+// Zero values leave the corresponding filter unset.
+type ModelQuery struct {
+	Name          string // case-insensitive substring match
+	NamePrefix    string // case-insensitive prefix match
+	Format        string
+	Owner         string
+	Tags          []string          // models must carry all of these tags
+	Attributes    map[string]string // models must carry all of these attribute values
+	MinSize       int64
+	MaxSize       int64
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// Geometry filters only match models with mesh statistics
+	MinVertices  int
+	MaxVertices  int
+	MinTriangles int
+	MaxTriangles int
+
+	SortBy     string
+	Descending bool
+	Limit      int
+	Cursor     string
+}
+
+// ModelPage is a single page of query results
+type ModelPage struct {
+	Models     []ModelMetadata `json:"models"`
+	Total      int             `json:"total"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// indexEntry holds the indexed fields of a model, so that stale postings
+// can be removed after the metadata has been changed in place
+type indexEntry struct {
+	id         string
+	name       string
+	format     string
+	owner      string
+	tags       []string
+	attributes map[string]string
+	size       int64
+	createdAt  time.Time
+	geometry   *importers.MeshStats
+}
+
+// modelIndex maintains secondary indexes over model metadata
+type modelIndex struct {
+	mu       sync.Mutex
+	entries  map[string]*indexEntry
+	byFormat map[string]map[string]struct{}
+	byOwner  map[string]map[string]struct{}
+	byTag    map[string]map[string]struct{}
+	byAttr   map[string]map[string]struct{} // attributeKey(key, value) -> model IDs
+
+	// Sorted views, rebuilt lazily after changes
+	dirty     bool
+	byName    []*indexEntry
+	bySize    []*indexEntry
+	byCreated []*indexEntry
+}
+
+func newModelIndex() *modelIndex {
+	return &modelIndex{
+		entries:  make(map[string]*indexEntry),
+		byFormat: make(map[string]map[string]struct{}),
+		byOwner:  make(map[string]map[string]struct{}),
+		byTag:    make(map[string]map[string]struct{}),
+		byAttr:   make(map[string]map[string]struct{}),
+	}
+}
+
+// add indexes a model, replacing any previous entry with the same ID
+func (idx *modelIndex) add(model *ModelMetadata) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.removeLocked(model.ID)
+
+	entry := &indexEntry{
+		id:         model.ID,
+		name:       strings.ToLower(model.Name),
+		format:     model.Format,
+		owner:      model.Owner,
+		tags:       append([]string(nil), model.Tags...),
+		attributes: make(map[string]string, len(model.Attributes)),
+		size:       model.Size,
+		createdAt:  model.CreatedAt,
+		geometry:   model.Geometry,
+	}
+
+	idx.entries[model.ID] = entry
+	addPosting(idx.byFormat, entry.format, entry.id)
+	addPosting(idx.byOwner, entry.owner, entry.id)
+	for _, tag := range entry.tags {
+		addPosting(idx.byTag, tag, entry.id)
+	}
+	for key, value := range model.Attributes {
+		entry.attributes[key] = value
+		addPosting(idx.byAttr, attributeKey(key, value), entry.id)
+	}
+	idx.dirty = true
+}
+
+// remove drops a model from the index
+func (idx *modelIndex) remove(modelID string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.removeLocked(modelID)
+}
+
+func (idx *modelIndex) removeLocked(modelID string) {
+	entry, ok := idx.entries[modelID]
+	if !ok {
+		return
+	}
+
+	delete(idx.entries, modelID)
+	removePosting(idx.byFormat, entry.format, modelID)
+	removePosting(idx.byOwner, entry.owner, modelID)
+	for _, tag := range entry.tags {
+		removePosting(idx.byTag, tag, modelID)
+	}
+	for key, value := range entry.attributes {
+		removePosting(idx.byAttr, attributeKey(key, value), modelID)
+	}
+	idx.dirty = true
+}
+
+// rebuild refreshes the sorted views
+func (idx *modelIndex) rebuild() {
+	if !idx.dirty {
+		return
+	}
+
+	all := make([]*indexEntry, 0, len(idx.entries))
+	for _, entry := range idx.entries {
+		all = append(all, entry)
+	}
+
+	idx.byName = sortedEntries(all, func(a, b *indexEntry) bool { return a.name < b.name })
+	idx.bySize = sortedEntries(all, func(a, b *indexEntry) bool { return a.size < b.size })
+	idx.byCreated = sortedEntries(all, func(a, b *indexEntry) bool { return a.createdAt.Before(b.createdAt) })
+	idx.dirty = false
+}
+
+// search returns the IDs of all models matching the query filters
+func (idx *modelIndex) search(query ModelQuery) []string {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.rebuild()
+
+	// Narrow down candidates using the most selective indexes first
+	var candidates map[string]struct{}
+	narrow := func(ids map[string]struct{}) {
+		if candidates == nil {
+			candidates = make(map[string]struct{}, len(ids))
+			for id := range ids {
+				candidates[id] = struct{}{}
+			}
+			return
+		}
+		for id := range candidates {
+			if _, ok := ids[id]; !ok {
+				delete(candidates, id)
+			}
+		}
+	}
+
+	if query.Format != "" {
+		narrow(idx.byFormat[query.Format])
+	}
+	if query.Owner != "" {
+		narrow(idx.byOwner[query.Owner])
+	}
+	for _, tag := range query.Tags {
+		narrow(idx.byTag[tag])
+	}
+	for key, value := range query.Attributes {
+		narrow(idx.byAttr[attributeKey(key, value)])
+	}
+	if query.NamePrefix != "" {
+		prefix := strings.ToLower(query.NamePrefix)
+		start := sort.Search(len(idx.byName), func(i int) bool { return idx.byName[i].name >= prefix })
+		ids := make(map[string]struct{})
+		for i := start; i < len(idx.byName) && strings.HasPrefix(idx.byName[i].name, prefix); i++ {
+			ids[idx.byName[i].id] = struct{}{}
+		}
+		narrow(ids)
+	}
+	if query.MinSize > 0 || query.MaxSize > 0 {
+		start := sort.Search(len(idx.bySize), func(i int) bool { return idx.bySize[i].size >= query.MinSize })
+		ids := make(map[string]struct{})
+		for i := start; i < len(idx.bySize) && (query.MaxSize <= 0 || idx.bySize[i].size <= query.MaxSize); i++ {
+			ids[idx.bySize[i].id] = struct{}{}
+		}
+		narrow(ids)
+	}
+	if !query.CreatedAfter.IsZero() || !query.CreatedBefore.IsZero() {
+		start := sort.Search(len(idx.byCreated), func(i int) bool {
+			return !idx.byCreated[i].createdAt.Before(query.CreatedAfter)
+		})
+		ids := make(map[string]struct{})
+		for i := start; i < len(idx.byCreated); i++ {
+			if !query.CreatedBefore.IsZero() && !idx.byCreated[i].createdAt.Before(query.CreatedBefore) {
+				break
+			}
+			ids[idx.byCreated[i].id] = struct{}{}
+		}
+		narrow(ids)
+	}
+
+	// Substring search and geometry ranges cannot use an index and are
+	// applied to what is left
+	name := strings.ToLower(query.Name)
+	matches := func(entry *indexEntry) bool {
+		return strings.Contains(entry.name, name) && query.matchesGeometry(entry.geometry)
+	}
+	var result []string
+	if candidates == nil {
+		for id, entry := range idx.entries {
+			if matches(entry) {
+				result = append(result, id)
+			}
+		}
+		return result
+	}
+	for id := range candidates {
+		if matches(idx.entries[id]) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// matchesGeometry reports whether mesh statistics satisfy the geometry
+// filters of the query. Zero bounds are unset.
+func (q ModelQuery) matchesGeometry(stats *importers.MeshStats) bool {
+	if q.MinVertices <= 0 && q.MaxVertices <= 0 && q.MinTriangles <= 0 && q.MaxTriangles <= 0 {
+		return true
+	}
+	if stats == nil {
+		return false
+	}
+	inRange := func(v, lo, hi int) bool {
+		return v >= lo && (hi <= 0 || v <= hi)
+	}
+	return inRange(stats.Vertices, q.MinVertices, q.MaxVertices) &&
+		inRange(stats.Triangles, q.MinTriangles, q.MaxTriangles)
+}
+
+// attributeKey joins an attribute key and value with a separator that
+// cannot appear in form values, so that distinct pairs never collide
+func attributeKey(key, value string) string {
+	return key + "\x00" + value
+}
+
+func addPosting(postings map[string]map[string]struct{}, key, id string) {
+	if postings[key] == nil {
+		postings[key] = make(map[string]struct{})
+	}
+	postings[key][id] = struct{}{}
+}
+
+func removePosting(postings map[string]map[string]struct{}, key, id string) {
+	delete(postings[key], id)
+	if len(postings[key]) == 0 {
+		delete(postings, key)
+	}
+}
+
+func sortedEntries(entries []*indexEntry, less func(a, b *indexEntry) bool) []*indexEntry {
+	sorted := make([]*indexEntry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool {
+		return less(sorted[i], sorted[j])
+	})
+	return sorted
+}
+
+// queryCursor records the position of the last model on a page
+type queryCursor struct {
+	Name      string    `json:"n,omitempty"`
+	Size      int64     `json:"s,omitempty"`
+	CreatedAt time.Time `json:"c,omitempty"`
+	ID        string    `json:"id"`
+}
+
+func encodeCursor(model *ModelMetadata) string {
+	data, _ := json.Marshal(queryCursor{
+		Name:      model.Name,
+		Size:      model.Size,
+		CreatedAt: model.CreatedAt,
+		ID:        model.ID,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor string) (*ModelMetadata, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c queryCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	return &ModelMetadata{ID: c.ID, Name: c.Name, Size: c.Size, CreatedAt: c.CreatedAt}, nil
+}
+
+// modelLess returns the ordering used to sort query results. Ties are broken
+// by ID so that cursors are stable.
+func modelLess(sortBy string, descending bool) (func(a, b *ModelMetadata) bool, error) {
+	var compare func(a, b *ModelMetadata) int
+
+	switch sortBy {
+	case SortByName:
+		compare = func(a, b *ModelMetadata) int {
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		}
+	case SortBySize:
+		compare = func(a, b *ModelMetadata) int {
+			switch {
+			case a.Size < b.Size:
+				return -1
+			case a.Size > b.Size:
+				return 1
+			}
+			return 0
+		}
+	case SortByCreatedAt, "":
+		compare = func(a, b *ModelMetadata) int {
+			return a.CreatedAt.Compare(b.CreatedAt)
+		}
+	default:
+		return nil, ErrInvalidSort
+	}
+
+	return func(a, b *ModelMetadata) bool {
+		c := compare(a, b)
+		if c == 0 {
+			c = strings.Compare(a.ID, b.ID)
+		}
+		if descending {
+			return c > 0
+		}
+		return c < 0
+	}, nil
+}
@@ -2,20 +2,209 @@ package core
 
 import (
 	"crypto/sha256"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"time"
+
+	"github.com/3FT-io/3DS/pkg/importers"
 )
 
 type ModelMetadata struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Format      string    `json:"format"`
-	Size        int64     `json:"size"`
-	Hash        string    `json:"hash"`
-	Chunks      []string  `json:"chunks"`
-	CreatedAt   time.Time `json:"created_at"`
-	Owner       string    `json:"owner"`
-	Permissions []string  `json:"permissions"`
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Format    string    `json:"format"`
+	Size      int64     `json:"size"`
+	Hash      string    `json:"hash"`
+	Chunks    []string  `json:"chunks"`
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt orders changes to the metadata replicated between peers
+	UpdatedAt   time.Time         `json:"updated_at"`
+	ExpiresAt   *time.Time        `json:"expires_at,omitempty"`
+	Owner       string            `json:"owner"`
+	Permissions []string          `json:"permissions"`
+	Tags        []string          `json:"tags,omitempty"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	// Geometry holds mesh statistics computed at upload, or nil if the
+	// model could not be imported
+	Geometry *importers.MeshStats `json:"geometry,omitempty"`
+	// Validation is the mesh validation report, served separately
+	Validation *importers.ValidationReport `json:"-"`
+	// Files lists the members of a bundle upload, stored as a zip archive.
+	// MainFile is the member holding the model, whose format is Format.
+	Files    []ModelFile `json:"files,omitempty"`
+	MainFile string      `json:"main_file,omitempty"`
+	// Textures lists the bundle's PNG and JPEG textures used by materials
+	Textures []TextureAsset `json:"textures,omitempty"`
+}
+
+// Clone returns a deep copy of the metadata. Storage hands out copies so
+// that callers can read them while the stored record is being updated.
+func (m *ModelMetadata) Clone() *ModelMetadata {
+	clone := *m
+	clone.Chunks = slices.Clone(m.Chunks)
+	clone.Permissions = slices.Clone(m.Permissions)
+	clone.Tags = slices.Clone(m.Tags)
+	clone.Attributes = maps.Clone(m.Attributes)
+	clone.Files = slices.Clone(m.Files)
+	if m.ExpiresAt != nil {
+		expiresAt := *m.ExpiresAt
+		clone.ExpiresAt = &expiresAt
+	}
+	if m.Geometry != nil {
+		geometry := *m.Geometry
+		if geometry.Volume != nil {
+			volume := *geometry.Volume
+			geometry.Volume = &volume
+		}
+		clone.Geometry = &geometry
+	}
+	if m.Validation != nil {
+		validation := *m.Validation
+		validation.Errors = cloneIssues(validation.Errors)
+		validation.Warnings = cloneIssues(validation.Warnings)
+		clone.Validation = &validation
+	}
+	if m.Textures != nil {
+		clone.Textures = make([]TextureAsset, len(m.Textures))
+		for i, texture := range m.Textures {
+			texture.Materials = slices.Clone(texture.Materials)
+			texture.Variants = slices.Clone(texture.Variants)
+			clone.Textures[i] = texture
+		}
+	}
+	return &clone
+}
+
+// cloneIssues deep copies validation issues
+func cloneIssues(issues []importers.ValidationIssue) []importers.ValidationIssue {
+	if issues == nil {
+		return nil
+	}
+	clones := make([]importers.ValidationIssue, len(issues))
+	for i, issue := range issues {
+		issue.Vertices = slices.Clone(issue.Vertices)
+		issue.Triangles = slices.Clone(issue.Triangles)
+		issue.Files = slices.Clone(issue.Files)
+		clones[i] = issue
+	}
+	return clones
+}
+
+// ModelFile is an entry in the file manifest of a bundle
+type ModelFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// Limits on user-defined metadata, which is replicated to every peer
+const (
+	maxTags             = 64
+	maxAttributes       = 64
+	maxMetadataKeyLen   = 128 // attribute keys and tags
+	maxMetadataValueLen = 1024
+)
+
+// MetadataPatch describes changes to a model's user-defined metadata.
+// Attributes set to null are removed; Tags replace the existing tags when
+// present.
+type MetadataPatch struct {
+	Attributes map[string]*string `json:"attributes,omitempty"`
+	Tags       *[]string          `json:"tags,omitempty"`
+}
+
+// Apply applies the patch to the given metadata. Attributes and tags are
+// replaced rather than modified in place, so that copies handed out
+// earlier are not affected.
+func (p *MetadataPatch) Apply(m *ModelMetadata) {
+	if len(p.Attributes) > 0 {
+		attributes := make(map[string]string, len(m.Attributes)+len(p.Attributes))
+		for key, value := range m.Attributes {
+			attributes[key] = value
+		}
+		for key, value := range p.Attributes {
+			if value == nil {
+				delete(attributes, key)
+			} else {
+				attributes[key] = *value
+			}
+		}
+		m.Attributes = attributes
+	}
+
+	if p.Tags != nil {
+		m.Tags = normalizeTags(*p.Tags)
+	}
+}
+
+// Validate checks that attribute keys and tags are usable and within the
+// size limits
+func (p *MetadataPatch) Validate() error {
+	if len(p.Attributes) > maxAttributes {
+		return fmt.Errorf("at most %d attributes are allowed", maxAttributes)
+	}
+	for key, value := range p.Attributes {
+		if key == "" {
+			return errors.New("attribute key must not be empty")
+		}
+		if len(key) > maxMetadataKeyLen {
+			return fmt.Errorf("attribute key is longer than %d bytes", maxMetadataKeyLen)
+		}
+		if value != nil && len(*value) > maxMetadataValueLen {
+			return fmt.Errorf("attribute %q is longer than %d bytes", key, maxMetadataValueLen)
+		}
+	}
+	if p.Tags != nil {
+		if len(*p.Tags) > maxTags {
+			return fmt.Errorf("at most %d tags are allowed", maxTags)
+		}
+		for _, tag := range *p.Tags {
+			if strings.TrimSpace(tag) == "" {
+				return errors.New("tag must not be empty")
+			}
+			if len(tag) > maxMetadataKeyLen {
+				return fmt.Errorf("tag is longer than %d bytes", maxMetadataKeyLen)
+			}
+		}
+	}
+	return nil
+}
+
+// validateRemote checks a model record received from a peer before it is
+// applied or kept
+func (m *ModelMetadata) validateRemote() error {
+	if m.ID == "" || len(m.ID) > maxMetadataKeyLen || strings.ContainsAny(m.ID, `/\`) || m.ID == "." || m.ID == ".." {
+		return fmt.Errorf("invalid model ID %q", m.ID)
+	}
+	if m.UpdatedAt.IsZero() {
+		return fmt.Errorf("model %s has no update time", m.ID)
+	}
+
+	patch := MetadataPatch{Tags: &m.Tags, Attributes: make(map[string]*string, len(m.Attributes))}
+	for key, value := range m.Attributes {
+		patch.Attributes[key] = &value
+	}
+	if err := patch.Validate(); err != nil {
+		return fmt.Errorf("model %s: %w", m.ID, err)
+	}
+	return nil
+}
+
+// normalizeTags trims and deduplicates tags while keeping their order
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
 }
 
 type ModelChunk struct {
@@ -26,6 +215,11 @@ type ModelChunk struct {
 	Hash    string `json:"hash"`
 }
 
+// Expired reports whether the model's time-to-live has passed
+func (m *ModelMetadata) Expired(now time.Time) bool {
+	return m.ExpiresAt != nil && !now.Before(*m.ExpiresAt)
+}
+
 func (m *ModelMetadata) CalculateHash() string {
 	h := sha256.New()
 	h.Write([]byte(m.ID + m.Name + m.Format))
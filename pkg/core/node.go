@@ -2,33 +2,46 @@ package core
 
 import (
 	"context"
+	"encoding/json"
+	"log"
+	"time"
 
 	"github.com/3FT-io/3DS/pkg/config"
 	"github.com/3FT-io/3DS/pkg/p2p"
+	"github.com/3FT-io/3DS/pkg/tiering"
 )
 
 type Node struct {
 	config  *config.Config
 	storage *Storage
 	network *p2p.Network
+	events  *EventBus
 }
 
 func NewNode(cfg *config.Config) (*Node, error) {
-	storage, err := NewStorage(cfg.StoragePath)
+	storage, err := NewTieredStorage(cfg.Tiers())
 	if err != nil {
 		return nil, err
 	}
+	storage.SetTierPolicy(tiering.Policy{
+		PromoteThreshold: cfg.TierPromoteThreshold,
+		DemoteAfter:      cfg.TierDemoteAfter,
+	})
 
 	network, err := p2p.NewNetwork(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Node{
+	node := &Node{
 		config:  cfg,
 		storage: storage,
 		network: network,
-	}, nil
+		events:  NewEventBus(),
+	}
+	network.SetMessageHandler(node.handleMessage)
+
+	return node, nil
 }
 
 func (n *Node) Start(ctx context.Context) error {
@@ -50,10 +63,102 @@ func (n *Node) Stop() error {
 	return n.network.Stop()
 }
 
+// Storage returns the node's model storage
+func (n *Node) Storage() *Storage {
+	return n.storage
+}
+
+// Events returns the bus on which storage events are published
+func (n *Node) Events() *EventBus {
+	return n.events
+}
+
+// AnnounceModel broadcasts a model's metadata, including its user-defined
+// attributes and tags, so that peers holding the model can replicate it
+func (n *Node) AnnounceModel(ctx context.Context, metadata *ModelMetadata) error {
+	if n.network == nil {
+		return p2p.ErrNotStarted
+	}
+
+	payload, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	return n.network.Publish(ctx, p2p.MessageTypeModelMetadata, payload)
+}
+
+// maxMetadataMessageSize bounds the model records accepted from peers
+const maxMetadataMessageSize = 1 << 20
+
+// handleMessage applies messages received from peers
+func (n *Node) handleMessage(ctx context.Context, msg *p2p.Message) {
+	switch msg.Type {
+	case p2p.MessageTypeModelAnnouncement, p2p.MessageTypeModelMetadata:
+		if len(msg.Payload) > maxMetadataMessageSize {
+			log.Printf("Model metadata from %s is too large: %d bytes", msg.From, len(msg.Payload))
+			return
+		}
+		var remote ModelMetadata
+		if err := json.Unmarshal(msg.Payload, &remote); err != nil {
+			log.Printf("Invalid model metadata from %s: %v", msg.From, err)
+			return
+		}
+
+		if _, err := n.storage.ReplicateModel(ctx, &remote); err != nil {
+			log.Printf("Rejected model metadata from %s: %v", msg.From, err)
+		}
+	}
+}
+
 func (n *Node) discovery(ctx context.Context) {
 	// Implement peer discovery logic
 }
 
 func (n *Node) maintenance(ctx context.Context) {
-	// Implement storage maintenance logic
+	interval := n.config.MaintenanceInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n.runMaintenance(ctx)
+		}
+	}
+}
+
+// runMaintenance performs a single pass of storage maintenance
+func (n *Node) runMaintenance(ctx context.Context) {
+	// Remove models that have expired or fall outside retention rules
+	now := time.Now()
+	removed, err := n.storage.EnforceRetention(ctx, now, n.config.RetentionRules)
+	if err != nil {
+		log.Printf("Retention enforcement failed: %v", err)
+	}
+	for _, expiry := range removed {
+		n.events.Publish(Event{
+			Type:    EventModelExpired,
+			ModelID: expiry.ModelID,
+			Name:    expiry.Name,
+			Reason:  string(expiry.Reason),
+			Time:    now,
+		})
+	}
+
+	// Move models between hot and cold tiers
+	if _, err := n.storage.Rebalance(ctx); err != nil {
+		log.Printf("Storage rebalance failed: %v", err)
+	}
+
+	// Remove assets left behind by replaced artifacts and deleted models
+	if _, err := n.storage.CollectAssets(ctx); err != nil {
+		log.Printf("Asset collection failed: %v", err)
+	}
 }
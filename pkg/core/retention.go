@@ -0,0 +1,122 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/3FT-io/3DS/pkg/config"
+)
+
+// ExpiryReason explains why a model was removed by the retention policy
+type ExpiryReason string
+
+const (
+	ExpiryReasonTTL         ExpiryReason = "ttl"
+	ExpiryReasonMaxAge      ExpiryReason = "max_age"
+	ExpiryReasonMaxVersions ExpiryReason = "max_versions"
+)
+
+// Expiry describes a model that is due for removal
+type Expiry struct {
+	ModelID string       `json:"model_id"`
+	Name    string       `json:"name"`
+	Reason  ExpiryReason `json:"reason"`
+}
+
+// ExpiredModels returns the models that have outlived their TTL or fall
+// outside one of the given retention rules
+func (s *Storage) ExpiredModels(now time.Time, rules []config.RetentionRule) []Expiry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	expired := make(map[string]ExpiryReason)
+
+	for id, model := range s.metadata {
+		if model.Expired(now) {
+			expired[id] = ExpiryReasonTTL
+		}
+	}
+
+	for _, rule := range rules {
+		// Group matching models by name so that each name is versioned separately
+		versions := make(map[string][]*ModelMetadata)
+		for _, model := range s.metadata {
+			if !ruleMatches(rule, model) {
+				continue
+			}
+			if rule.MaxAge > 0 && now.Sub(model.CreatedAt) > rule.MaxAge {
+				if _, ok := expired[model.ID]; !ok {
+					expired[model.ID] = ExpiryReasonMaxAge
+				}
+			}
+			versions[model.Name] = append(versions[model.Name], model)
+		}
+
+		if rule.MaxVersions <= 0 {
+			continue
+		}
+
+		for _, models := range versions {
+			sort.Slice(models, func(i, j int) bool {
+				return models[i].CreatedAt.After(models[j].CreatedAt)
+			})
+			for _, model := range models[min(rule.MaxVersions, len(models)):] {
+				if _, ok := expired[model.ID]; !ok {
+					expired[model.ID] = ExpiryReasonMaxVersions
+				}
+			}
+		}
+	}
+
+	result := make([]Expiry, 0, len(expired))
+	for id, reason := range expired {
+		result = append(result, Expiry{
+			ModelID: id,
+			Name:    s.metadata[id].Name,
+			Reason:  reason,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ModelID < result[j].ModelID
+	})
+
+	return result
+}
+
+// EnforceRetention deletes all expired models and returns what was removed
+func (s *Storage) EnforceRetention(ctx context.Context, now time.Time, rules []config.RetentionRule) ([]Expiry, error) {
+	expired := s.ExpiredModels(now, rules)
+	removed := make([]Expiry, 0, len(expired))
+
+	for _, expiry := range expired {
+		select {
+		case <-ctx.Done():
+			return removed, ctx.Err()
+		default:
+		}
+
+		if err := s.DeleteModel(ctx, expiry.ModelID); err != nil {
+			// The model may have been deleted since it was found to be expired
+			if errors.Is(err, ErrModelNotFound) {
+				continue
+			}
+			return removed, err
+		}
+		removed = append(removed, expiry)
+	}
+
+	return removed, nil
+}
+
+func ruleMatches(rule config.RetentionRule, model *ModelMetadata) bool {
+	if rule.Owner != "" && rule.Owner != model.Owner {
+		return false
+	}
+	if rule.Format != "" && rule.Format != model.Format {
+		return false
+	}
+	return true
+}
@@ -3,48 +3,123 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/3FT-io/3DS/pkg/config"
+	"github.com/3FT-io/3DS/pkg/tiering"
 )
 
 const ChunkSize = 1024 * 1024 * 5 // 5MB chunks
 
+// Error definitions
+var (
+	ErrModelNotFound = errors.New("model not found")
+)
+
 type Storage struct {
-	basePath string
-	metadata map[string]*ModelMetadata
-	mu       sync.RWMutex
+	basePath  string
+	tiers     []config.StorageTier
+	modelTier map[string]int // model ID -> index into tiers
+	tracker   *tiering.Tracker
+	policy    tiering.Policy
+	metadata  map[string]*ModelMetadata
+	index     *modelIndex
+	// replicas holds the records peers announced for models not stored
+	// on this node
+	replicas map[string]*ModelMetadata
+	// assetSize is the number of bytes held by assets, which are kept in
+	// the first tier
+	assetSize int64
+	mu        sync.RWMutex
 }
 
 // StorageStatus represents the current state of the storage system
 type StorageStatus struct {
 	TotalModels int            `json:"total_models"`
 	TotalSize   int64          `json:"total_size"`
+	AssetSize   int64          `json:"asset_size"`
 	BasePath    string         `json:"base_path"`
+	Tiers       []TierStatus   `json:"tiers"`
 	Models      []ModelSummary `json:"models"`
 }
 
+// TierStatus represents the usage of a single storage tier
+type TierStatus struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	MaxSize int64  `json:"max_size"`
+	Used    int64  `json:"used"`
+	Models  int    `json:"models"`
+}
+
 type ModelSummary struct {
 	ID        string    `json:"id"`
 	Name      string    `json:"name"`
 	Size      int64     `json:"size"`
+	Tier      string    `json:"tier"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
 func NewStorage(path string) (*Storage, error) {
-	if err := os.MkdirAll(path, 0755); err != nil {
-		return nil, err
+	return NewTieredStorage([]config.StorageTier{{Name: "default", Path: path}})
+}
+
+// NewTieredStorage creates a storage instance spread over the given tiers,
+// ordered from hottest to coldest
+func NewTieredStorage(tiers []config.StorageTier) (*Storage, error) {
+	if len(tiers) == 0 {
+		return nil, errors.New("at least one storage tier is required")
+	}
+
+	for _, tier := range tiers {
+		if err := os.MkdirAll(tier.Path, 0755); err != nil {
+			return nil, err
+		}
 	}
 
-	return &Storage{
-		basePath: path,
+	s := &Storage{
+		basePath:  tiers[0].Path,
+		tiers:     tiers,
+		modelTier: make(map[string]int),
+		tracker:   tiering.NewTracker(),
+		policy: tiering.Policy{
+			PromoteThreshold: 3,
+			DemoteAfter:      24 * time.Hour,
+		},
 		metadata: make(map[string]*ModelMetadata),
-	}, nil
+		index:    newModelIndex(),
+		replicas: make(map[string]*ModelMetadata),
+	}
+	err := s.walkAssets(func(hash string, info os.FileInfo) error {
+		s.assetSize += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SetTierPolicy sets the policy used to promote and demote models
+func (s *Storage) SetTierPolicy(policy tiering.Policy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.policy = policy
+}
+
+// Tracker returns the access tracker used for tiering decisions
+func (s *Storage) Tracker() *tiering.Tracker {
+	return s.tracker
 }
 
 func (s *Storage) StoreModel(ctx context.Context, name string, format string, reader io.Reader) (*ModelMetadata, error) {
@@ -52,16 +127,19 @@ func (s *Storage) StoreModel(ctx context.Context, name string, format string, re
 	defer s.mu.Unlock()
 
 	// Create model metadata
+	now := time.Now()
 	metadata := &ModelMetadata{
 		ID:   generateUUID(),
 		Name: name,
 
 		Format:    format,
-		CreatedAt: time.Now(),
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
 
-	// Create model directory
-	modelPath := filepath.Join(s.basePath, metadata.ID)
+	// Create model directory in the hottest tier with room left
+	tier := s.placementTier()
+	modelPath := filepath.Join(s.tiers[tier].Path, metadata.ID)
 	if err := os.MkdirAll(modelPath, 0755); err != nil {
 		return nil, err
 	}
@@ -80,8 +158,38 @@ func (s *Storage) StoreModel(ctx context.Context, name string, format string, re
 
 	// Store metadata in memory
 	s.metadata[metadata.ID] = metadata
+	s.modelTier[metadata.ID] = tier
+	s.tracker.Seed(metadata.ID)
+	s.index.add(metadata)
 
-	return metadata, nil
+	return metadata.Clone(), nil
+}
+
+// placementTier returns the hottest tier that is not yet full
+func (s *Storage) placementTier() int {
+	usage := s.tierUsage()
+	for i, tier := range s.tiers {
+		if tier.MaxSize == 0 || usage[i] < tier.MaxSize {
+			return i
+		}
+	}
+	return len(s.tiers) - 1
+}
+
+// tierUsage returns the bytes used by models in each tier, counting
+// assets against the first tier
+func (s *Storage) tierUsage() []int64 {
+	usage := make([]int64, len(s.tiers))
+	usage[0] = s.assetSize
+	for id, model := range s.metadata {
+		usage[s.modelTier[id]] += model.Size
+	}
+	return usage
+}
+
+// modelPath returns the directory holding a model's chunks
+func (s *Storage) modelPath(modelID string) string {
+	return filepath.Join(s.tiers[s.modelTier[modelID]].Path, modelID)
 }
 
 func (s *Storage) splitAndStoreChunks(ctx context.Context, modelPath string, reader io.Reader) ([]string, int64, error) {
@@ -137,12 +245,70 @@ func (s *Storage) ListModels(ctx context.Context) ([]ModelMetadata, error) {
 
 	models := make([]ModelMetadata, 0, len(s.metadata))
 	for _, model := range s.metadata {
-		models = append(models, *model)
+		models = append(models, *model.Clone())
 	}
 
 	return models, nil
 }
 
+// SearchModels returns a page of models matching the given query
+func (s *Storage) SearchModels(ctx context.Context, query ModelQuery) (*ModelPage, error) {
+	less, err := modelLess(query.SortBy, query.Descending)
+	if err != nil {
+		return nil, err
+	}
+
+	var after *ModelMetadata
+	if query.Cursor != "" {
+		if after, err = decodeCursor(query.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+	limit = min(limit, MaxQueryLimit)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := s.index.search(query)
+	matches := make([]*ModelMetadata, 0, len(ids))
+	for _, id := range ids {
+		if model, ok := s.metadata[id]; ok {
+			matches = append(matches, model)
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return less(matches[i], matches[j])
+	})
+
+	page := &ModelPage{
+		Models: make([]ModelMetadata, 0, min(limit, len(matches))),
+		Total:  len(matches),
+	}
+
+	start := 0
+	if after != nil {
+		start = sort.Search(len(matches), func(i int) bool {
+			return less(after, matches[i])
+		})
+	}
+
+	end := min(start+limit, len(matches))
+	for _, model := range matches[start:end] {
+		page.Models = append(page.Models, *model.Clone())
+	}
+	if end < len(matches) {
+		page.NextCursor = encodeCursor(matches[end-1])
+	}
+
+	return page, nil
+}
+
 // GetModelMetadata retrieves metadata for a specific model by ID
 func (s *Storage) GetModelMetadata(ctx context.Context, modelID string) (interface{}, error) {
 	// Assuming you store metadata alongside your models
@@ -160,9 +326,9 @@ func (s *Storage) getMetadata(modelID string) (*ModelMetadata, error) {
 
 	metadata, exists := s.metadata[modelID]
 	if !exists {
-		return nil, fmt.Errorf("model not found: %s", modelID)
+		return nil, fmt.Errorf("%w: %s", ErrModelNotFound, modelID)
 	}
-	return metadata, nil
+	return metadata.Clone(), nil
 }
 
 func (s *Storage) DeleteModel(ctx context.Context, modelID string) error {
@@ -170,16 +336,31 @@ func (s *Storage) DeleteModel(ctx context.Context, modelID string) error {
 	defer s.mu.Unlock()
 
 	// Check if model exists
-	if _, exists := s.metadata[modelID]; !exists {
-		return fmt.Errorf("model not found: %s", modelID)
+	metadata, exists := s.metadata[modelID]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrModelNotFound, modelID)
 	}
 
+	// Unlink derived artifacts, then remove the assets no other model uses
+	assets := s.modelAssets(metadata)
+	if metadata.Hash != "" {
+		os.RemoveAll(filepath.Join(s.basePath, "derived", metadata.Hash))
+	}
+
+	// Delete model directory
+	modelPath := s.modelPath(modelID)
+
 	// Delete from metadata map
 	delete(s.metadata, modelID)
+	delete(s.modelTier, modelID)
+	s.tracker.Forget(modelID)
+	s.index.remove(modelID)
 
-	// Delete model directory
-	modelPath := filepath.Join(s.basePath, modelID)
-	return os.RemoveAll(modelPath)
+	if err := os.RemoveAll(modelPath); err != nil {
+		return err
+	}
+	_, err := s.removeAssets(assets, time.Now().Add(-assetGracePeriod))
+	return err
 }
 
 // GetStatus returns the current status of the storage system
@@ -190,15 +371,31 @@ func (s *Storage) GetStatus(ctx context.Context) (*StorageStatus, error) {
 	status := &StorageStatus{
 		TotalModels: len(s.metadata),
 		BasePath:    s.basePath,
+		Tiers:       make([]TierStatus, len(s.tiers)),
+		TotalSize:   s.assetSize,
+		AssetSize:   s.assetSize,
 		Models:      make([]ModelSummary, 0, len(s.metadata)),
 	}
 
+	for i, tier := range s.tiers {
+		status.Tiers[i] = TierStatus{
+			Name:    tier.Name,
+			Path:    tier.Path,
+			MaxSize: tier.MaxSize,
+		}
+	}
+	status.Tiers[0].Used = s.assetSize
+
 	for _, model := range s.metadata {
+		tier := s.modelTier[model.ID]
 		status.TotalSize += model.Size
+		status.Tiers[tier].Used += model.Size
+		status.Tiers[tier].Models++
 		status.Models = append(status.Models, ModelSummary{
 			ID:        model.ID,
 			Name:      model.Name,
 			Size:      model.Size,
+			Tier:      s.tiers[tier].Name,
 			CreatedAt: model.CreatedAt,
 		})
 	}
@@ -213,7 +410,7 @@ func (s *Storage) StreamModel(ctx context.Context, modelID string, writer io.Wri
 		return err
 	}
 
-	modelPath := filepath.Join(s.basePath, modelID)
+	s.tracker.Touch(modelID)
 
 	// Read and stream each chunk in order
 	for i := 0; i < len(metadata.Chunks); i++ {
@@ -221,8 +418,7 @@ func (s *Storage) StreamModel(ctx context.Context, modelID string, writer io.Wri
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
-			chunkPath := filepath.Join(modelPath, fmt.Sprintf("chunk_%d", i))
-			data, err := os.ReadFile(chunkPath)
+			data, err := s.readChunk(modelID, i)
 			if err != nil {
 				return fmt.Errorf("failed to read chunk %d: %w", i, err)
 			}
@@ -236,7 +432,16 @@ func (s *Storage) StreamModel(ctx context.Context, modelID string, writer io.Wri
 	return nil
 }
 
-// GetModel retrieves a model's metadata by ID
+// readChunk reads a single chunk, holding the read lock so that the model
+// cannot be moved to another tier mid-read
+func (s *Storage) readChunk(modelID string, index int) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return os.ReadFile(filepath.Join(s.modelPath(modelID), fmt.Sprintf("chunk_%d", index)))
+}
+
+// GetModel retrieves a copy of a model's metadata by ID
 func (s *Storage) GetModel(ctx context.Context, modelID string) (*ModelMetadata, error) {
 	metadata, err := s.getMetadata(modelID)
 	if err != nil {
@@ -245,6 +450,78 @@ func (s *Storage) GetModel(ctx context.Context, modelID string) (*ModelMetadata,
 	return metadata, nil
 }
 
+// UpdateModel applies changes to a model's metadata while holding the
+// storage lock and returns a copy of the result
+func (s *Storage) UpdateModel(ctx context.Context, modelID string, update func(*ModelMetadata) error) (*ModelMetadata, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	metadata, exists := s.metadata[modelID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotFound, modelID)
+	}
+
+	if err := update(metadata); err != nil {
+		return nil, err
+	}
+	metadata.UpdatedAt = time.Now()
+	s.index.add(metadata)
+
+	return metadata.Clone(), nil
+}
+
+// ReplicateModel applies a model record announced by a peer, last writer
+// wins by UpdatedAt. A model stored here takes the record's tags and
+// attributes; records of models stored elsewhere are kept as replicas. It
+// reports whether the record was newer than the one held.
+func (s *Storage) ReplicateModel(ctx context.Context, remote *ModelMetadata) (bool, error) {
+	if err := remote.validateRemote(); err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attributes := make(map[string]string, len(remote.Attributes))
+	for key, value := range remote.Attributes {
+		attributes[key] = value
+	}
+
+	if local, ok := s.metadata[remote.ID]; ok {
+		if !remote.UpdatedAt.After(local.UpdatedAt) {
+			return false, nil
+		}
+		local.Tags = normalizeTags(remote.Tags)
+		local.Attributes = attributes
+		local.UpdatedAt = remote.UpdatedAt
+		s.index.add(local)
+		return true, nil
+	}
+
+	if replica, ok := s.replicas[remote.ID]; ok && !remote.UpdatedAt.After(replica.UpdatedAt) {
+		return false, nil
+	}
+	replica := *remote
+	replica.Tags = normalizeTags(remote.Tags)
+	replica.Attributes = attributes
+	s.replicas[remote.ID] = &replica
+	return true, nil
+}
+
+// Replicas returns the records peers announced for models not stored on
+// this node
+func (s *Storage) Replicas(ctx context.Context) []ModelMetadata {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	replicas := make([]ModelMetadata, 0, len(s.replicas))
+	for _, replica := range s.replicas {
+		replicas = append(replicas, *replica.Clone())
+	}
+	sort.Slice(replicas, func(i, j int) bool { return replicas[i].ID < replicas[j].ID })
+	return replicas
+}
+
 // StoreObject stores an object's metadata
 func (s *Storage) StoreObject(ctx context.Context, obj *Object) error {
 	s.mu.Lock()
@@ -0,0 +1,206 @@
+package core_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/3FT-io/3DS/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedSearchModels(t *testing.T, storage *core.Storage) map[string]*core.ModelMetadata {
+	ctx := context.Background()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	specs := []struct {
+		name    string
+		format  string
+		owner   string
+		content string
+		tags    []string
+	}{
+		{"Chair.obj", "obj", "alice", "12", []string{"furniture"}},
+		{"chair_lod1.obj", "obj", "alice", "1234", []string{"furniture", "lod"}},
+		{"Table.fbx", "fbx", "bob", "123456", []string{"furniture"}},
+		{"Robot.glb", "glb", "bob", "12345678", nil},
+	}
+
+	models := make(map[string]*core.ModelMetadata)
+	for i, spec := range specs {
+		metadata, err := storage.StoreModel(ctx, spec.name, spec.format, strings.NewReader(spec.content))
+		require.NoError(t, err)
+
+		metadata, err = storage.UpdateModel(ctx, metadata.ID, func(m *core.ModelMetadata) error {
+			m.Owner = spec.owner
+			m.Tags = spec.tags
+			m.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+			return nil
+		})
+		require.NoError(t, err)
+		models[spec.name] = metadata
+	}
+
+	return models
+}
+
+func modelNames(page *core.ModelPage) []string {
+	names := make([]string, 0, len(page.Models))
+	for _, model := range page.Models {
+		names = append(names, model.Name)
+	}
+	return names
+}
+
+func TestSearchModelsFilters(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	seedSearchModels(t, storage)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		query    core.ModelQuery
+		expected []string
+	}{
+		{
+			name:     "all models by creation time",
+			query:    core.ModelQuery{},
+			expected: []string{"Chair.obj", "chair_lod1.obj", "Table.fbx", "Robot.glb"},
+		},
+		{
+			name:     "name substring is case-insensitive",
+			query:    core.ModelQuery{Name: "CHAIR"},
+			expected: []string{"Chair.obj", "chair_lod1.obj"},
+		},
+		{
+			name:     "name prefix",
+			query:    core.ModelQuery{NamePrefix: "ro"},
+			expected: []string{"Robot.glb"},
+		},
+		{
+			name:     "format and owner",
+			query:    core.ModelQuery{Format: "obj", Owner: "alice"},
+			expected: []string{"Chair.obj", "chair_lod1.obj"},
+		},
+		{
+			name:     "all tags must match",
+			query:    core.ModelQuery{Tags: []string{"furniture", "lod"}},
+			expected: []string{"chair_lod1.obj"},
+		},
+		{
+			name:     "size range",
+			query:    core.ModelQuery{MinSize: 3, MaxSize: 6},
+			expected: []string{"chair_lod1.obj", "Table.fbx"},
+		},
+		{
+			name:     "created range",
+			query:    core.ModelQuery{CreatedAfter: base.Add(time.Hour), CreatedBefore: base.Add(3 * time.Hour)},
+			expected: []string{"chair_lod1.obj", "Table.fbx"},
+		},
+		{
+			name:     "sort by size descending",
+			query:    core.ModelQuery{Tags: []string{"furniture"}, SortBy: core.SortBySize, Descending: true},
+			expected: []string{"Table.fbx", "chair_lod1.obj", "Chair.obj"},
+		},
+		{
+			name:     "no matches",
+			query:    core.ModelQuery{Owner: "carol"},
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := storage.SearchModels(context.Background(), tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, modelNames(page))
+			assert.Equal(t, len(tt.expected), page.Total)
+		})
+	}
+}
+
+func TestSearchModelsPagination(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	seedSearchModels(t, storage)
+	ctx := context.Background()
+
+	var names []string
+	query := core.ModelQuery{SortBy: core.SortByName, Limit: 3}
+	for {
+		page, err := storage.SearchModels(ctx, query)
+		require.NoError(t, err)
+		assert.Equal(t, 4, page.Total)
+		names = append(names, modelNames(page)...)
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+
+	assert.Equal(t, []string{"Chair.obj", "chair_lod1.obj", "Robot.glb", "Table.fbx"}, names)
+
+	_, err := storage.SearchModels(ctx, core.ModelQuery{Cursor: "not-a-cursor"})
+	assert.ErrorIs(t, err, core.ErrInvalidCursor)
+
+	_, err = storage.SearchModels(ctx, core.ModelQuery{SortBy: "color"})
+	assert.ErrorIs(t, err, core.ErrInvalidSort)
+}
+
+func TestSearchModelsReflectsUpdatesAndDeletes(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	models := seedSearchModels(t, storage)
+	ctx := context.Background()
+
+	_, err := storage.UpdateModel(ctx, models["Robot.glb"].ID, func(m *core.ModelMetadata) error {
+		m.Owner = "alice"
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, storage.DeleteModel(ctx, models["Chair.obj"].ID))
+
+	page, err := storage.SearchModels(ctx, core.ModelQuery{Owner: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"chair_lod1.obj", "Robot.glb"}, modelNames(page))
+}
+
+func TestSearchModelsByAttributes(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	models := seedSearchModels(t, storage)
+	ctx := context.Background()
+
+	artist := "dana"
+	license := "cc-by"
+	patch := core.MetadataPatch{Attributes: map[string]*string{"artist": &artist, "license": &license}}
+	_, err := storage.UpdateModel(ctx, models["Table.fbx"].ID, func(m *core.ModelMetadata) error {
+		patch.Apply(m)
+		return nil
+	})
+	require.NoError(t, err)
+
+	page, err := storage.SearchModels(ctx, core.ModelQuery{Attributes: map[string]string{"artist": "dana"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Table.fbx"}, modelNames(page))
+
+	// Removing an attribute drops it from the index
+	patch = core.MetadataPatch{Attributes: map[string]*string{"artist": nil}}
+	updated, err := storage.UpdateModel(ctx, models["Table.fbx"].ID, func(m *core.ModelMetadata) error {
+		patch.Apply(m)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"license": "cc-by"}, updated.Attributes)
+
+	page, err = storage.SearchModels(ctx, core.ModelQuery{Attributes: map[string]string{"artist": "dana"}})
+	require.NoError(t, err)
+	assert.Empty(t, page.Models)
+}
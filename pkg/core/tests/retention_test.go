@@ -0,0 +1,106 @@
+package core_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/3FT-io/3DS/pkg/config"
+	"github.com/3FT-io/3DS/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func storeTestModel(t *testing.T, storage *core.Storage, name, format, owner string, createdAt time.Time) *core.ModelMetadata {
+	metadata, err := storage.StoreModel(context.Background(), name, format, strings.NewReader("test content"))
+	require.NoError(t, err)
+
+	metadata, err = storage.UpdateModel(context.Background(), metadata.ID, func(m *core.ModelMetadata) error {
+		m.Owner = owner
+		m.CreatedAt = createdAt
+		return nil
+	})
+	require.NoError(t, err)
+
+	return metadata
+}
+
+func TestEnforceRetentionTTL(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	expiring := storeTestModel(t, storage, "preview.glb", "glb", "ci", now)
+	kept := storeTestModel(t, storage, "release.glb", "glb", "ci", now)
+
+	_, err := storage.UpdateModel(ctx, expiring.ID, func(m *core.ModelMetadata) error {
+		expiresAt := now.Add(-time.Minute)
+		m.ExpiresAt = &expiresAt
+		return nil
+	})
+	require.NoError(t, err)
+
+	removed, err := storage.EnforceRetention(ctx, now, nil)
+	require.NoError(t, err)
+	require.Len(t, removed, 1)
+	assert.Equal(t, expiring.ID, removed[0].ModelID)
+	assert.Equal(t, core.ExpiryReasonTTL, removed[0].Reason)
+
+	_, err = storage.GetModel(ctx, expiring.ID)
+	assert.ErrorIs(t, err, core.ErrModelNotFound)
+	_, err = storage.GetModel(ctx, kept.ID)
+	assert.NoError(t, err)
+}
+
+func TestEnforceRetentionRules(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	oldest := storeTestModel(t, storage, "asset.obj", "obj", "ci", now.Add(-3*time.Hour))
+	middle := storeTestModel(t, storage, "asset.obj", "obj", "ci", now.Add(-2*time.Hour))
+	newest := storeTestModel(t, storage, "asset.obj", "obj", "ci", now.Add(-time.Hour))
+	stale := storeTestModel(t, storage, "old.obj", "obj", "ci", now.Add(-10*24*time.Hour))
+	otherOwner := storeTestModel(t, storage, "asset.obj", "obj", "artist", now.Add(-10*24*time.Hour))
+
+	rules := []config.RetentionRule{
+		{Owner: "ci", MaxVersions: 2, MaxAge: 7 * 24 * time.Hour},
+	}
+
+	removed, err := storage.EnforceRetention(ctx, now, rules)
+	require.NoError(t, err)
+
+	reasons := make(map[string]core.ExpiryReason)
+	for _, expiry := range removed {
+		reasons[expiry.ModelID] = expiry.Reason
+	}
+
+	assert.Equal(t, map[string]core.ExpiryReason{
+		oldest.ID: core.ExpiryReasonMaxVersions,
+		stale.ID:  core.ExpiryReasonMaxAge,
+	}, reasons)
+
+	for _, id := range []string{middle.ID, newest.ID, otherOwner.ID} {
+		_, err := storage.GetModel(ctx, id)
+		assert.NoError(t, err)
+	}
+}
+
+func TestEventBus(t *testing.T) {
+	bus := core.NewEventBus()
+	events := bus.Subscribe(1)
+
+	bus.Publish(core.Event{Type: core.EventModelExpired, ModelID: "model-1"})
+	// The subscriber buffer is full, so this event is dropped instead of blocking
+	bus.Publish(core.Event{Type: core.EventModelExpired, ModelID: "model-2"})
+
+	event := <-events
+	assert.Equal(t, core.EventModelExpired, event.Type)
+	assert.Equal(t, "model-1", event.ModelID)
+	assert.Empty(t, events)
+}
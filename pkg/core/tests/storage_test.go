@@ -2,9 +2,12 @@ package core_test
 
 import (
 	"context"
+	"encoding/json"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/3FT-io/3DS/pkg/core"
 	"github.com/stretchr/testify/assert"
@@ -106,3 +109,264 @@ func TestDeleteModel(t *testing.T) {
 	_, err = storage.GetModel(ctx, metadata.ID)
 	assert.Error(t, err)
 }
+
+func TestReplicateModel(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	metadata, err := storage.StoreModel(ctx, "chair.obj", "obj", strings.NewReader("v 0 0 0"))
+	require.NoError(t, err)
+	metadata, err = storage.UpdateModel(ctx, metadata.ID, func(m *core.ModelMetadata) error {
+		m.Tags = []string{"local"}
+		return nil
+	})
+	require.NoError(t, err)
+	updated := metadata.UpdatedAt
+
+	// Older records lose to the local metadata
+	remote := *metadata
+	remote.Tags = []string{"stale"}
+	remote.UpdatedAt = updated.Add(-time.Minute)
+	changed, err := storage.ReplicateModel(ctx, &remote)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	local, err := storage.GetModel(ctx, metadata.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"local"}, local.Tags)
+
+	// Newer ones replace the tags and attributes
+	remote.Tags = []string{" props ", "props"}
+	remote.Attributes = map[string]string{"artist": "dana"}
+	remote.UpdatedAt = updated.Add(time.Minute)
+	changed, err = storage.ReplicateModel(ctx, &remote)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	local, err = storage.GetModel(ctx, metadata.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"props"}, local.Tags)
+	assert.Equal(t, map[string]string{"artist": "dana"}, local.Attributes)
+	assert.Equal(t, remote.UpdatedAt, local.UpdatedAt)
+	page, err := storage.SearchModels(ctx, core.ModelQuery{Tags: []string{"props"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, page.Total)
+
+	// Records of models held by other peers are kept, the newest winning
+	other := core.ModelMetadata{ID: "remote-model", Name: "table.glb", Tags: []string{"a"}, UpdatedAt: updated}
+	changed, err = storage.ReplicateModel(ctx, &other)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	other.Tags, other.UpdatedAt = []string{"b"}, updated.Add(-time.Second)
+	changed, err = storage.ReplicateModel(ctx, &other)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	replicas := storage.Replicas(ctx)
+	require.Len(t, replicas, 1)
+	assert.Equal(t, "table.glb", replicas[0].Name)
+	assert.Equal(t, []string{"a"}, replicas[0].Tags)
+	_, err = storage.GetModel(ctx, "remote-model")
+	assert.ErrorIs(t, err, core.ErrModelNotFound)
+
+	// Invalid records are rejected
+	for _, record := range []core.ModelMetadata{
+		{ID: "../escape", UpdatedAt: updated},
+		{ID: "no-time"},
+		{ID: "empty-tag", Tags: []string{" "}, UpdatedAt: updated},
+		{ID: "huge-tags", Tags: make([]string, 65), UpdatedAt: updated},
+		{ID: "huge-value", Attributes: map[string]string{"k": strings.Repeat("v", 1025)}, UpdatedAt: updated},
+	} {
+		_, err := storage.ReplicateModel(ctx, &record)
+		assert.Error(t, err, record.ID)
+	}
+	assert.Len(t, storage.Replicas(ctx), 1)
+}
+
+func TestStoreAsset(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	hash, err := storage.StoreAsset(ctx, []byte("texture"))
+	require.NoError(t, err)
+	assert.Len(t, hash, 64)
+
+	again, err := storage.StoreAsset(ctx, []byte("texture"))
+	require.NoError(t, err)
+	assert.Equal(t, hash, again)
+
+	data, err := storage.GetAsset(ctx, hash)
+	require.NoError(t, err)
+	assert.Equal(t, "texture", string(data))
+
+	_, err = storage.GetAsset(ctx, strings.Repeat("0", 64))
+	assert.ErrorIs(t, err, core.ErrAssetNotFound)
+	_, err = storage.GetAsset(ctx, "../../etc/passwd")
+	assert.ErrorIs(t, err, core.ErrAssetNotFound)
+}
+
+func TestTextureVariant(t *testing.T) {
+	texture := core.TextureAsset{Variants: []core.TextureVariant{
+		{Width: 256, Height: 128, Hash: "a"},
+		{Width: 128, Height: 64, Hash: "b"},
+		{Width: 64, Height: 32, Hash: "c"},
+	}}
+
+	assert.Equal(t, "a", texture.Variant(0).Hash)
+	assert.Equal(t, "a", texture.Variant(512).Hash)
+	assert.Equal(t, "b", texture.Variant(200).Hash)
+	assert.Equal(t, "c", texture.Variant(16).Hash)
+}
+
+func TestStoreDerived(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	model, err := storage.StoreModel(ctx, "cube.obj", "obj", strings.NewReader("v 0 0 0\n"))
+	require.NoError(t, err)
+
+	_, err = storage.GetDerived(ctx, model.Hash, "convert/glb")
+	assert.ErrorIs(t, err, core.ErrAssetNotFound)
+
+	artifact, err := storage.StoreDerived(ctx, core.DerivedArtifact{Kind: "convert/glb", Source: model.Hash, Name: "cube.glb"}, []byte("glb"))
+	require.NoError(t, err)
+	assert.Equal(t, model.Hash, artifact.Source)
+	assert.Equal(t, int64(3), artifact.Size)
+
+	found, err := storage.GetDerived(ctx, model.Hash, "convert/glb")
+	require.NoError(t, err)
+	assert.Equal(t, artifact.Hash, found.Hash)
+	assert.Equal(t, "cube.glb", found.Name)
+	data, err := storage.GetAsset(ctx, found.Hash)
+	require.NoError(t, err)
+	assert.Equal(t, "glb", string(data))
+
+	// Deleting the model unlinks its artifacts
+	require.NoError(t, storage.DeleteModel(ctx, model.ID))
+	_, err = storage.GetDerived(ctx, model.Hash, "convert/glb")
+	assert.ErrorIs(t, err, core.ErrAssetNotFound)
+	_, err = storage.StoreDerived(ctx, core.DerivedArtifact{Kind: "convert/glb", Source: "../model"}, []byte("glb"))
+	assert.ErrorIs(t, err, core.ErrAssetNotFound)
+}
+
+// backdateAssets makes the stored assets older than the grace period
+func backdateAssets(t *testing.T, dir string) {
+	old := time.Now().Add(-2 * time.Hour)
+	err := filepath.Walk(filepath.Join(dir, "assets"), func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		return os.Chtimes(path, old, old)
+	})
+	require.NoError(t, err)
+}
+
+func TestAssetCollection(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "3ds-storage-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	storage, err := core.NewStorage(tmpDir)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	shared, err := storage.StoreAsset(ctx, []byte("shared"))
+	require.NoError(t, err)
+	own, err := storage.StoreAsset(ctx, []byte("own"))
+	require.NoError(t, err)
+	withTextures := func(hashes ...string) *core.ModelMetadata {
+		model, err := storage.StoreModel(ctx, "crate.obj", "obj", strings.NewReader("v 0 0 0"))
+		require.NoError(t, err)
+		texture := core.TextureAsset{Path: "crate.png"}
+		for _, hash := range hashes {
+			texture.Variants = append(texture.Variants, core.TextureVariant{Hash: hash})
+		}
+		model, err = storage.UpdateModel(ctx, model.ID, func(m *core.ModelMetadata) error {
+			m.Textures = []core.TextureAsset{texture}
+			return nil
+		})
+		require.NoError(t, err)
+		return model
+	}
+	first := withTextures(shared, own)
+	withTextures(shared)
+	artifact, err := storage.StoreDerived(ctx, core.DerivedArtifact{Kind: "convert/glb", Source: first.Hash}, []byte("glb"))
+	require.NoError(t, err)
+
+	// Assets count against the first tier
+	status, err := storage.GetStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("shared")+len("own")+len("glb")), status.AssetSize)
+	assert.Equal(t, status.AssetSize+2*int64(len("v 0 0 0")), status.Tiers[0].Used)
+
+	// Deleting a model removes the assets only it used
+	backdateAssets(t, tmpDir)
+	require.NoError(t, storage.DeleteModel(ctx, first.ID))
+	_, err = storage.GetAsset(ctx, own)
+	assert.ErrorIs(t, err, core.ErrAssetNotFound)
+	_, err = storage.GetAsset(ctx, artifact.Hash)
+	assert.ErrorIs(t, err, core.ErrAssetNotFound)
+	_, err = storage.GetAsset(ctx, shared)
+	assert.NoError(t, err)
+	status, err = storage.GetStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("shared")), status.AssetSize)
+
+	// Unreferenced assets are collected once the grace period is over
+	orphan, err := storage.StoreAsset(ctx, []byte("orphan"))
+	require.NoError(t, err)
+	removed, err := storage.CollectAssets(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, removed)
+	backdateAssets(t, tmpDir)
+	removed, err = storage.CollectAssets(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	_, err = storage.GetAsset(ctx, orphan)
+	assert.ErrorIs(t, err, core.ErrAssetNotFound)
+	_, err = storage.GetAsset(ctx, shared)
+	assert.NoError(t, err)
+
+	// Asset usage is restored when the storage is reopened
+	reopened, err := core.NewStorage(tmpDir)
+	require.NoError(t, err)
+	status, err = reopened.GetStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("shared")), status.AssetSize)
+}
+
+func TestDerivedArtifactsOfDeletedModels(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "3ds-storage-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	storage, err := core.NewStorage(tmpDir)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	model, err := storage.StoreModel(ctx, "crate.obj", "obj", strings.NewReader("v 0 0 0"))
+	require.NoError(t, err)
+	artifact, err := storage.StoreDerived(ctx, core.DerivedArtifact{Kind: "lod/1/glb", Source: model.Hash}, []byte("lod"))
+	require.NoError(t, err)
+
+	// Artifacts finished after their model was deleted are not linked
+	require.NoError(t, storage.DeleteModel(ctx, model.ID))
+	_, err = storage.StoreDerived(ctx, core.DerivedArtifact{Kind: "lod/2/glb", Source: model.Hash}, []byte("late"))
+	assert.ErrorIs(t, err, core.ErrModelNotFound)
+	assert.NoDirExists(t, filepath.Join(tmpDir, "derived", model.Hash))
+
+	// Links left without a model do not keep their assets alive
+	link, err := json.Marshal(artifact)
+	require.NoError(t, err)
+	orphan := filepath.Join(tmpDir, "derived", model.Hash)
+	require.NoError(t, os.MkdirAll(orphan, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(orphan, "lod%2F1%2Fglb.json"), link, 0644))
+	_, err = storage.StoreAsset(ctx, []byte("lod"))
+	require.NoError(t, err)
+
+	backdateAssets(t, tmpDir)
+	removed, err := storage.CollectAssets(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	assert.NoDirExists(t, orphan)
+	_, err = storage.GetAsset(ctx, artifact.Hash)
+	assert.ErrorIs(t, err, core.ErrAssetNotFound)
+}
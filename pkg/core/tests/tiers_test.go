@@ -0,0 +1,132 @@
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/3FT-io/3DS/pkg/config"
+	"github.com/3FT-io/3DS/pkg/core"
+	"github.com/3FT-io/3DS/pkg/tiering"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTieredStorage(t *testing.T) (*core.Storage, []config.StorageTier, func()) {
+	tmpDir, err := os.MkdirTemp("", "3ds-tiers-test-*")
+	require.NoError(t, err)
+
+	tiers := []config.StorageTier{
+		{Name: "hot", Path: filepath.Join(tmpDir, "hot")},
+		{Name: "cold", Path: filepath.Join(tmpDir, "cold")},
+	}
+
+	storage, err := core.NewTieredStorage(tiers)
+	require.NoError(t, err)
+
+	cleanup := func() {
+		os.RemoveAll(tmpDir)
+	}
+
+	return storage, tiers, cleanup
+}
+
+func TestTieredStorageDemotesIdleModels(t *testing.T) {
+	storage, tiers, cleanup := setupTieredStorage(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	storage.SetTierPolicy(tiering.Policy{PromoteThreshold: 2, DemoteAfter: time.Hour})
+
+	// Pretend the model was stored two hours ago
+	storage.Tracker().SetClock(func() time.Time { return time.Now().Add(-2 * time.Hour) })
+	metadata, err := storage.StoreModel(ctx, "test.obj", "obj", strings.NewReader("test model content"))
+	require.NoError(t, err)
+	storage.Tracker().SetClock(time.Now)
+
+	tier, err := storage.ModelTier(metadata.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "hot", tier)
+
+	moved, err := storage.Rebalance(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, moved)
+
+	tier, err = storage.ModelTier(metadata.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "cold", tier)
+	assert.NoDirExists(t, filepath.Join(tiers[0].Path, metadata.ID))
+	assert.DirExists(t, filepath.Join(tiers[1].Path, metadata.ID))
+
+	// Reads stay transparent after the move
+	var buf bytes.Buffer
+	require.NoError(t, storage.StreamModel(ctx, metadata.ID, &buf))
+	assert.Equal(t, "test model content", buf.String())
+
+	status, err := storage.GetStatus(ctx)
+	require.NoError(t, err)
+	require.Len(t, status.Tiers, 2)
+	assert.Equal(t, 0, status.Tiers[0].Models)
+	assert.Equal(t, 1, status.Tiers[1].Models)
+	assert.Equal(t, int64(len("test model content")), status.Tiers[1].Used)
+}
+
+func TestTieredStoragePromotesHotModels(t *testing.T) {
+	storage, _, cleanup := setupTieredStorage(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	storage.SetTierPolicy(tiering.Policy{PromoteThreshold: 2, DemoteAfter: time.Hour})
+
+	storage.Tracker().SetClock(func() time.Time { return time.Now().Add(-2 * time.Hour) })
+	metadata, err := storage.StoreModel(ctx, "test.obj", "obj", strings.NewReader("test model content"))
+	require.NoError(t, err)
+	storage.Tracker().SetClock(time.Now)
+
+	_, err = storage.Rebalance(ctx)
+	require.NoError(t, err)
+
+	// Read the model often enough to get it promoted again
+	for i := 0; i < 2; i++ {
+		require.NoError(t, storage.StreamModel(ctx, metadata.ID, &bytes.Buffer{}))
+	}
+
+	moved, err := storage.Rebalance(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, moved)
+
+	tier, err := storage.ModelTier(metadata.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "hot", tier)
+}
+
+func TestTieredStorageRespectsCapacity(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "3ds-tiers-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	storage, err := core.NewTieredStorage([]config.StorageTier{
+		{Name: "hot", Path: filepath.Join(tmpDir, "hot"), MaxSize: 4},
+		{Name: "cold", Path: filepath.Join(tmpDir, "cold")},
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	first, err := storage.StoreModel(ctx, "first.obj", "obj", strings.NewReader("12345"))
+	require.NoError(t, err)
+	second, err := storage.StoreModel(ctx, "second.obj", "obj", strings.NewReader("12345"))
+	require.NoError(t, err)
+
+	tier, err := storage.ModelTier(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "hot", tier)
+
+	// The hot tier is full, so the second model lands in the cold tier
+	tier, err = storage.ModelTier(second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "cold", tier)
+}
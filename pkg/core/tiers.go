@@ -0,0 +1,112 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/3FT-io/3DS/pkg/tiering"
+)
+
+// tierMove describes a pending move of a model between tiers
+type tierMove struct {
+	modelID string
+	from    int
+	to      int
+}
+
+// Rebalance promotes frequently read models to hotter tiers and demotes
+// idle ones to colder tiers. Models are copied before the switch so that
+// reads stay transparent while a move is in progress.
+func (s *Storage) Rebalance(ctx context.Context) (int, error) {
+	defer s.tracker.ResetCounts()
+
+	moves := s.planMoves(time.Now())
+	moved := 0
+
+	for _, move := range moves {
+		select {
+		case <-ctx.Done():
+			return moved, ctx.Err()
+		default:
+		}
+
+		ok, err := s.moveModel(move)
+		if err != nil {
+			return moved, err
+		}
+		if ok {
+			moved++
+		}
+	}
+
+	return moved, nil
+}
+
+// planMoves decides which models should change tiers, respecting the
+// capacity of the target tier
+func (s *Storage) planMoves(now time.Time) []tierMove {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.tiers) < 2 {
+		return nil
+	}
+
+	usage := s.tierUsage()
+	var moves []tierMove
+
+	for id, model := range s.metadata {
+		current := s.modelTier[id]
+		target := s.policy.Target(current, len(s.tiers), s.tracker.Stats(id), now)
+		if target == current {
+			continue
+		}
+
+		if limit := s.tiers[target].MaxSize; limit > 0 && usage[target]+model.Size > limit {
+			continue
+		}
+
+		usage[current] -= model.Size
+		usage[target] += model.Size
+		moves = append(moves, tierMove{modelID: id, from: current, to: target})
+	}
+
+	return moves
+}
+
+// moveModel copies a model to its target tier and switches reads over to
+// the new location. It reports false if the model changed in the meantime.
+func (s *Storage) moveModel(move tierMove) (bool, error) {
+	src := filepath.Join(s.tiers[move.from].Path, move.modelID)
+	dst := filepath.Join(s.tiers[move.to].Path, move.modelID)
+
+	if err := tiering.CopyPath(src, dst); err != nil {
+		return false, fmt.Errorf("failed to move model %s to tier %s: %w", move.modelID, s.tiers[move.to].Name, err)
+	}
+
+	s.mu.Lock()
+	_, exists := s.metadata[move.modelID]
+	if !exists || s.modelTier[move.modelID] != move.from {
+		s.mu.Unlock()
+		os.RemoveAll(dst)
+		return false, nil
+	}
+	s.modelTier[move.modelID] = move.to
+	s.mu.Unlock()
+
+	return true, os.RemoveAll(src)
+}
+
+// ModelTier returns the name of the tier currently holding a model
+func (s *Storage) ModelTier(modelID string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, exists := s.metadata[modelID]; !exists {
+		return "", fmt.Errorf("%w: %s", ErrModelNotFound, modelID)
+	}
+	return s.tiers[s.modelTier[modelID]].Name, nil
+}
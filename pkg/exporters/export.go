@@ -0,0 +1,214 @@
+package exporters
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+// ErrUnsupportedFormat is returned by Export for unknown format names
+var ErrUnsupportedFormat = errors.New("unsupported export format")
+
+// UnsupportedFeatureError reports parts of a model that the target format
+// cannot represent
+type UnsupportedFeatureError struct {
+	Format   string   `json:"format"`
+	Features []string `json:"features"`
+}
+
+func (e *UnsupportedFeatureError) Error() string {
+	return fmt.Sprintf("%s cannot represent: %s", e.Format, strings.Join(e.Features, ", "))
+}
+
+// Model is the geometry and materials written by the exporters
+type Model struct {
+	Mesh *importers.Mesh
+	// Groups are named face ranges as returned by
+	// VertexImporter.GetGroups. Faces outside any group are written
+	// without a name.
+	Groups []importers.MeshGroup
+	// Materials holds the materials by name
+	Materials map[string]*importers.Material
+}
+
+// NewModel collects the mesh, face groups and materials of an import.
+// materials may be nil.
+func NewModel(vertices *importers.VertexImporter, materials *importers.MaterialImporter) *Model {
+	model := &Model{
+		Mesh:      vertices.GetMesh(),
+		Groups:    vertices.GetGroups(),
+		Materials: make(map[string]*importers.Material),
+	}
+	if materials != nil {
+		for name, material := range materials.GetMaterials() {
+			model.Materials[name] = material
+		}
+	}
+	return model
+}
+
+// File is one file written by Export
+type File struct {
+	Name string
+	Data []byte
+}
+
+// Formats lists the format names accepted by Export
+var Formats = []string{"obj", "gltf", "glb", "stl", "ply"}
+
+// ExportOptions controls how Export writes a model
+type ExportOptions struct {
+	// Resolver loads the textures referenced by materials. They are
+	// embedded in GLB files and added as companion files of OBJ and glTF
+	// files. Without one, textures are only referenced by their paths.
+	Resolver importers.ResourceResolver
+}
+
+// Export encodes the model in the named format ("obj", "gltf", "glb",
+// "stl" or "ply"). The first file returned is the model file, named name
+// with the format's extension, followed by companion files such as the
+// MTL library of an OBJ file, the binary buffer of a glTF file and the
+// textures loaded by the resolver.
+func Export(format, name string, model *Model, opts ExportOptions) ([]File, error) {
+	var files []File
+	var err error
+	switch format {
+	case "obj":
+		files, err = exportOBJ(name, model)
+	case "gltf":
+		var gltf, bin bytes.Buffer
+		if err := WriteGLTF(&gltf, &bin, name+".bin", model, GLTFOptions{}); err != nil {
+			return nil, err
+		}
+		files = []File{{Name: name + ".gltf", Data: gltf.Bytes()}}
+		if bin.Len() > 0 {
+			files = append(files, File{Name: name + ".bin", Data: bin.Bytes()})
+		}
+	case "glb":
+		var glb bytes.Buffer
+		if err := WriteGLB(&glb, model, GLTFOptions{Resolver: opts.Resolver}); err != nil {
+			return nil, err
+		}
+		return []File{{Name: name + ".glb", Data: glb.Bytes()}}, nil
+	case "stl":
+		var stl bytes.Buffer
+		if err := WriteSTL(&stl, model); err != nil {
+			return nil, err
+		}
+		return []File{{Name: name + ".stl", Data: stl.Bytes()}}, nil
+	case "ply":
+		var ply bytes.Buffer
+		if err := WritePLY(&ply, model); err != nil {
+			return nil, err
+		}
+		return []File{{Name: name + ".ply", Data: ply.Bytes()}}, nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Resolver != nil {
+		files = append(files, textureFiles(model, opts.Resolver, files)...)
+	}
+	return files, nil
+}
+
+// DroppedFeatures lists the parts of a model that the format's writer
+// leaves out without failing: STL keeps only positions and PLY no groups
+// or materials. It returns nil when nothing is lost.
+func DroppedFeatures(format string, model *Model) []string {
+	if format != "stl" && format != "ply" {
+		return nil
+	}
+
+	var named, materials, polygons, normals, texCoords, colors, tangents bool
+	materials = len(model.Materials) > 0
+	for _, group := range model.Groups {
+		named = named || group.Object != "" || group.Group != ""
+		materials = materials || group.Material != ""
+	}
+	for _, polygon := range model.Mesh.Polygons {
+		polygons = polygons || len(polygon.Corners) > 3
+	}
+	for _, vertex := range model.Mesh.Vertices {
+		normals = normals || vertex.Normal != ([3]float64{})
+		texCoords = texCoords || vertex.TexCoords != ([2]float64{})
+		colors = colors || vertex.HasColor
+		tangents = tangents || vertex.HasTangent
+	}
+
+	features := []struct {
+		name    string
+		dropped bool
+	}{
+		{"groups", named},
+		{"materials", materials},
+		{"polygons", polygons && format == "stl"},
+		{"vertex normals", normals && format == "stl"},
+		{"texture coordinates", texCoords && format == "stl"},
+		{"vertex colors", colors && format == "stl"},
+		{"tangents", tangents},
+	}
+	var dropped []string
+	for _, feature := range features {
+		if feature.dropped {
+			dropped = append(dropped, feature.name)
+		}
+	}
+	return dropped
+}
+
+// textureFiles loads the textures referenced by the model's materials.
+// Textures that fail to load, whose paths leave the output directory or
+// that would replace one of files are left out.
+func textureFiles(model *Model, resolver importers.ResourceResolver, files []File) []File {
+	taken := make(map[string]bool, len(files))
+	for _, file := range files {
+		taken[file.Name] = true
+	}
+
+	var paths []string
+	for _, material := range model.Materials {
+		paths = append(paths, material.DiffuseMap, material.NormalMap, material.SpecularMap, material.EmissiveMap)
+		if p := material.PBR; p != nil {
+			paths = append(paths, p.BaseColorTexture, p.MetallicRoughnessTexture, p.MetallicTexture,
+				p.RoughnessTexture, p.NormalTexture, p.OcclusionTexture, p.EmissiveTexture)
+		}
+	}
+	sort.Strings(paths)
+
+	var textures []File
+	for _, name := range paths {
+		if name == "" || taken[name] || !fs.ValidPath(name) {
+			continue
+		}
+		taken[name] = true
+		if data, err := resolver(name); err == nil {
+			textures = append(textures, File{Name: name, Data: data})
+		}
+	}
+	return textures
+}
+
+// formatFloat formats a number with the fewest digits that parse back to
+// the same value
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// formatFloats formats numbers separated by spaces
+func formatFloats(values ...float64) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = formatFloat(v)
+	}
+	return strings.Join(parts, " ")
+}
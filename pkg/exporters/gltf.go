@@ -0,0 +1,629 @@
+package exporters
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"image"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/3FT-io/3DS/pkg/importers"
+	"github.com/3FT-io/3DS/pkg/textures"
+)
+
+const (
+	glbMagic      = 0x46546C67 // "glTF"
+	glbVersion    = 2
+	glbChunkJSON  = 0x4E4F534A // "JSON"
+	glbChunkBIN   = 0x004E4942 // "BIN\0"
+	glbHeaderSize = 12
+
+	gltfFloat         = 5126
+	gltfUnsignedShort = 5123
+	gltfUnsignedInt   = 5125
+
+	gltfArrayBuffer        = 34962
+	gltfElementArrayBuffer = 34963
+
+	gltfModePoints    = 0
+	gltfModeTriangles = 4
+)
+
+// GLTFOptions controls how WriteGLTF and WriteGLB write a model
+type GLTFOptions struct {
+	// Resolver loads the PNG and JPEG images referenced by materials so
+	// that they are embedded in the binary buffer. Without one, or for
+	// images it cannot load, images are referenced by URI.
+	Resolver importers.ResourceResolver
+}
+
+// The gltf types mirror the parts of the glTF 2.0 JSON schema written by
+// the exporter
+type gltfDocument struct {
+	Asset       gltfAsset        `json:"asset"`
+	Scene       int              `json:"scene"`
+	Scenes      []gltfScene      `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes,omitempty"`
+	Meshes      []gltfMesh       `json:"meshes,omitempty"`
+	Materials   []gltfMaterial   `json:"materials,omitempty"`
+	Textures    []gltfTexture    `json:"textures,omitempty"`
+	Images      []gltfImage      `json:"images,omitempty"`
+	Accessors   []gltfAccessor   `json:"accessors,omitempty"`
+	BufferViews []gltfBufferView `json:"bufferViews,omitempty"`
+	Buffers     []gltfBuffer     `json:"buffers,omitempty"`
+}
+
+type gltfAsset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator"`
+}
+
+type gltfScene struct {
+	Nodes []int `json:"nodes"`
+}
+
+type gltfNode struct {
+	Mesh int `json:"mesh"`
+}
+
+type gltfMesh struct {
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices,omitempty"`
+	Material   *int           `json:"material,omitempty"`
+	Mode       int            `json:"mode"`
+}
+
+type gltfAccessor struct {
+	BufferView    int       `json:"bufferView"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float64 `json:"min,omitempty"`
+	Max           []float64 `json:"max,omitempty"`
+}
+
+type gltfBufferView struct {
+	Buffer     int  `json:"buffer"`
+	ByteOffset int  `json:"byteOffset"`
+	ByteLength int  `json:"byteLength"`
+	Target     *int `json:"target,omitempty"`
+}
+
+type gltfBuffer struct {
+	URI        string `json:"uri,omitempty"`
+	ByteLength int    `json:"byteLength"`
+}
+
+type gltfTextureInfo struct {
+	Index    int      `json:"index"`
+	Scale    *float64 `json:"scale,omitempty"`
+	Strength *float64 `json:"strength,omitempty"`
+}
+
+type gltfPBR struct {
+	BaseColorFactor          [4]float64       `json:"baseColorFactor"`
+	BaseColorTexture         *gltfTextureInfo `json:"baseColorTexture,omitempty"`
+	MetallicFactor           float64          `json:"metallicFactor"`
+	RoughnessFactor          float64          `json:"roughnessFactor"`
+	MetallicRoughnessTexture *gltfTextureInfo `json:"metallicRoughnessTexture,omitempty"`
+}
+
+type gltfMaterial struct {
+	Name                 string           `json:"name"`
+	PBRMetallicRoughness gltfPBR          `json:"pbrMetallicRoughness"`
+	NormalTexture        *gltfTextureInfo `json:"normalTexture,omitempty"`
+	OcclusionTexture     *gltfTextureInfo `json:"occlusionTexture,omitempty"`
+	EmissiveTexture      *gltfTextureInfo `json:"emissiveTexture,omitempty"`
+	EmissiveFactor       [3]float64       `json:"emissiveFactor"`
+	AlphaMode            string           `json:"alphaMode"`
+	AlphaCutoff          *float64         `json:"alphaCutoff,omitempty"`
+	DoubleSided          bool             `json:"doubleSided,omitempty"`
+}
+
+type gltfTexture struct {
+	Source int `json:"source"`
+}
+
+type gltfImage struct {
+	Name       string `json:"name,omitempty"`
+	URI        string `json:"uri,omitempty"`
+	BufferView *int   `json:"bufferView,omitempty"`
+	MimeType   string `json:"mimeType,omitempty"`
+}
+
+// gltfBuilder accumulates the document and its binary buffer
+type gltfBuilder struct {
+	doc      gltfDocument
+	bin      bytes.Buffer
+	opts     GLTFOptions
+	textures map[string]int
+}
+
+// WriteGLB writes the model as a single binary glTF file with all
+// geometry, and images the resolver loads, in its BIN chunk
+func WriteGLB(w io.Writer, model *Model, opts GLTFOptions) error {
+	doc, bin, err := buildGLTF(model, opts)
+	if err != nil {
+		return err
+	}
+	if len(bin) > 0 {
+		doc.Buffers = []gltfBuffer{{ByteLength: len(bin)}}
+	}
+
+	jsonData, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	jsonData = pad4(jsonData, ' ')
+	bin = pad4(bin, 0)
+
+	length := glbHeaderSize + 8 + len(jsonData)
+	if len(bin) > 0 {
+		length += 8 + len(bin)
+	}
+
+	var out bytes.Buffer
+	for _, v := range []uint32{glbMagic, glbVersion, uint32(length), uint32(len(jsonData)), glbChunkJSON} {
+		binary.Write(&out, binary.LittleEndian, v)
+	}
+	out.Write(jsonData)
+	if len(bin) > 0 {
+		binary.Write(&out, binary.LittleEndian, uint32(len(bin)))
+		binary.Write(&out, binary.LittleEndian, uint32(glbChunkBIN))
+		out.Write(bin)
+	}
+
+	_, err = w.Write(out.Bytes())
+	return err
+}
+
+// WriteGLTF writes the model as glTF JSON to w and its binary buffer to
+// bin. The JSON references the buffer by binURI.
+func WriteGLTF(w io.Writer, bin io.Writer, binURI string, model *Model, opts GLTFOptions) error {
+	doc, data, err := buildGLTF(model, opts)
+	if err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		doc.Buffers = []gltfBuffer{{URI: escapeURI(binURI), ByteLength: len(data)}}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err = bin.Write(data)
+	return err
+}
+
+// buildGLTF converts the model into a glTF document with one node and one
+// mesh holding a primitive per material, and the binary buffer it uses.
+// Texture coordinates are flipped to the glTF convention, missing normals
+// are generated and all materials are written in name order.
+func buildGLTF(model *Model, opts GLTFOptions) (*gltfDocument, []byte, error) {
+	b := &gltfBuilder{
+		doc: gltfDocument{
+			Asset:  gltfAsset{Version: "2.0", Generator: "3DS"},
+			Scenes: []gltfScene{{Nodes: []int{}}},
+		},
+		opts:     opts,
+		textures: make(map[string]int),
+	}
+
+	names := make([]string, 0, len(model.Materials))
+	for name := range model.Materials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	materialIndex := make(map[string]int, len(names))
+	for i, name := range names {
+		materialIndex[name] = i
+		b.doc.Materials = append(b.doc.Materials, b.material(name, model.Materials[name]))
+	}
+
+	mesh := prepareMesh(model.Mesh)
+	if len(mesh.Vertices) == 0 {
+		return &b.doc, b.bin.Bytes(), nil
+	}
+	attributes := b.attributes(mesh)
+
+	if mesh.Primitive == importers.PrimitivePoints {
+		b.addMesh(gltfPrimitive{Attributes: attributes, Mode: gltfModePoints})
+		return &b.doc, b.bin.Bytes(), nil
+	}
+
+	// Group triangles by material, keeping the order of first use
+	materials := triangleMaterials(model, len(mesh.Indices)/3)
+	var order []string
+	byMaterial := make(map[string][]uint32)
+	for t, material := range materials {
+		if _, ok := byMaterial[material]; !ok {
+			order = append(order, material)
+		}
+		byMaterial[material] = append(byMaterial[material], mesh.Indices[t*3:t*3+3]...)
+	}
+
+	var primitives []gltfPrimitive
+	for _, material := range order {
+		indices := b.indices(byMaterial[material])
+		primitive := gltfPrimitive{Attributes: attributes, Indices: &indices, Mode: gltfModeTriangles}
+		if index, ok := materialIndex[material]; ok {
+			primitive.Material = &index
+		}
+		primitives = append(primitives, primitive)
+	}
+	b.addMesh(primitives...)
+
+	return &b.doc, b.bin.Bytes(), nil
+}
+
+// prepareMesh returns the mesh with unit normals, generating the missing
+// ones when only some vertices have a normal
+func prepareMesh(mesh *importers.Mesh) *importers.Mesh {
+	var withNormal, withoutNormal bool
+	for _, vertex := range mesh.Vertices {
+		if vertex.Normal == ([3]float64{}) {
+			withoutNormal = true
+		} else {
+			withNormal = true
+		}
+	}
+
+	prepared := &importers.Mesh{
+		Vertices:  append([]importers.Vertex(nil), mesh.Vertices...),
+		Indices:   mesh.Indices[:len(mesh.Indices)/3*3],
+		Primitive: mesh.Primitive,
+	}
+	if mesh.Primitive == importers.PrimitivePoints {
+		prepared.Indices = nil
+	}
+	if withNormal && withoutNormal && mesh.Primitive == importers.PrimitiveTriangles {
+		prepared.GenerateNormals(importers.NormalOptions{})
+	}
+	for i, vertex := range prepared.Vertices {
+		if length := math.Sqrt(dot3(vertex.Normal, vertex.Normal)); length > 0 {
+			for c := range vertex.Normal {
+				prepared.Vertices[i].Normal[c] = vertex.Normal[c] / length
+			}
+		}
+	}
+	return prepared
+}
+
+// triangleMaterials returns the material name of every triangle from the
+// model's groups. Without groups, a model with a single material uses it
+// for all triangles.
+func triangleMaterials(model *Model, triangles int) []string {
+	materials := make([]string, triangles)
+	if len(model.Groups) == 0 && len(model.Materials) == 1 {
+		for name := range model.Materials {
+			for t := range materials {
+				materials[t] = name
+			}
+		}
+	}
+	for _, group := range model.Groups {
+		for t := group.Start / 3; t < (group.Start+group.Count)/3 && t < triangles; t++ {
+			materials[t] = group.Material
+		}
+	}
+	return materials
+}
+
+// attributes writes the vertex attributes shared by all primitives.
+// Normals, texture coordinates and colors are written if any vertex has
+// them, tangents only if all vertices have one along with a normal.
+func (b *gltfBuilder) attributes(mesh *importers.Mesh) map[string]int {
+	var hasNormals, hasTexCoords, hasColors bool
+	hasTangents := true
+	for _, vertex := range mesh.Vertices {
+		hasNormals = hasNormals || vertex.Normal != ([3]float64{})
+		hasTexCoords = hasTexCoords || vertex.TexCoords != ([2]float64{})
+		hasColors = hasColors || vertex.HasColor
+		hasTangents = hasTangents && vertex.HasTangent
+	}
+	hasTangents = hasTangents && hasNormals
+
+	// Every attribute gets bounds, which glTF requires for POSITION
+	attribute := func(accessorType string, components int, value func(v importers.Vertex) []float64) int {
+		values := make([]float32, 0, len(mesh.Vertices)*components)
+		for _, vertex := range mesh.Vertices {
+			for _, v := range value(vertex) {
+				values = append(values, float32(v))
+			}
+		}
+
+		min, max := float32Bounds(values, components)
+		view := b.addView(float32Bytes(values), gltfArrayBuffer)
+		return b.addAccessor(gltfAccessor{
+			BufferView:    view,
+			ComponentType: gltfFloat,
+			Count:         len(mesh.Vertices),
+			Type:          accessorType,
+			Min:           min,
+			Max:           max,
+		})
+	}
+
+	attributes := map[string]int{
+		"POSITION": attribute("VEC3", 3, func(v importers.Vertex) []float64 { return v.Position[:] }),
+	}
+	if hasNormals {
+		attributes["NORMAL"] = attribute("VEC3", 3, func(v importers.Vertex) []float64 { return v.Normal[:] })
+	}
+	if hasTangents {
+		attributes["TANGENT"] = attribute("VEC4", 4, func(v importers.Vertex) []float64 {
+			sign := 1.0
+			if v.Tangent[3] < 0 {
+				sign = -1
+			}
+			return []float64{v.Tangent[0], v.Tangent[1], v.Tangent[2], sign}
+		})
+	}
+	if hasTexCoords {
+		attributes["TEXCOORD_0"] = attribute("VEC2", 2, func(v importers.Vertex) []float64 {
+			return []float64{v.TexCoords[0], 1 - v.TexCoords[1]}
+		})
+	}
+	if hasColors {
+		attributes["COLOR_0"] = attribute("VEC4", 4, func(v importers.Vertex) []float64 {
+			if !v.HasColor {
+				return []float64{1, 1, 1, 1}
+			}
+			return v.Color[:]
+		})
+	}
+	return attributes
+}
+
+// indices writes a triangle index accessor, using 16-bit indices when the
+// largest index allows. The largest value of a component type is reserved
+// for primitive restart.
+func (b *gltfBuilder) indices(indices []uint32) int {
+	var largest uint32
+	for _, index := range indices {
+		largest = max(largest, index)
+	}
+
+	var data []byte
+	componentType := gltfUnsignedInt
+	if largest < math.MaxUint16 {
+		componentType = gltfUnsignedShort
+		data = make([]byte, 2*len(indices))
+		for i, index := range indices {
+			binary.LittleEndian.PutUint16(data[2*i:], uint16(index))
+		}
+	} else {
+		data = make([]byte, 4*len(indices))
+		for i, index := range indices {
+			binary.LittleEndian.PutUint32(data[4*i:], index)
+		}
+	}
+
+	return b.addAccessor(gltfAccessor{
+		BufferView:    b.addView(data, gltfElementArrayBuffer),
+		ComponentType: componentType,
+		Count:         len(indices),
+		Type:          "SCALAR",
+	})
+}
+
+// material converts a material to metallic-roughness parameters, clamped
+// to the ranges glTF allows
+func (b *gltfBuilder) material(name string, m *importers.Material) gltfMaterial {
+	p := m.ToPBR()
+	material := gltfMaterial{
+		Name: name,
+		PBRMetallicRoughness: gltfPBR{
+			MetallicFactor:           clamp01(p.MetallicFactor),
+			RoughnessFactor:          clamp01(p.RoughnessFactor),
+			BaseColorTexture:         b.texture(p.BaseColorTexture),
+			MetallicRoughnessTexture: b.texture(p.MetallicRoughnessTexture),
+		},
+		NormalTexture:    b.texture(p.NormalTexture),
+		OcclusionTexture: b.texture(p.OcclusionTexture),
+		EmissiveTexture:  b.texture(p.EmissiveTexture),
+		AlphaMode:        p.AlphaMode,
+		DoubleSided:      p.DoubleSided,
+	}
+	for i, v := range p.BaseColorFactor {
+		material.PBRMetallicRoughness.BaseColorFactor[i] = clamp01(v)
+	}
+	for i, v := range p.EmissiveFactor {
+		material.EmissiveFactor[i] = clamp01(v)
+	}
+
+	switch material.AlphaMode {
+	case "MASK":
+		cutoff := max(p.AlphaCutoff, 0)
+		material.AlphaCutoff = &cutoff
+	case "BLEND":
+	default:
+		material.AlphaMode = "OPAQUE"
+	}
+	if material.PBRMetallicRoughness.MetallicRoughnessTexture == nil {
+		material.PBRMetallicRoughness.MetallicRoughnessTexture = b.packedTexture(p.MetallicTexture, p.RoughnessTexture)
+	}
+	if material.NormalTexture != nil && p.NormalScale != 1 {
+		scale := p.NormalScale
+		material.NormalTexture.Scale = &scale
+	}
+	if material.OcclusionTexture != nil && p.OcclusionStrength != 1 {
+		strength := clamp01(p.OcclusionStrength)
+		material.OcclusionTexture.Strength = &strength
+	}
+	return material
+}
+
+// texture returns the texture for an image URI, adding it on first use.
+// Images the resolver loads are embedded under their URI as name.
+func (b *gltfBuilder) texture(uri string) *gltfTextureInfo {
+	if uri == "" {
+		return nil
+	}
+	if index, ok := b.textures[uri]; ok {
+		return &gltfTextureInfo{Index: index}
+	}
+
+	image := gltfImage{URI: escapeURI(uri)}
+	if b.opts.Resolver != nil {
+		if data, err := b.opts.Resolver(uri); err == nil {
+			if mimeType := http.DetectContentType(data); mimeType == "image/png" || mimeType == "image/jpeg" {
+				view := b.addView(data, 0)
+				image = gltfImage{Name: uri, BufferView: &view, MimeType: mimeType}
+			}
+		}
+	}
+
+	b.doc.Images = append(b.doc.Images, image)
+	b.doc.Textures = append(b.doc.Textures, gltfTexture{Source: len(b.doc.Images) - 1})
+	index := len(b.doc.Textures) - 1
+	b.textures[uri] = index
+	return &gltfTextureInfo{Index: index}
+}
+
+// packedTexture returns a texture combining separate metallic and
+// roughness images into the glTF layout, roughness in green and metallic
+// in blue. It needs the resolver to load the images and returns nil if it
+// cannot.
+func (b *gltfBuilder) packedTexture(metallic, roughness string) *gltfTextureInfo {
+	if metallic == "" && roughness == "" || b.opts.Resolver == nil {
+		return nil
+	}
+	key := metallic + "\x00" + roughness
+	if index, ok := b.textures[key]; ok {
+		return &gltfTextureInfo{Index: index}
+	}
+
+	data, err := packMetallicRoughness(b.opts.Resolver, metallic, roughness)
+	if err != nil {
+		return nil
+	}
+	view := b.addView(data, 0)
+	b.doc.Images = append(b.doc.Images, gltfImage{BufferView: &view, MimeType: "image/png"})
+	b.doc.Textures = append(b.doc.Textures, gltfTexture{Source: len(b.doc.Images) - 1})
+	index := len(b.doc.Textures) - 1
+	b.textures[key] = index
+	return &gltfTextureInfo{Index: index}
+}
+
+// packMetallicRoughness loads the metallic and roughness images, either of
+// which may be empty, and encodes their first channels as a PNG image with
+// roughness in green and metallic in blue. A missing image leaves its
+// channel at full value so the factor alone applies. The result has the
+// size of the metallic image if there is one.
+func packMetallicRoughness(resolver importers.ResourceResolver, metallic, roughness string) ([]byte, error) {
+	var sources [2]*image.RGBA
+	var width, height int
+	for i, uri := range []string{metallic, roughness} {
+		if uri == "" {
+			continue
+		}
+		data, err := resolver(uri)
+		if err != nil {
+			return nil, err
+		}
+		img, info, err := textures.Decode(data)
+		if err != nil {
+			return nil, err
+		}
+		if width == 0 {
+			width, height = info.Width, info.Height
+		}
+		sources[i] = textures.Downscale(img, width, height)
+	}
+
+	packed := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for offset := 0; offset < len(packed.Pix); offset += 4 {
+		copy(packed.Pix[offset:offset+4], []uint8{255, 255, 255, 255})
+		if sources[0] != nil {
+			packed.Pix[offset+2] = sources[0].Pix[offset]
+		}
+		if sources[1] != nil {
+			packed.Pix[offset+1] = sources[1].Pix[offset]
+		}
+	}
+	return textures.Encode(packed, "png")
+}
+
+// addView appends data to the buffer at a 4-byte aligned offset, which
+// satisfies the alignment of every component type. A zero target is
+// omitted, as for image data.
+func (b *gltfBuilder) addView(data []byte, target int) int {
+	for b.bin.Len()%4 != 0 {
+		b.bin.WriteByte(0)
+	}
+	view := gltfBufferView{ByteOffset: b.bin.Len(), ByteLength: len(data)}
+	if target != 0 {
+		view.Target = &target
+	}
+	b.bin.Write(data)
+	b.doc.BufferViews = append(b.doc.BufferViews, view)
+	return len(b.doc.BufferViews) - 1
+}
+
+func (b *gltfBuilder) addAccessor(accessor gltfAccessor) int {
+	b.doc.Accessors = append(b.doc.Accessors, accessor)
+	return len(b.doc.Accessors) - 1
+}
+
+// addMesh adds a mesh with the given primitives and a node instancing it
+func (b *gltfBuilder) addMesh(primitives ...gltfPrimitive) {
+	b.doc.Meshes = append(b.doc.Meshes, gltfMesh{Primitives: primitives})
+	b.doc.Nodes = append(b.doc.Nodes, gltfNode{Mesh: len(b.doc.Meshes) - 1})
+	b.doc.Scenes[0].Nodes = append(b.doc.Scenes[0].Nodes, len(b.doc.Nodes)-1)
+}
+
+// float32Bounds returns the per-component minimum and maximum, which must
+// match the stored float32 values exactly
+func float32Bounds(values []float32, components int) ([]float64, []float64) {
+	min := make([]float64, components)
+	max := make([]float64, components)
+	for c := 0; c < components; c++ {
+		min[c], max[c] = math.Inf(1), math.Inf(-1)
+	}
+	for i, v := range values {
+		c := i % components
+		min[c] = math.Min(min[c], float64(v))
+		max[c] = math.Max(max[c], float64(v))
+	}
+	return min, max
+}
+
+func float32Bytes(values []float32) []byte {
+	data := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(v))
+	}
+	return data
+}
+
+// pad4 pads data to a multiple of four bytes as GLB chunks require
+func pad4(data []byte, fill byte) []byte {
+	for len(data)%4 != 0 {
+		data = append(data, fill)
+	}
+	return data
+}
+
+// escapeURI escapes a relative file path for use as a glTF URI
+func escapeURI(path string) string {
+	return (&url.URL{Path: path}).String()
+}
+
+func clamp01(v float64) float64 {
+	return math.Min(math.Max(v, 0), 1)
+}
+
+func dot3(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
@@ -0,0 +1,275 @@
+package exporters
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+// OBJOptions controls how WriteOBJ writes a model
+type OBJOptions struct {
+	// MaterialLibrary is the MTL file referenced by mtllib. No library is
+	// referenced if it is empty.
+	MaterialLibrary string
+}
+
+// objPosition is a position line of an OBJ file with its optional color
+type objPosition struct {
+	position [3]float64
+	color    [3]float64
+	hasColor bool
+}
+
+// WriteOBJ writes the model as an OBJ file. Positions, texture coordinates
+// and normals are written once each and faces reference them by v/vt/vn
+// indices; texture coordinates and normals are written for all vertices if
+// any vertex has them. Polygons recorded at import are written whole,
+// other triangles as they are. Groups become o, g, usemtl and s statements
+// and vertex colors are written as "v x y z r g b". Tangents and the alpha
+// of vertex colors are not written.
+func WriteOBJ(w io.Writer, model *Model, opts OBJOptions) error {
+	mesh := model.Mesh
+	if mesh.Primitive != importers.PrimitiveTriangles {
+		return &UnsupportedFeatureError{Format: "obj", Features: []string{"point clouds"}}
+	}
+
+	var hasTexCoords, hasNormals bool
+	for _, vertex := range mesh.Vertices {
+		hasTexCoords = hasTexCoords || vertex.TexCoords != ([2]float64{})
+		hasNormals = hasNormals || vertex.Normal != ([3]float64{})
+	}
+
+	// refs holds the 1-based v, vt and vn indices of every vertex
+	refs := make([][3]int, len(mesh.Vertices))
+	positionIndex := make(map[objPosition]int)
+	texCoordIndex := make(map[[2]float64]int)
+	normalIndex := make(map[[3]float64]int)
+	var positions []objPosition
+	var texCoords [][2]float64
+	var normals [][3]float64
+	for i, vertex := range mesh.Vertices {
+		position := objPosition{position: vertex.Position, hasColor: vertex.HasColor}
+		if vertex.HasColor {
+			position.color = [3]float64{vertex.Color[0], vertex.Color[1], vertex.Color[2]}
+		}
+		if _, ok := positionIndex[position]; !ok {
+			positions = append(positions, position)
+			positionIndex[position] = len(positions)
+		}
+		refs[i][0] = positionIndex[position]
+
+		if hasTexCoords {
+			if _, ok := texCoordIndex[vertex.TexCoords]; !ok {
+				texCoords = append(texCoords, vertex.TexCoords)
+				texCoordIndex[vertex.TexCoords] = len(texCoords)
+			}
+			refs[i][1] = texCoordIndex[vertex.TexCoords]
+		}
+		if hasNormals {
+			if _, ok := normalIndex[vertex.Normal]; !ok {
+				normals = append(normals, vertex.Normal)
+				normalIndex[vertex.Normal] = len(normals)
+			}
+			refs[i][2] = normalIndex[vertex.Normal]
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	if opts.MaterialLibrary != "" {
+		fmt.Fprintf(bw, "mtllib %s\n", opts.MaterialLibrary)
+	}
+	for _, p := range positions {
+		if p.hasColor {
+			fmt.Fprintf(bw, "v %s %s\n", formatFloats(p.position[:]...), formatFloats(p.color[:]...))
+		} else {
+			fmt.Fprintf(bw, "v %s\n", formatFloats(p.position[:]...))
+		}
+	}
+	for _, t := range texCoords {
+		fmt.Fprintf(bw, "vt %s\n", formatFloats(t[:]...))
+	}
+	for _, n := range normals {
+		fmt.Fprintf(bw, "vn %s\n", formatFloats(n[:]...))
+	}
+
+	writeFace := func(corners []uint32) error {
+		bw.WriteString("f")
+		for _, index := range corners {
+			if int(index) >= len(refs) {
+				return fmt.Errorf("vertex index out of range: %d", index)
+			}
+			ref := refs[index]
+			bw.WriteString(" " + strconv.Itoa(ref[0]))
+			switch {
+			case hasTexCoords && hasNormals:
+				fmt.Fprintf(bw, "/%d/%d", ref[1], ref[2])
+			case hasTexCoords:
+				fmt.Fprintf(bw, "/%d", ref[1])
+			case hasNormals:
+				fmt.Fprintf(bw, "//%d", ref[2])
+			}
+		}
+		bw.WriteString("\n")
+		return nil
+	}
+
+	groups := append([]importers.MeshGroup(nil), model.Groups...)
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Start < groups[j].Start })
+	polygons := append([]importers.Polygon(nil), mesh.Polygons...)
+	sort.SliceStable(polygons, func(i, j int) bool { return polygons[i].Start < polygons[j].Start })
+
+	// Smoothing groups are only written if the model uses any, so that
+	// OBJ files without s statements stay without
+	smoothing := false
+	for _, group := range groups {
+		smoothing = smoothing || group.SmoothingGroup != 0
+	}
+
+	// current mirrors the state an OBJ reader has after the statements
+	// written so far
+	var current importers.MeshGroup
+	writeGroup := func(group importers.MeshGroup) {
+		if group.Object != current.Object && group.Object != "" {
+			fmt.Fprintf(bw, "o %s\n", group.Object)
+			current.Group = ""
+		}
+		// "g default" and a bare usemtl return to no group and no material
+		switch {
+		case group.Group == current.Group:
+		case group.Group == "":
+			bw.WriteString("g default\n")
+		default:
+			fmt.Fprintf(bw, "g %s\n", group.Group)
+		}
+		switch {
+		case group.Material == current.Material:
+		case group.Material == "":
+			bw.WriteString("usemtl\n")
+		default:
+			fmt.Fprintf(bw, "usemtl %s\n", group.Material)
+		}
+		if smoothing && group.SmoothingGroup != current.SmoothingGroup {
+			if group.SmoothingGroup == 0 {
+				bw.WriteString("s off\n")
+			} else {
+				fmt.Fprintf(bw, "s %d\n", group.SmoothingGroup)
+			}
+		}
+		current = group
+	}
+
+	g, p := 0, 0
+	for i := 0; i+3 <= len(mesh.Indices); {
+		for g < len(groups) && groups[g].Start <= i {
+			writeGroup(groups[g])
+			g++
+		}
+		for p < len(polygons) && polygons[p].Start < i {
+			p++
+		}
+
+		if p < len(polygons) && polygons[p].Start == i && polygons[p].Count > 0 {
+			if err := writeFace(polygons[p].Corners); err != nil {
+				return err
+			}
+			i += polygons[p].Count
+			p++
+			continue
+		}
+		if err := writeFace(mesh.Indices[i : i+3]); err != nil {
+			return err
+		}
+		i += 3
+	}
+
+	return bw.Flush()
+}
+
+// WriteMTL writes materials as an MTL file in name order. PBR parameters
+// are written with the Pr, Pm, map_Pr and map_Pm extensions.
+func WriteMTL(w io.Writer, materials map[string]*importers.Material) error {
+	names := make([]string, 0, len(materials))
+	for name := range materials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for i, name := range names {
+		m := materials[name]
+		if i > 0 {
+			bw.WriteString("\n")
+		}
+		fmt.Fprintf(bw, "newmtl %s\n", name)
+		fmt.Fprintf(bw, "Ka %s\n", formatFloats(m.AmbientColor[:]...))
+		fmt.Fprintf(bw, "Kd %s\n", formatFloats(m.DiffuseColor[:]...))
+		fmt.Fprintf(bw, "Ks %s\n", formatFloats(m.SpecularColor[:]...))
+		fmt.Fprintf(bw, "Ns %s\n", formatFloat(m.Shininess))
+		if m.EmissiveColor != ([3]float64{}) {
+			fmt.Fprintf(bw, "Ke %s\n", formatFloats(m.EmissiveColor[:]...))
+		}
+		// A zero Transparency means d was not given
+		if m.Transparency != 0 {
+			fmt.Fprintf(bw, "d %s\n", formatFloat(m.Transparency))
+		}
+
+		maps := []struct{ statement, file string }{
+			{"map_Kd", m.DiffuseMap},
+			{"map_Ks", m.SpecularMap},
+			{"map_Bump", m.NormalMap},
+			{"map_Ke", m.EmissiveMap},
+		}
+		if m.PBR != nil {
+			fmt.Fprintf(bw, "Pr %s\n", formatFloat(m.PBR.RoughnessFactor))
+			fmt.Fprintf(bw, "Pm %s\n", formatFloat(m.PBR.MetallicFactor))
+			maps = append(maps,
+				struct{ statement, file string }{"map_Pr", m.PBR.RoughnessTexture},
+				struct{ statement, file string }{"map_Pm", m.PBR.MetallicTexture},
+			)
+		}
+		for _, texture := range maps {
+			if texture.file != "" {
+				fmt.Fprintf(bw, "%s %s\n", texture.statement, texture.file)
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// exportOBJ writes an OBJ file and, if the model has materials, its MTL
+// library. Whitespace in the library's name is replaced by underscores
+// since mtllib separates file names by whitespace.
+func exportOBJ(name string, model *Model) ([]File, error) {
+	var opts OBJOptions
+	if len(model.Materials) > 0 {
+		opts.MaterialLibrary = strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return '_'
+			}
+			return r
+		}, name) + ".mtl"
+	}
+
+	var obj bytes.Buffer
+	if err := WriteOBJ(&obj, model, opts); err != nil {
+		return nil, err
+	}
+	files := []File{{Name: name + ".obj", Data: obj.Bytes()}}
+
+	if opts.MaterialLibrary != "" {
+		var mtl bytes.Buffer
+		if err := WriteMTL(&mtl, model.Materials); err != nil {
+			return nil, err
+		}
+		files = append(files, File{Name: opts.MaterialLibrary, Data: mtl.Bytes()})
+	}
+	return files, nil
+}
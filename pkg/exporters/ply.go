@@ -0,0 +1,117 @@
+package exporters
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+// WritePLY writes the model as a binary little-endian PLY file. Vertices
+// keep their normals, texture coordinates and colors, each written if any
+// vertex has them, with colors as 8-bit channels. Polygons recorded at
+// import are written whole; point clouds are written without faces.
+// Groups, materials and tangents are not written; DroppedFeatures lists
+// those the model has.
+func WritePLY(w io.Writer, model *Model) error {
+	mesh := model.Mesh
+
+	var hasNormals, hasTexCoords, hasColors bool
+	for _, vertex := range mesh.Vertices {
+		hasNormals = hasNormals || vertex.Normal != ([3]float64{})
+		hasTexCoords = hasTexCoords || vertex.TexCoords != ([2]float64{})
+		hasColors = hasColors || vertex.HasColor
+	}
+
+	var faces [][]uint32
+	if mesh.Primitive == importers.PrimitiveTriangles {
+		faces = polygonFaces(mesh)
+	}
+	for _, face := range faces {
+		for _, index := range face {
+			if int(index) >= len(mesh.Vertices) {
+				return fmt.Errorf("vertex index out of range: %d", index)
+			}
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("ply\nformat binary_little_endian 1.0\ncomment 3DS\n")
+	fmt.Fprintf(bw, "element vertex %d\n", len(mesh.Vertices))
+	bw.WriteString("property float x\nproperty float y\nproperty float z\n")
+	if hasNormals {
+		bw.WriteString("property float nx\nproperty float ny\nproperty float nz\n")
+	}
+	if hasTexCoords {
+		bw.WriteString("property float s\nproperty float t\n")
+	}
+	if hasColors {
+		bw.WriteString("property uchar red\nproperty uchar green\nproperty uchar blue\nproperty uchar alpha\n")
+	}
+	if mesh.Primitive == importers.PrimitiveTriangles {
+		fmt.Fprintf(bw, "element face %d\n", len(faces))
+		bw.WriteString("property list uchar uint vertex_indices\n")
+	}
+	bw.WriteString("end_header\n")
+
+	writeFloats := func(values ...float64) {
+		for _, v := range values {
+			binary.Write(bw, binary.LittleEndian, float32(v))
+		}
+	}
+	for _, vertex := range mesh.Vertices {
+		writeFloats(vertex.Position[:]...)
+		if hasNormals {
+			writeFloats(vertex.Normal[:]...)
+		}
+		if hasTexCoords {
+			writeFloats(vertex.TexCoords[:]...)
+		}
+		if hasColors {
+			color := [4]float64{1, 1, 1, 1}
+			if vertex.HasColor {
+				color = vertex.Color
+			}
+			for _, c := range color {
+				bw.WriteByte(uint8(math.Round(clamp01(c) * math.MaxUint8)))
+			}
+		}
+	}
+
+	for _, face := range faces {
+		bw.WriteByte(uint8(len(face)))
+		for _, index := range face {
+			binary.Write(bw, binary.LittleEndian, index)
+		}
+	}
+
+	return bw.Flush()
+}
+
+// polygonFaces returns the faces of a triangle mesh: the polygons recorded
+// at import with up to 255 corners, and the remaining triangles
+func polygonFaces(mesh *importers.Mesh) [][]uint32 {
+	polygons := append([]importers.Polygon(nil), mesh.Polygons...)
+	sort.SliceStable(polygons, func(i, j int) bool { return polygons[i].Start < polygons[j].Start })
+
+	var faces [][]uint32
+	p := 0
+	for i := 0; i+3 <= len(mesh.Indices); {
+		for p < len(polygons) && polygons[p].Start < i {
+			p++
+		}
+		if p < len(polygons) && polygons[p].Start == i && polygons[p].Count > 0 && len(polygons[p].Corners) <= math.MaxUint8 {
+			faces = append(faces, polygons[p].Corners)
+			i += polygons[p].Count
+			p++
+			continue
+		}
+		faces = append(faces, mesh.Indices[i:i+3])
+		i += 3
+	}
+	return faces
+}
@@ -0,0 +1,39 @@
+package exporters
+
+import "github.com/3FT-io/3DS/pkg/importers"
+
+// Simplify returns the model reduced to about targetTriangles triangles
+// with Mesh.Simplify, along with the simplification error. Borders
+// between groups are kept, so every group keeps its material; groups left
+// without triangles are dropped.
+func (m *Model) Simplify(targetTriangles int) (*Model, float64) {
+	labels := make([]int, m.Mesh.TriangleCount())
+	for t := range labels {
+		labels[t] = -1
+	}
+	for g, group := range m.Groups {
+		for t := group.Start / 3; t < (group.Start+group.Count)/3 && t < len(labels); t++ {
+			labels[t] = g
+		}
+	}
+
+	result := m.Mesh.Simplify(importers.SimplifyOptions{TargetTriangles: targetTriangles, Labels: labels})
+	simplified := &Model{Mesh: result.Mesh, Materials: m.Materials}
+
+	// Triangles keep their order, so every group is one run of labels
+	for t, label := range result.Labels {
+		if label < 0 {
+			continue
+		}
+		last := len(simplified.Groups) - 1
+		if t > 0 && result.Labels[t-1] == label {
+			simplified.Groups[last].Count += 3
+			simplified.Groups[last].Faces++
+			continue
+		}
+		group := m.Groups[label]
+		group.Start, group.Count, group.Faces = t*3, 3, 1
+		simplified.Groups = append(simplified.Groups, group)
+	}
+	return simplified, result.Error
+}
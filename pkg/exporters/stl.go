@@ -0,0 +1,77 @@
+package exporters
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+const (
+	stlHeaderSize = 80
+	// stlHeader starts the binary header. It must not start with "solid",
+	// which marks ASCII files.
+	stlHeader = "3DS binary STL"
+)
+
+// WriteSTL writes the model's triangles as a binary STL file with face
+// normals computed from the positions. STL has no vertex attributes,
+// groups or materials, so only the positions are kept; DroppedFeatures
+// lists what is lost.
+func WriteSTL(w io.Writer, model *Model) error {
+	mesh := model.Mesh
+	if mesh.Primitive != importers.PrimitiveTriangles {
+		return &UnsupportedFeatureError{Format: "stl", Features: []string{"point clouds"}}
+	}
+	triangles := len(mesh.Indices) / 3
+	if triangles > math.MaxUint32 {
+		return &UnsupportedFeatureError{Format: "stl", Features: []string{fmt.Sprintf("%d triangles", triangles)}}
+	}
+
+	bw := bufio.NewWriter(w)
+	header := make([]byte, stlHeaderSize)
+	copy(header, stlHeader)
+	bw.Write(header)
+	binary.Write(bw, binary.LittleEndian, uint32(triangles))
+
+	record := make([]byte, 50)
+	putVector := func(at int, v [3]float64) {
+		for c := 0; c < 3; c++ {
+			binary.LittleEndian.PutUint32(record[at+4*c:], math.Float32bits(float32(v[c])))
+		}
+	}
+	for t := 0; t < triangles; t++ {
+		var corners [3][3]float64
+		for c := range corners {
+			index := mesh.Indices[t*3+c]
+			if int(index) >= len(mesh.Vertices) {
+				return fmt.Errorf("vertex index out of range: %d", index)
+			}
+			corners[c] = mesh.Vertices[index].Position
+		}
+
+		putVector(0, faceNormal(corners[0], corners[1], corners[2]))
+		for c, corner := range corners {
+			putVector(12+12*c, corner)
+		}
+		bw.Write(record)
+	}
+
+	return bw.Flush()
+}
+
+// faceNormal returns the unit normal of a counter-clockwise triangle, or
+// zero for a degenerate one
+func faceNormal(a, b, c [3]float64) [3]float64 {
+	u := [3]float64{b[0] - a[0], b[1] - a[1], b[2] - a[2]}
+	v := [3]float64{c[0] - a[0], c[1] - a[1], c[2] - a[2]}
+	n := [3]float64{u[1]*v[2] - u[2]*v[1], u[2]*v[0] - u[0]*v[2], u[0]*v[1] - u[1]*v[0]}
+	length := math.Sqrt(dot3(n, n))
+	if length == 0 {
+		return [3]float64{}
+	}
+	return [3]float64{n[0] / length, n[1] / length, n[2] / length}
+}
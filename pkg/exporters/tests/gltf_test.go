@@ -0,0 +1,296 @@
+package exporters_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/3FT-io/3DS/pkg/exporters"
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+// sceneModel is a quad and a triangle with all vertex attributes, using
+// a Phong and a PBR material. All values are exact in float32.
+func sceneModel() *exporters.Model {
+	vertex := func(x, y, u, v float64) importers.Vertex {
+		return importers.Vertex{
+			Position:   [3]float64{x, y, 0.5},
+			Normal:     [3]float64{0, 0, 1},
+			TexCoords:  [2]float64{u, v},
+			Color:      [4]float64{u, v, 0.5, 1},
+			HasColor:   true,
+			Tangent:    [4]float64{1, 0, 0, -1},
+			HasTangent: true,
+		}
+	}
+
+	return &exporters.Model{
+		Mesh: &importers.Mesh{
+			Vertices: []importers.Vertex{
+				vertex(-1, -1, 0, 0),
+				vertex(1, -1, 1, 0),
+				vertex(1, 1, 1, 1),
+				vertex(-1, 1, 0, 1),
+				vertex(2, 2, 0.25, 0.75),
+			},
+			Indices:   []uint32{0, 1, 2, 0, 2, 3, 2, 4, 3},
+			Primitive: importers.PrimitiveTriangles,
+		},
+		Groups: []importers.MeshGroup{
+			{Material: "Painted", Start: 0, Count: 6},
+			{Material: "Steel", Start: 6, Count: 3},
+		},
+		Materials: map[string]*importers.Material{
+			"Painted": {
+				Name:         "Painted",
+				DiffuseColor: [3]float64{0.5, 0.25, 0},
+				Shininess:    30,
+				DiffuseMap:   "textures/paint.png",
+			},
+			"Steel": (&importers.PBRMaterial{
+				BaseColorFactor:   [4]float64{0.75, 0.75, 0.75, 1},
+				MetallicFactor:    1,
+				RoughnessFactor:   0.25,
+				NormalTexture:     "steel_normal.png",
+				NormalScale:       0.5,
+				OcclusionStrength: 1,
+				AlphaMode:         "MASK",
+				AlphaCutoff:       0.25,
+				DoubleSided:       true,
+			}).ToPhong("Steel"),
+		},
+	}
+}
+
+// corners expands the triangles of a mesh into their vertices so meshes
+// can be compared regardless of how vertices are shared
+func corners(mesh *importers.Mesh) []importers.Vertex {
+	vertices := make([]importers.Vertex, len(mesh.Indices))
+	for i, index := range mesh.Indices {
+		vertices[i] = mesh.Vertices[index]
+	}
+	return vertices
+}
+
+// triangleMaterials returns the material of each triangle of an import
+func triangleMaterials(vertices *importers.VertexImporter) []string {
+	materials := make([]string, len(vertices.GetMesh().Indices)/3)
+	for _, group := range vertices.GetGroups() {
+		for i := group.Start / 3; i < (group.Start+group.Count)/3; i++ {
+			materials[i] = group.Material
+		}
+	}
+	return materials
+}
+
+// gltfJSON is the part of a glTF document the tests inspect
+type gltfJSON struct {
+	Meshes []struct {
+		Primitives []struct {
+			Attributes map[string]int `json:"attributes"`
+			Indices    *int           `json:"indices"`
+			Material   *int           `json:"material"`
+			Mode       int            `json:"mode"`
+		} `json:"primitives"`
+	} `json:"meshes"`
+	Materials []struct {
+		Name string `json:"name"`
+	} `json:"materials"`
+	Images []struct {
+		Name       string `json:"name"`
+		URI        string `json:"uri"`
+		BufferView *int   `json:"bufferView"`
+		MimeType   string `json:"mimeType"`
+	} `json:"images"`
+	Accessors []struct {
+		ComponentType int       `json:"componentType"`
+		Count         int       `json:"count"`
+		Min           []float64 `json:"min"`
+		Max           []float64 `json:"max"`
+	} `json:"accessors"`
+	BufferViews []struct {
+		ByteOffset int `json:"byteOffset"`
+		ByteLength int `json:"byteLength"`
+	} `json:"bufferViews"`
+	Buffers []struct {
+		URI        string `json:"uri"`
+		ByteLength int    `json:"byteLength"`
+	} `json:"buffers"`
+}
+
+func TestGLBRoundTrip(t *testing.T) {
+	model := sceneModel()
+
+	files, err := exporters.Export("glb", "scene", model, exporters.ExportOptions{})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "scene.glb", files[0].Name)
+
+	container, err := importers.ParseGLB(files[0].Data)
+	require.NoError(t, err)
+	assert.Zero(t, len(container.JSON)%4)
+	assert.Zero(t, len(container.BIN)%4)
+
+	var doc gltfJSON
+	require.NoError(t, json.Unmarshal(container.JSON, &doc))
+	require.Len(t, doc.Buffers, 1)
+	assert.Empty(t, doc.Buffers[0].URI)
+	for _, view := range doc.BufferViews {
+		assert.Zero(t, view.ByteOffset%4)
+		assert.LessOrEqual(t, view.ByteOffset+view.ByteLength, doc.Buffers[0].ByteLength)
+	}
+
+	// One primitive per material, sharing the vertex attributes
+	require.Len(t, doc.Meshes, 1)
+	primitives := doc.Meshes[0].Primitives
+	require.Len(t, primitives, 2)
+	assert.Equal(t, primitives[0].Attributes, primitives[1].Attributes)
+	assert.Equal(t, "Painted", doc.Materials[*primitives[0].Material].Name)
+	assert.Equal(t, "Steel", doc.Materials[*primitives[1].Material].Name)
+	assert.Equal(t, 4, primitives[0].Mode)
+	assert.Equal(t, 5123, doc.Accessors[*primitives[0].Indices].ComponentType)
+	for _, name := range []string{"POSITION", "NORMAL", "TEXCOORD_0", "COLOR_0", "TANGENT"} {
+		assert.Contains(t, primitives[0].Attributes, name)
+	}
+
+	position := doc.Accessors[primitives[0].Attributes["POSITION"]]
+	assert.Equal(t, 5, position.Count)
+	assert.Equal(t, []float64{-1, -1, 0.5}, position.Min)
+	assert.Equal(t, []float64{2, 2, 0.5}, position.Max)
+	texCoords := doc.Accessors[primitives[0].Attributes["TEXCOORD_0"]]
+	assert.Equal(t, []float64{0, 0}, texCoords.Min)
+	assert.Equal(t, []float64{1, 1}, texCoords.Max)
+
+	vertices := importers.NewVertexImporter()
+	require.NoError(t, vertices.ImportFromGLB(bytes.NewReader(files[0].Data), nil))
+	assert.Equal(t, corners(model.Mesh), corners(vertices.GetMesh()))
+
+	materials := importers.NewMaterialImporter()
+	require.NoError(t, materials.ImportFromGLB(bytes.NewReader(files[0].Data), nil))
+	reimported := materials.GetMaterials()
+	require.Len(t, reimported, 2)
+	for name, material := range model.Materials {
+		assert.Equal(t, material.ToPBR(), reimported[name].PBR, name)
+	}
+	assert.Equal(t, []string{"Painted", "Painted", "Steel"}, triangleMaterials(vertices))
+
+	// The groups of the import carry the materials through another export
+	files, err = exporters.Export("glb", "scene", exporters.NewModel(vertices, materials), exporters.ExportOptions{})
+	require.NoError(t, err)
+	again := importers.NewVertexImporter()
+	require.NoError(t, again.ImportFromGLB(bytes.NewReader(files[0].Data), nil))
+	assert.Equal(t, corners(model.Mesh), corners(again.GetMesh()))
+	assert.Equal(t, []string{"Painted", "Painted", "Steel"}, triangleMaterials(again))
+}
+
+func TestGLTFWritesSeparateBuffer(t *testing.T) {
+	model := sceneModel()
+
+	files, err := exporters.Export("gltf", "scene", model, exporters.ExportOptions{})
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "scene.gltf", files[0].Name)
+	assert.Equal(t, "scene.bin", files[1].Name)
+
+	var doc gltfJSON
+	require.NoError(t, json.Unmarshal(files[0].Data, &doc))
+	require.Len(t, doc.Buffers, 1)
+	assert.Equal(t, "scene.bin", doc.Buffers[0].URI)
+	assert.Equal(t, len(files[1].Data), doc.Buffers[0].ByteLength)
+	require.Len(t, doc.Images, 2)
+	assert.Equal(t, "textures/paint.png", doc.Images[0].URI)
+	assert.Equal(t, "steel_normal.png", doc.Images[1].URI)
+
+	resolver := func(uri string) ([]byte, error) {
+		if uri == "scene.bin" {
+			return files[1].Data, nil
+		}
+		return nil, fmt.Errorf("not found: %s", uri)
+	}
+	vertices := importers.NewVertexImporter()
+	require.NoError(t, vertices.ImportFromGLTF(bytes.NewReader(files[0].Data), resolver))
+	assert.Equal(t, corners(model.Mesh), corners(vertices.GetMesh()))
+}
+
+func TestGLBEmbedsTextures(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 2, 2))
+	img.SetGray(1, 0, color.Gray{Y: 200})
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+
+	model := sceneModel()
+	model.Materials["Steel"].PBR.MetallicTexture = "steel_metal.png"
+	model.Materials["Steel"].PBR.RoughnessTexture = "steel_rough.png"
+	resolver := func(uri string) ([]byte, error) { return buf.Bytes(), nil }
+
+	var glb bytes.Buffer
+	require.NoError(t, exporters.WriteGLB(&glb, model, exporters.GLTFOptions{Resolver: resolver}))
+	container, err := importers.ParseGLB(glb.Bytes())
+	require.NoError(t, err)
+
+	var doc gltfJSON
+	require.NoError(t, json.Unmarshal(container.JSON, &doc))
+	require.Len(t, doc.Images, 3)
+	for _, image := range doc.Images {
+		assert.Empty(t, image.URI)
+		require.NotNil(t, image.BufferView)
+		assert.Equal(t, "image/png", image.MimeType)
+	}
+	assert.Equal(t, "steel_normal.png", doc.Images[1].Name)
+
+	// Metallic and roughness are packed into the blue and green channels
+	view := doc.BufferViews[*doc.Images[2].BufferView]
+	packed, err := png.Decode(bytes.NewReader(container.BIN[view.ByteOffset : view.ByteOffset+view.ByteLength]))
+	require.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 255, G: 200, B: 200, A: 255}, color.NRGBAModel.Convert(packed.At(1, 0)))
+	assert.Equal(t, color.NRGBA{R: 255, G: 0, B: 0, A: 255}, color.NRGBAModel.Convert(packed.At(0, 0)))
+
+	materials := importers.NewMaterialImporter()
+	require.NoError(t, materials.ImportFromGLB(bytes.NewReader(glb.Bytes()), nil))
+	steel := materials.GetMaterials()["Steel"]
+	assert.Equal(t, "steel_normal.png", steel.PBR.NormalTexture)
+	assert.Equal(t, "image_2", steel.PBR.MetallicRoughnessTexture)
+}
+
+func TestGLBIndexTypesAndPoints(t *testing.T) {
+	// Index 65535 is reserved for primitive restart in 16-bit indices
+	vertices := make([]importers.Vertex, 65536)
+	for i := range vertices {
+		vertices[i].Position = [3]float64{float64(i), 0, 0}
+	}
+	model := &exporters.Model{Mesh: &importers.Mesh{
+		Vertices:  vertices,
+		Indices:   []uint32{0, 1, 65535},
+		Primitive: importers.PrimitiveTriangles,
+	}}
+
+	var glb bytes.Buffer
+	require.NoError(t, exporters.WriteGLB(&glb, model, exporters.GLTFOptions{}))
+	container, err := importers.ParseGLB(glb.Bytes())
+	require.NoError(t, err)
+	var doc gltfJSON
+	require.NoError(t, json.Unmarshal(container.JSON, &doc))
+	primitive := doc.Meshes[0].Primitives[0]
+	assert.Equal(t, 5125, doc.Accessors[*primitive.Indices].ComponentType)
+	assert.Nil(t, primitive.Material)
+	assert.NotContains(t, primitive.Attributes, "NORMAL")
+
+	model.Mesh.Indices = nil
+	model.Mesh.Primitive = importers.PrimitivePoints
+	glb.Reset()
+	require.NoError(t, exporters.WriteGLB(&glb, model, exporters.GLTFOptions{}))
+	container, err = importers.ParseGLB(glb.Bytes())
+	require.NoError(t, err)
+	doc = gltfJSON{}
+	require.NoError(t, json.Unmarshal(container.JSON, &doc))
+	primitive = doc.Meshes[0].Primitives[0]
+	assert.Equal(t, 0, primitive.Mode)
+	assert.Nil(t, primitive.Indices)
+}
@@ -0,0 +1,141 @@
+package exporters_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/3FT-io/3DS/pkg/exporters"
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+// sceneOBJ uses polygons, texture coordinates, normals, vertex colors,
+// objects, groups, materials and smoothing groups
+const sceneOBJ = `mtllib scene.mtl
+v 0 0 0 1 0 0
+v 1 0 0 0 1 0
+v 1 1 0 0 0 1
+v 0 1 0
+v 0.5 1.5 0
+v 0.1 0.2 0.30000000000000004
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+vn 0 0 1
+o Box
+usemtl Red
+s 1
+f 1/1/1 2/2/1 3/3/1 5/4/1 4/4/1
+o Marker
+g tip base
+usemtl Blue
+s off
+f 1/1/1 2/2/1 6/3/1
+`
+
+const sceneMTL = `newmtl Red
+Ka 0.1 0 0
+Kd 0.8 0.1 0.1
+Ks 0.5 0.5 0.5
+Ns 96
+Ke 0.2 0 0
+d 0.75
+map_Kd red.png
+map_Bump red_normal.png
+
+newmtl Blue
+Kd 0.1 0.1 0.8
+Pm 0.9
+Pr 0.3
+map_Pr blue_roughness.png
+`
+
+func importOBJ(t *testing.T, obj, mtl string) *exporters.Model {
+	vertices := importers.NewVertexImporter()
+	require.NoError(t, vertices.ImportFromOBJ(strings.NewReader(obj)))
+	materials := importers.NewMaterialImporter()
+	require.NoError(t, materials.ImportFromOBJ(strings.NewReader(mtl)))
+	return exporters.NewModel(vertices, materials)
+}
+
+func TestOBJRoundTrip(t *testing.T) {
+	model := importOBJ(t, sceneOBJ, sceneMTL)
+
+	files, err := exporters.Export("obj", "scene", model, exporters.ExportOptions{})
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "scene.obj", files[0].Name)
+	assert.Equal(t, "scene.mtl", files[1].Name)
+	assert.Contains(t, string(files[0].Data), "mtllib scene.mtl\n")
+
+	reimported := importOBJ(t, string(files[0].Data), string(files[1].Data))
+	assert.Equal(t, model.Mesh, reimported.Mesh)
+	assert.Equal(t, model.Groups, reimported.Groups)
+	assert.Equal(t, model.Materials, reimported.Materials)
+
+	// The pentagon is written as one face, not as its triangles
+	assert.Equal(t, 2, strings.Count(string(files[0].Data), "\nf "))
+	assert.True(t, reimported.Mesh.Vertices[0].HasColor)
+	assert.False(t, reimported.Mesh.Vertices[3].HasColor)
+}
+
+func TestOBJRoundTripResets(t *testing.T) {
+	// Faces after the first ones leave their group and material again
+	const obj = `mtllib scene.mtl
+v 0 0 0
+v 1 0 0
+v 0 1 0
+g tip
+usemtl Red
+f 1 2 3
+g default
+f 1 2 3
+usemtl
+f 1 2 3
+`
+	model := importOBJ(t, obj, sceneMTL)
+	require.Len(t, model.Groups, 3)
+	assert.Equal(t, "", model.Groups[1].Group)
+	assert.Equal(t, "", model.Groups[2].Material)
+
+	files, err := exporters.Export("obj", "my scene", model, exporters.ExportOptions{})
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "my_scene.mtl", files[1].Name)
+	assert.Contains(t, string(files[0].Data), "mtllib my_scene.mtl\n")
+
+	vertices := importers.NewVertexImporter()
+	require.NoError(t, vertices.ImportFromOBJ(bytes.NewReader(files[0].Data)))
+	assert.Equal(t, []string{"my_scene.mtl"}, vertices.GetMaterialLibraries())
+	reimported := importOBJ(t, string(files[0].Data), string(files[1].Data))
+	assert.Equal(t, model.Groups, reimported.Groups)
+}
+
+func TestWriteOBJPositionsOnly(t *testing.T) {
+	model := importOBJ(t, "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\n", "")
+
+	var buf bytes.Buffer
+	require.NoError(t, exporters.WriteOBJ(&buf, model, exporters.OBJOptions{}))
+	assert.Equal(t, "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\n", buf.String())
+
+	// Without materials no library is written
+	files, err := exporters.Export("obj", "triangle", model, exporters.ExportOptions{})
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestWriteOBJErrors(t *testing.T) {
+	model := &exporters.Model{Mesh: &importers.Mesh{Primitive: importers.PrimitivePoints}}
+	var buf bytes.Buffer
+	err := exporters.WriteOBJ(&buf, model, exporters.OBJOptions{})
+	var unsupported *exporters.UnsupportedFeatureError
+	require.ErrorAs(t, err, &unsupported)
+	assert.Equal(t, []string{"point clouds"}, unsupported.Features)
+
+	_, err = exporters.Export("blend", "model", model, exporters.ExportOptions{})
+	assert.ErrorIs(t, err, exporters.ErrUnsupportedFormat)
+}
@@ -0,0 +1,53 @@
+package exporters_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/3FT-io/3DS/pkg/exporters"
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+func TestPLYRoundTrip(t *testing.T) {
+	model := importOBJ(t, sceneOBJ, sceneMTL)
+
+	var buf bytes.Buffer
+	require.NoError(t, exporters.WritePLY(&buf, model))
+	assert.Contains(t, buf.String(), "format binary_little_endian 1.0\n")
+	assert.Contains(t, buf.String(), "element face 2\n") // the pentagon and a triangle
+
+	vertices := importers.NewVertexImporter()
+	require.NoError(t, vertices.ImportFromPLY(bytes.NewReader(buf.Bytes())))
+	reimported := vertices.GetMesh()
+	require.Len(t, reimported.Vertices, len(model.Mesh.Vertices))
+	require.Equal(t, model.Mesh.TriangleCount(), reimported.TriangleCount())
+	for i, vertex := range model.Mesh.Vertices {
+		other := reimported.Vertices[i]
+		assert.InDeltaSlice(t, vertex.Position[:], other.Position[:], 1e-6)
+		assert.Equal(t, vertex.Normal, other.Normal)
+		assert.Equal(t, vertex.TexCoords, other.TexCoords)
+		if vertex.HasColor {
+			assert.Equal(t, vertex.Color, other.Color)
+		} else {
+			assert.Equal(t, [4]float64{1, 1, 1, 1}, other.Color)
+		}
+	}
+
+	// Only the groups and materials are dropped
+	assert.Equal(t, []string{"groups", "materials"}, exporters.DroppedFeatures("ply", model))
+
+	// Point clouds are written without faces
+	points := &exporters.Model{Mesh: &importers.Mesh{
+		Vertices:  []importers.Vertex{{Position: [3]float64{1, 2, 3}}},
+		Primitive: importers.PrimitivePoints,
+	}}
+	buf.Reset()
+	require.NoError(t, exporters.WritePLY(&buf, points))
+	assert.NotContains(t, buf.String(), "element face")
+	vertices = importers.NewVertexImporter()
+	require.NoError(t, vertices.ImportFromPLY(bytes.NewReader(buf.Bytes())))
+	assert.Equal(t, importers.PrimitivePoints, vertices.GetMesh().Primitive)
+}
@@ -0,0 +1,71 @@
+package exporters_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/3FT-io/3DS/pkg/exporters"
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+func TestModelSimplifyKeepsGroups(t *testing.T) {
+	// An 8 by 8 grid whose left half is painted and right half is steel
+	const n = 8
+	mesh := &importers.Mesh{Primitive: importers.PrimitiveTriangles}
+	for j := 0; j <= n; j++ {
+		for i := 0; i <= n; i++ {
+			mesh.Vertices = append(mesh.Vertices, importers.Vertex{
+				Position: [3]float64{float64(i) / n, float64(j) / n, 0},
+				Normal:   [3]float64{0, 0, 1},
+			})
+		}
+	}
+	quads := func(from, to int) {
+		for j := 0; j < n; j++ {
+			for i := from; i < to; i++ {
+				a := uint32(j*(n+1) + i)
+				b, c, d := a+1, a+n+2, a+n+1
+				mesh.Indices = append(mesh.Indices, a, b, c, a, c, d)
+			}
+		}
+	}
+	quads(0, n/2)
+	quads(n/2, n)
+	half := len(mesh.Indices) / 2
+	model := &exporters.Model{
+		Mesh: mesh,
+		Groups: []importers.MeshGroup{
+			{Material: "Painted", Start: 0, Count: half, Faces: half / 3},
+			{Material: "Steel", Start: half, Count: half, Faces: half / 3},
+		},
+		Materials: sceneModel().Materials,
+	}
+
+	simplified, simplificationError := model.Simplify(4)
+	assert.InDelta(t, 0, simplificationError, 1e-6)
+	assert.Equal(t, 4, simplified.Mesh.TriangleCount())
+	assert.Equal(t, model.Materials, simplified.Materials)
+
+	require.Len(t, simplified.Groups, 2)
+	start := 0
+	for g, group := range simplified.Groups {
+		assert.Equal(t, model.Groups[g].Material, group.Material)
+		assert.Equal(t, start, group.Start)
+		assert.Equal(t, 3*group.Faces, group.Count)
+		start += group.Count
+
+		// Every triangle stays on its group's side of the border
+		for _, index := range simplified.Mesh.Indices[group.Start : group.Start+group.Count] {
+			x := simplified.Mesh.Vertices[index].Position[0]
+			if g == 0 {
+				assert.LessOrEqual(t, x, 0.5)
+			} else {
+				assert.GreaterOrEqual(t, x, 0.5)
+			}
+		}
+	}
+	assert.Equal(t, len(simplified.Mesh.Indices), start)
+	assert.Len(t, model.Mesh.Indices, 2*half)
+}
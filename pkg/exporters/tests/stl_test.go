@@ -0,0 +1,42 @@
+package exporters_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/3FT-io/3DS/pkg/exporters"
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+func TestSTLRoundTrip(t *testing.T) {
+	model := importOBJ(t, sceneOBJ, sceneMTL)
+
+	files, err := exporters.Export("stl", "scene", model, exporters.ExportOptions{})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "scene.stl", files[0].Name)
+	require.True(t, importers.IsBinarySTL(files[0].Data))
+
+	vertices := importers.NewVertexImporter()
+	require.NoError(t, vertices.ImportFromSTL(bytes.NewReader(files[0].Data)))
+	reimported := vertices.GetMesh()
+	require.Equal(t, model.Mesh.TriangleCount(), reimported.TriangleCount())
+	for i, index := range model.Mesh.Indices {
+		assert.InDeltaSlice(t, model.Mesh.Vertices[index].Position[:], reimported.Vertices[reimported.Indices[i]].Position[:], 1e-6)
+	}
+	// Facet normals are computed from the positions
+	assert.Equal(t, [3]float64{0, 0, 1}, reimported.Vertices[0].Normal)
+
+	// Everything but the triangle positions is reported as dropped
+	assert.Equal(t, []string{"groups", "materials", "polygons", "vertex normals", "texture coordinates", "vertex colors"},
+		exporters.DroppedFeatures("stl", model))
+	assert.Nil(t, exporters.DroppedFeatures("glb", model))
+
+	points := &exporters.Model{Mesh: &importers.Mesh{Primitive: importers.PrimitivePoints}}
+	var unsupported *exporters.UnsupportedFeatureError
+	_, err = exporters.Export("stl", "points", points, exporters.ExportOptions{})
+	assert.ErrorAs(t, err, &unsupported)
+}
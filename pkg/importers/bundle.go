@@ -0,0 +1,383 @@
+package importers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Bundle limits guard against zip bombs
+const (
+	bundleMaxFiles = 4096
+	// DefaultMaxBundleSize bounds the unpacked size of a bundle opened
+	// with OpenBundle
+	DefaultMaxBundleSize = 128 << 20
+)
+
+// ErrNoModelFile is returned when a bundle holds no file in a known model
+// format
+var ErrNoModelFile = errors.New("bundle contains no model file")
+
+// ErrBundleTooLarge is returned when a bundle unpacks to more than its
+// size limit
+var ErrBundleTooLarge = errors.New("bundle is too large")
+
+// modelFormatPriority orders the model formats when a bundle holds more
+// than one model file, preferring formats that reference the others
+var modelFormatPriority = []string{"gltf", "glb", "fbx", "dae", "obj", "3mf", "ply", "stl"}
+
+// Bundle is a set of files making up one model, such as an OBJ file with
+// its MTL libraries and textures. Paths are slash separated and relative
+// to the bundle root.
+type Bundle struct {
+	files map[string][]byte
+}
+
+// NewBundle creates a bundle from file contents keyed by path. Paths that
+// would escape the bundle root are rejected.
+func NewBundle(files map[string][]byte) (*Bundle, error) {
+	if len(files) > bundleMaxFiles {
+		return nil, fmt.Errorf("bundle has more than %d files", bundleMaxFiles)
+	}
+
+	b := &Bundle{files: make(map[string][]byte, len(files))}
+	for name, data := range files {
+		clean, err := cleanBundlePath(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := b.files[clean]; ok {
+			return nil, fmt.Errorf("bundle has duplicate file %q", clean)
+		}
+		b.files[clean] = data
+	}
+	return b, nil
+}
+
+// OpenBundle reads a bundle from a zip archive of at most
+// DefaultMaxBundleSize unpacked bytes. Directories are skipped.
+func OpenBundle(data []byte) (*Bundle, error) {
+	return OpenBundleLimit(data, DefaultMaxBundleSize)
+}
+
+// OpenBundleLimit is OpenBundle for archives of at most maxSize unpacked
+// bytes
+func OpenBundleLimit(data []byte, maxSize int64) (*Bundle, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle archive: %w", err)
+	}
+	if len(archive.File) > bundleMaxFiles {
+		return nil, fmt.Errorf("bundle has more than %d files", bundleMaxFiles)
+	}
+
+	files := make(map[string][]byte, len(archive.File))
+	var total int64
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open bundle file %q: %w", file.Name, err)
+		}
+		content, err := io.ReadAll(io.LimitReader(rc, maxSize-total+1))
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle file %q: %w", file.Name, err)
+		}
+		total += int64(len(content))
+		if total > maxSize {
+			return nil, ErrBundleTooLarge
+		}
+		files[file.Name] = content
+	}
+
+	return NewBundle(files)
+}
+
+// Paths returns the paths of all files in sorted order
+func (b *Bundle) Paths() []string {
+	paths := make([]string, 0, len(b.files))
+	for name := range b.files {
+		paths = append(paths, name)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// File returns the contents of the file at the given path
+func (b *Bundle) File(name string) ([]byte, bool) {
+	clean, err := cleanBundlePath(name)
+	if err != nil {
+		return nil, false
+	}
+	data, ok := b.files[clean]
+	return data, ok
+}
+
+// Zip encodes the bundle as a zip archive with files in path order
+func (b *Bundle) Zip() ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range b.Paths() {
+		w, err := zw.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(b.files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Resolver returns a resolver for resources referenced by the file at
+// base, resolving relative URIs against its directory
+func (b *Bundle) Resolver(base string) ResourceResolver {
+	return func(uri string) ([]byte, error) {
+		name, err := b.resolve(base, uri)
+		if err != nil {
+			return nil, err
+		}
+		return b.files[name], nil
+	}
+}
+
+// resolve returns the bundle path of a reference from the file at base.
+// glTF references are URIs while MTL ones are plain file names, so the
+// reference is tried both as is and unescaped.
+func (b *Bundle) resolve(base, uri string) (string, error) {
+	dir := path.Dir(base)
+	if name, err := cleanBundlePath(path.Join(dir, strings.ReplaceAll(uri, "\\", "/"))); err == nil {
+		if _, ok := b.files[name]; ok {
+			return name, nil
+		}
+	}
+
+	unescaped, err := cleanResourcePath(uri)
+	if err != nil {
+		return "", err
+	}
+	name, err := cleanBundlePath(path.Join(dir, unescaped))
+	if err != nil {
+		return "", err
+	}
+	if _, ok := b.files[name]; !ok {
+		return "", fmt.Errorf("bundle has no file %q", name)
+	}
+	return name, nil
+}
+
+// cleanBundlePath normalizes a file path within a bundle, rejecting paths
+// that would escape its root
+func cleanBundlePath(name string) (string, error) {
+	clean := path.Clean(strings.TrimPrefix(strings.ReplaceAll(name, "\\", "/"), "/"))
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("bundle path %q is outside the bundle", name)
+	}
+	return clean, nil
+}
+
+// FormatFromPath returns the model format named by a file extension, or
+// "" for other files
+func FormatFromPath(name string) string {
+	format := strings.ToLower(strings.TrimPrefix(path.Ext(name), "."))
+	if slices.Contains(modelFormatPriority, format) {
+		return format
+	}
+	return ""
+}
+
+// MainFile returns the path and format of the model file in the bundle.
+// If there are several, the format first in modelFormatPriority wins,
+// then the shallowest and alphabetically first path.
+func (b *Bundle) MainFile() (string, string, error) {
+	best, bestFormat := "", ""
+	bestRank := len(modelFormatPriority)
+	for _, name := range b.Paths() {
+		format := FormatFromPath(name)
+		if format == "" {
+			continue
+		}
+		rank := slices.Index(modelFormatPriority, format)
+		if rank < bestRank || (rank == bestRank && strings.Count(name, "/") < strings.Count(best, "/")) {
+			best, bestFormat, bestRank = name, format, rank
+		}
+	}
+	if best == "" {
+		return "", "", ErrNoModelFile
+	}
+	return best, bestFormat, nil
+}
+
+// BundleImport is the result of importing the model file of a bundle
+type BundleImport struct {
+	Vertices  *VertexImporter
+	Materials *MaterialImporter
+	// Missing lists the sorted references to files the bundle lacks, such
+	// as material libraries, buffers and textures
+	Missing []string
+	// Textures maps the bundle path of each texture found to the sorted
+	// names of the materials using it
+	Textures map[string][]string
+}
+
+// Import imports the model file at main, resolving external resources and
+// OBJ material libraries within the bundle. On failure the result still
+// lists the missing files found so far.
+func (b *Bundle) Import(main string, opts ImportOptions) (*BundleImport, error) {
+	result := &BundleImport{Missing: []string{}, Textures: make(map[string][]string)}
+	data, ok := b.File(main)
+	if !ok {
+		return result, fmt.Errorf("bundle has no file %q", main)
+	}
+	format := FormatFromPath(main)
+
+	missing := make(map[string]bool)
+	check := func(base, uri string) {
+		if uri == "" || strings.HasPrefix(uri, "data:") {
+			return
+		}
+		if _, err := b.resolve(base, uri); err != nil {
+			missing[uri] = true
+		}
+	}
+	finish := func(err error) (*BundleImport, error) {
+		for uri := range missing {
+			result.Missing = append(result.Missing, uri)
+		}
+		sort.Strings(result.Missing)
+		for _, materials := range result.Textures {
+			sort.Strings(materials)
+		}
+		return result, err
+	}
+
+	// link records the textures of a material found in the bundle. Other
+	// references are reported missing unless they may name embedded
+	// images, as glTF ones do.
+	link := func(base string, material *Material, reportMissing bool) {
+		for _, texture := range materialTextures(material) {
+			if texture == "" || strings.HasPrefix(texture, "data:") {
+				continue
+			}
+			name, err := b.resolve(base, texture)
+			if err != nil {
+				if reportMissing {
+					missing[texture] = true
+				}
+				continue
+			}
+			if !slices.Contains(result.Textures[name], material.Name) {
+				result.Textures[name] = append(result.Textures[name], material.Name)
+			}
+		}
+	}
+
+	// glTF buffers must be checked before importing, which fails on them
+	gltf := format == "gltf" || format == "glb"
+	if gltf {
+		for _, uri := range gltfExternalURIs(data, format) {
+			check(main, uri)
+		}
+	}
+
+	opts.Resolver = b.Resolver(main)
+	vertices := NewVertexImporter()
+	if err := vertices.Import(format, bytes.NewReader(data), opts); err != nil {
+		return finish(err)
+	}
+
+	materials := NewMaterialImporter()
+	if format == "obj" {
+		for _, library := range vertices.GetMaterialLibraries() {
+			name, err := b.resolve(main, library)
+			if err != nil {
+				missing[library] = true
+				continue
+			}
+			libraryMaterials := NewMaterialImporter()
+			if err := libraryMaterials.ImportFromOBJ(bytes.NewReader(b.files[name])); err != nil {
+				return finish(fmt.Errorf("material library %q: %w", library, err))
+			}
+			for _, material := range libraryMaterials.GetMaterials() {
+				link(name, material, true)
+				materials.materials[material.Name] = material
+			}
+		}
+	} else {
+		if err := materials.Import(format, bytes.NewReader(data), opts.Resolver); err != nil {
+			return finish(err)
+		}
+		for _, material := range materials.GetMaterials() {
+			link(main, material, !gltf)
+		}
+	}
+
+	result.Vertices = vertices
+	result.Materials = materials
+	return finish(nil)
+}
+
+// materialTextures lists the texture references of a material. MTL map
+// options preceding the file name are stripped.
+func materialTextures(m *Material) []string {
+	textures := []string{m.DiffuseMap, m.NormalMap, m.SpecularMap, m.EmissiveMap}
+	if m.PBR != nil {
+		textures = append(textures, m.PBR.MetallicTexture, m.PBR.RoughnessTexture)
+	}
+	for i, texture := range textures {
+		textures[i] = mtlMapFile(texture)
+	}
+	return textures
+}
+
+// mtlMapFile strips the options of an MTL map statement such as
+// "-bm 0.5 normal.png", which always precede the file name
+func mtlMapFile(value string) string {
+	fields := strings.Fields(value)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "-") {
+		return value
+	}
+	return fields[len(fields)-1]
+}
+
+// gltfExternalURIs returns the buffer and image URIs of a glTF or GLB file
+func gltfExternalURIs(data []byte, format string) []string {
+	if format == "glb" {
+		container, err := ParseGLB(data)
+		if err != nil {
+			return nil
+		}
+		data = container.JSON
+	}
+
+	var doc struct {
+		Buffers []gltfBuffer `json:"buffers"`
+		Images  []gltfImage  `json:"images"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil
+	}
+
+	var uris []string
+	for _, buffer := range doc.Buffers {
+		uris = append(uris, buffer.URI)
+	}
+	for _, image := range doc.Images {
+		uris = append(uris, image.URI)
+	}
+	return uris
+}
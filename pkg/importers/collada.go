@@ -0,0 +1,916 @@
+package importers
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const colladaMaxDepth = 64
+
+// colladaDocument holds the COLLADA libraries used by the importers
+type colladaDocument struct {
+	XMLName      xml.Name             `xml:"COLLADA"`
+	Images       []colladaImage       `xml:"library_images>image"`
+	Effects      []colladaEffect      `xml:"library_effects>effect"`
+	Materials    []colladaMaterial    `xml:"library_materials>material"`
+	Geometries   []colladaGeometry    `xml:"library_geometries>geometry"`
+	Nodes        []colladaNode        `xml:"library_nodes>node"`
+	VisualScenes []colladaVisualScene `xml:"library_visual_scenes>visual_scene"`
+	Scene        struct {
+		VisualScene *colladaInstance `xml:"instance_visual_scene"`
+	} `xml:"scene"`
+}
+
+type colladaInstance struct {
+	URL string `xml:"url,attr"`
+	// Materials binds the material symbols of an instance_geometry's
+	// primitives to materials
+	Materials []struct {
+		Symbol string `xml:"symbol,attr"`
+		Target string `xml:"target,attr"`
+	} `xml:"bind_material>technique_common>instance_material"`
+}
+
+type colladaImage struct {
+	ID       string `xml:"id,attr"`
+	InitFrom struct {
+		Value string `xml:",chardata"`
+		Ref   string `xml:"ref"` // COLLADA 1.5
+	} `xml:"init_from"`
+}
+
+type colladaEffect struct {
+	ID      string `xml:"id,attr"`
+	Profile struct {
+		NewParams []colladaNewParam `xml:"newparam"`
+		Technique struct {
+			NewParams []colladaNewParam `xml:"newparam"`
+			Shaders   []colladaShader   `xml:",any"`
+			Extra     []struct {
+				Techniques []struct {
+					Bump *colladaColorOrTexture `xml:"bump"`
+				} `xml:"technique"`
+			} `xml:"extra"`
+		} `xml:"technique"`
+	} `xml:"profile_COMMON"`
+}
+
+type colladaNewParam struct {
+	SID     string `xml:"sid,attr"`
+	Surface *struct {
+		InitFrom string `xml:"init_from"`
+	} `xml:"surface"`
+	Sampler *struct {
+		Source        string           `xml:"source"`
+		InstanceImage *colladaInstance `xml:"instance_image"` // COLLADA 1.5
+	} `xml:"sampler2D"`
+}
+
+// colladaShader is a phong, blinn, lambert or constant shading model
+type colladaShader struct {
+	XMLName      xml.Name
+	Ambient      *colladaColorOrTexture `xml:"ambient"`
+	Diffuse      *colladaColorOrTexture `xml:"diffuse"`
+	Specular     *colladaColorOrTexture `xml:"specular"`
+	Shininess    *colladaFloat          `xml:"shininess"`
+	Transparent  *colladaColorOrTexture `xml:"transparent"`
+	Transparency *colladaFloat          `xml:"transparency"`
+}
+
+type colladaColorOrTexture struct {
+	Opaque  string `xml:"opaque,attr"`
+	Color   string `xml:"color"`
+	Texture *struct {
+		Texture string `xml:"texture,attr"`
+	} `xml:"texture"`
+}
+
+type colladaFloat struct {
+	Float float64 `xml:"float"`
+}
+
+type colladaMaterial struct {
+	ID             string          `xml:"id,attr"`
+	Name           string          `xml:"name,attr"`
+	InstanceEffect colladaInstance `xml:"instance_effect"`
+}
+
+type colladaGeometry struct {
+	ID   string       `xml:"id,attr"`
+	Name string       `xml:"name,attr"`
+	Mesh *colladaMesh `xml:"mesh"`
+}
+
+type colladaMesh struct {
+	Sources  []colladaSource `xml:"source"`
+	Vertices struct {
+		ID     string         `xml:"id,attr"`
+		Inputs []colladaInput `xml:"input"`
+	} `xml:"vertices"`
+	Triangles []colladaPrimitive `xml:"triangles"`
+	Polylists []colladaPrimitive `xml:"polylist"`
+	Polygons  []colladaPrimitive `xml:"polygons"`
+}
+
+type colladaSource struct {
+	ID         string `xml:"id,attr"`
+	FloatArray struct {
+		Values string `xml:",chardata"`
+	} `xml:"float_array"`
+	Accessor struct {
+		Count  int `xml:"count,attr"`
+		Offset int `xml:"offset,attr"`
+		Stride int `xml:"stride,attr"`
+	} `xml:"technique_common>accessor"`
+}
+
+type colladaInput struct {
+	Semantic string `xml:"semantic,attr"`
+	Source   string `xml:"source,attr"`
+	Offset   int    `xml:"offset,attr"`
+}
+
+type colladaPrimitive struct {
+	Material string         `xml:"material,attr"`
+	Count    int            `xml:"count,attr"`
+	Inputs   []colladaInput `xml:"input"`
+	VCount   string         `xml:"vcount"`
+	P        []string       `xml:"p"`
+}
+
+type colladaVisualScene struct {
+	ID    string        `xml:"id,attr"`
+	Nodes []colladaNode `xml:"node"`
+}
+
+type colladaNode struct {
+	ID                 string             `xml:"id,attr"`
+	Nodes              []colladaNode      `xml:"node"`
+	InstanceGeometries []colladaInstance  `xml:"instance_geometry"`
+	InstanceNodes      []colladaInstance  `xml:"instance_node"`
+	Transforms         []colladaTransform `xml:",any"`
+}
+
+// colladaTransform is a matrix, translate, rotate or scale element; other
+// unknown node children are collected too and ignored
+type colladaTransform struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+// colladaLoader resolves references within a COLLADA document
+type colladaLoader struct {
+	doc     *colladaDocument
+	sources map[string][]float64
+	names   []string // cached by materialNames
+}
+
+func newColladaLoader(data []byte) (*colladaLoader, error) {
+	doc := &colladaDocument{}
+	if err := xml.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("invalid COLLADA document: %w", err)
+	}
+	return &colladaLoader{doc: doc, sources: make(map[string][]float64)}, nil
+}
+
+// colladaID strips the fragment marker from a "#id" URL
+func colladaID(url string) string {
+	return strings.TrimPrefix(strings.TrimSpace(url), "#")
+}
+
+// parseColladaFloats parses a whitespace-separated list of numbers
+func parseColladaFloats(text string) ([]float64, error) {
+	fields := strings.Fields(text)
+	values := make([]float64, len(fields))
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", field)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// parseColladaInts parses a whitespace-separated list of indices
+func parseColladaInts(text string) ([]int, error) {
+	fields := strings.Fields(text)
+	values := make([]int, len(fields))
+	for i, field := range fields {
+		v, err := strconv.Atoi(field)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("invalid index %q", field)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// colladaSourceData is a parsed source with its accessor layout
+type colladaSourceData struct {
+	values []float64
+	offset int
+	stride int
+	count  int
+}
+
+// element returns up to n components of element i
+func (s *colladaSourceData) element(i, n int) ([]float64, error) {
+	if i < 0 || i >= s.count {
+		return nil, fmt.Errorf("index %d out of range", i)
+	}
+	start := s.offset + i*s.stride
+	end := start + min(n, s.stride)
+	if end > len(s.values) {
+		return nil, fmt.Errorf("index %d exceeds source data", i)
+	}
+	return s.values[start:end], nil
+}
+
+func (l *colladaLoader) source(mesh *colladaMesh, url string) (*colladaSourceData, error) {
+	id := colladaID(url)
+	for i := range mesh.Sources {
+		src := &mesh.Sources[i]
+		if src.ID != id {
+			continue
+		}
+
+		values, ok := l.sources[id]
+		if !ok {
+			var err error
+			if values, err = parseColladaFloats(src.FloatArray.Values); err != nil {
+				return nil, fmt.Errorf("source %q: %w", id, err)
+			}
+			l.sources[id] = values
+		}
+
+		if src.Accessor.Offset < 0 {
+			return nil, fmt.Errorf("source %q has a negative offset", id)
+		}
+		stride := src.Accessor.Stride
+		if stride <= 0 {
+			stride = 1
+		}
+		count := src.Accessor.Count
+		if count <= 0 {
+			count = len(values) / stride
+		}
+		return &colladaSourceData{values: values, offset: src.Accessor.Offset, stride: stride, count: count}, nil
+	}
+	return nil, fmt.Errorf("source %q not found", id)
+}
+
+// colladaSemantic binds a vertex attribute to a source at an index offset
+type colladaSemantic struct {
+	semantic string
+	offset   int
+	source   *colladaSourceData
+}
+
+// primitiveInputs expands the VERTEX input into the vertices element's
+// inputs and keeps the first texture coordinate and color set
+func (l *colladaLoader) primitiveInputs(mesh *colladaMesh, inputs []colladaInput) ([]colladaSemantic, int, error) {
+	var result []colladaSemantic
+	seen := make(map[string]bool)
+	stride := 0
+
+	add := func(semantic string, offset int, url string) error {
+		stride = max(stride, offset+1)
+		if seen[semantic] {
+			return nil
+		}
+		switch semantic {
+		case "POSITION", "NORMAL", "TEXCOORD", "COLOR":
+		default:
+			return nil
+		}
+		src, err := l.source(mesh, url)
+		if err != nil {
+			return err
+		}
+		seen[semantic] = true
+		result = append(result, colladaSemantic{semantic: semantic, offset: offset, source: src})
+		return nil
+	}
+
+	for _, input := range inputs {
+		if input.Offset < 0 {
+			return nil, 0, fmt.Errorf("input %s has negative offset", input.Semantic)
+		}
+		if input.Semantic != "VERTEX" {
+			if err := add(input.Semantic, input.Offset, input.Source); err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+
+		if colladaID(input.Source) != mesh.Vertices.ID {
+			return nil, 0, fmt.Errorf("vertices %q not found", input.Source)
+		}
+		for _, shared := range mesh.Vertices.Inputs {
+			if err := add(shared.Semantic, input.Offset, shared.Source); err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+
+	if !seen["POSITION"] {
+		return nil, 0, errors.New("primitive has no POSITION input")
+	}
+	return result, stride, nil
+}
+
+// primitivePolygons returns a primitive's index tuples, stride indices
+// per corner, and the number of corners of each polygon
+func primitivePolygons(kind string, prim colladaPrimitive, stride int) ([]int, []int, error) {
+	var indices []int
+	var sizes []int
+
+	switch kind {
+	case "triangles", "polylist":
+		if len(prim.P) > 1 {
+			return nil, nil, fmt.Errorf("%s has %d <p> elements", kind, len(prim.P))
+		}
+		if len(prim.P) == 1 {
+			var err error
+			if indices, err = parseColladaInts(prim.P[0]); err != nil {
+				return nil, nil, err
+			}
+		}
+		if kind == "triangles" {
+			// The triangles are counted from the indices, as count is
+			// only as trustworthy as the rest of the file
+			if stride == 0 || len(indices)%(3*stride) != 0 {
+				return nil, nil, fmt.Errorf("triangles have %d indices, which is not a multiple of %d", len(indices), 3*stride)
+			}
+			triangles := len(indices) / (3 * stride)
+			if triangles != prim.Count {
+				return nil, nil, fmt.Errorf("triangles declares %d triangles but has %d", prim.Count, triangles)
+			}
+			sizes = make([]int, triangles)
+			for i := range sizes {
+				sizes[i] = 3
+			}
+		} else {
+			counts, err := parseColladaInts(prim.VCount)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid vcount: %w", err)
+			}
+			sizes = counts
+		}
+
+	case "polygons":
+		for _, p := range prim.P {
+			polygon, err := parseColladaInts(p)
+			if err != nil {
+				return nil, nil, err
+			}
+			indices = append(indices, polygon...)
+			sizes = append(sizes, len(polygon)/stride)
+		}
+	}
+
+	total := 0
+	for _, size := range sizes {
+		if size < 0 || size > len(indices) {
+			return nil, nil, fmt.Errorf("%s has a polygon of %d corners", kind, size)
+		}
+		total += size
+	}
+	if total*stride != len(indices) {
+		return nil, nil, fmt.Errorf("%s declares %d corners but has %d indices", kind, total, len(indices))
+	}
+	return indices, sizes, nil
+}
+
+// loadGeometry instantiates the visual scene's geometries. Documents
+// without a visual scene import every geometry untransformed.
+func (l *colladaLoader) loadGeometry() (*vertexWelder, error) {
+	welder := newVertexWelder()
+
+	scene, err := l.visualScene()
+	if err != nil {
+		return nil, err
+	}
+
+	if scene == nil {
+		for i := range l.doc.Geometries {
+			if err := l.addGeometry(welder, &l.doc.Geometries[i], colladaInstance{}, IdentityMatrix()); err != nil {
+				return nil, err
+			}
+		}
+		return welder, nil
+	}
+
+	visiting := make(map[*colladaNode]bool)
+	for i := range scene.Nodes {
+		if err := l.addNode(welder, &scene.Nodes[i], IdentityMatrix(), visiting, 0); err != nil {
+			return nil, err
+		}
+	}
+	return welder, nil
+}
+
+// visualScene returns the instantiated scene, the first one, or nil
+func (l *colladaLoader) visualScene() (*colladaVisualScene, error) {
+	if instance := l.doc.Scene.VisualScene; instance != nil {
+		id := colladaID(instance.URL)
+		for i := range l.doc.VisualScenes {
+			if l.doc.VisualScenes[i].ID == id {
+				return &l.doc.VisualScenes[i], nil
+			}
+		}
+		return nil, fmt.Errorf("visual scene %q not found", instance.URL)
+	}
+	if len(l.doc.VisualScenes) > 0 {
+		return &l.doc.VisualScenes[0], nil
+	}
+	return nil, nil
+}
+
+func (l *colladaLoader) addNode(welder *vertexWelder, node *colladaNode, parent Matrix4, visiting map[*colladaNode]bool, depth int) error {
+	if visiting[node] || depth > colladaMaxDepth {
+		return fmt.Errorf("node %q is part of a cycle", node.ID)
+	}
+	visiting[node] = true
+	defer delete(visiting, node)
+	if err := welder.instance(); err != nil {
+		return err
+	}
+
+	local, err := node.localMatrix()
+	if err != nil {
+		return fmt.Errorf("node %q: %w", node.ID, err)
+	}
+	world := parent.Mul(local)
+
+	for _, instance := range node.InstanceGeometries {
+		geometry := l.geometry(colladaID(instance.URL))
+		if geometry == nil {
+			return fmt.Errorf("geometry %q not found", instance.URL)
+		}
+		if err := welder.instance(); err != nil {
+			return err
+		}
+		if err := l.addGeometry(welder, geometry, instance, world); err != nil {
+			return err
+		}
+	}
+
+	for i := range node.Nodes {
+		if err := l.addNode(welder, &node.Nodes[i], world, visiting, depth+1); err != nil {
+			return err
+		}
+	}
+
+	for _, instance := range node.InstanceNodes {
+		child := l.libraryNode(colladaID(instance.URL))
+		if child == nil {
+			return fmt.Errorf("node %q not found", instance.URL)
+		}
+		if err := l.addNode(welder, child, world, visiting, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *colladaLoader) geometry(id string) *colladaGeometry {
+	for i := range l.doc.Geometries {
+		if l.doc.Geometries[i].ID == id {
+			return &l.doc.Geometries[i]
+		}
+	}
+	return nil
+}
+
+// libraryNode finds a node by id in library_nodes, including nested nodes
+func (l *colladaLoader) libraryNode(id string) *colladaNode {
+	var find func(nodes []colladaNode) *colladaNode
+	find = func(nodes []colladaNode) *colladaNode {
+		for i := range nodes {
+			if nodes[i].ID == id {
+				return &nodes[i]
+			}
+			if found := find(nodes[i].Nodes); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	return find(l.doc.Nodes)
+}
+
+// localMatrix composes the node's transform elements in document order
+func (n *colladaNode) localMatrix() (Matrix4, error) {
+	m := IdentityMatrix()
+	for _, transform := range n.Transforms {
+		var want int
+		switch transform.XMLName.Local {
+		case "matrix":
+			want = 16
+		case "translate", "scale":
+			want = 3
+		case "rotate":
+			want = 4
+		default:
+			continue
+		}
+
+		values, err := parseColladaFloats(transform.Value)
+		if err != nil || len(values) != want {
+			return Matrix4{}, fmt.Errorf("invalid %s", transform.XMLName.Local)
+		}
+
+		var t Matrix4
+		switch transform.XMLName.Local {
+		case "matrix":
+			// COLLADA matrices are written row by row
+			for row := 0; row < 4; row++ {
+				for col := 0; col < 4; col++ {
+					t[col*4+row] = values[row*4+col]
+				}
+			}
+		case "translate":
+			t = ComposeMatrix([3]float64{values[0], values[1], values[2]}, [4]float64{0, 0, 0, 1}, [3]float64{1, 1, 1})
+		case "scale":
+			t = ComposeMatrix([3]float64{}, [4]float64{0, 0, 0, 1}, [3]float64{values[0], values[1], values[2]})
+		case "rotate":
+			axis := normalize3([3]float64{values[0], values[1], values[2]})
+			half := values[3] * math.Pi / 360
+			s := math.Sin(half)
+			t = ComposeMatrix([3]float64{}, [4]float64{axis[0] * s, axis[1] * s, axis[2] * s, math.Cos(half)}, [3]float64{1, 1, 1})
+		}
+		m = m.Mul(t)
+	}
+	return m, nil
+}
+
+// addGeometry transforms and welds the polygons of a mesh geometry, with a
+// group for every primitive element using the material bound by instance
+func (l *colladaLoader) addGeometry(welder *vertexWelder, geometry *colladaGeometry, instance colladaInstance, world Matrix4) error {
+	mesh := geometry.Mesh
+	if mesh == nil {
+		// Splines and other geometry types carry no polygons
+		return nil
+	}
+
+	flip := world.determinant3() < 0
+	groups := []struct {
+		kind       string
+		primitives []colladaPrimitive
+	}{
+		{"triangles", mesh.Triangles},
+		{"polylist", mesh.Polylists},
+		{"polygons", mesh.Polygons},
+	}
+
+	name := geometry.Name
+	if name == "" {
+		name = geometry.ID
+	}
+	for _, group := range groups {
+		for _, prim := range group.primitives {
+			meshGroup := MeshGroup{Object: name, Material: l.boundMaterial(instance, prim.Material), Start: len(welder.indices)}
+			faces, err := l.addPrimitive(welder, mesh, group.kind, prim, world, flip)
+			if err != nil {
+				return fmt.Errorf("geometry %q %s: %w", geometry.ID, group.kind, err)
+			}
+			meshGroup.Faces = faces
+			welder.addGroup(meshGroup)
+		}
+	}
+	return nil
+}
+
+// boundMaterial returns the importer name of the material an instance
+// binds to a primitive's material symbol. Unbound symbols are taken as
+// material ids, as written by many exporters.
+func (l *colladaLoader) boundMaterial(instance colladaInstance, symbol string) string {
+	if symbol == "" {
+		return ""
+	}
+	id := symbol
+	for _, binding := range instance.Materials {
+		if binding.Symbol == symbol {
+			id = colladaID(binding.Target)
+			break
+		}
+	}
+	for i, m := range l.doc.Materials {
+		if m.ID == id {
+			return l.materialNames()[i]
+		}
+	}
+	return ""
+}
+
+// addPrimitive welds a primitive element's polygons and returns their
+// number
+func (l *colladaLoader) addPrimitive(welder *vertexWelder, mesh *colladaMesh, kind string, prim colladaPrimitive, world Matrix4, flip bool) (int, error) {
+	inputs, stride, err := l.primitiveInputs(mesh, prim.Inputs)
+	if err != nil {
+		return 0, err
+	}
+
+	indices, sizes, err := primitivePolygons(kind, prim, stride)
+	if err != nil {
+		return 0, err
+	}
+
+	corner := 0
+	for _, size := range sizes {
+		polygon := make([]uint32, size)
+		for k := 0; k < size; k++ {
+			tuple := indices[(corner+k)*stride : (corner+k+1)*stride]
+			vertex, err := colladaVertex(inputs, tuple, world)
+			if err != nil {
+				return 0, err
+			}
+			polygon[k] = welder.add(vertex)
+		}
+		corner += size
+
+		for i := 1; i+1 < len(polygon); i++ {
+			if flip {
+				welder.indices = append(welder.indices, polygon[0], polygon[i+1], polygon[i])
+			} else {
+				welder.indices = append(welder.indices, polygon[0], polygon[i], polygon[i+1])
+			}
+		}
+	}
+	return len(sizes), nil
+}
+
+// colladaVertex assembles a transformed vertex from one index tuple
+func colladaVertex(inputs []colladaSemantic, tuple []int, world Matrix4) (Vertex, error) {
+	var vertex Vertex
+	for _, input := range inputs {
+		switch input.semantic {
+		case "POSITION":
+			p, err := input.source.element(tuple[input.offset], 3)
+			if err != nil || len(p) < 3 {
+				return Vertex{}, fmt.Errorf("POSITION: invalid element %d", tuple[input.offset])
+			}
+			vertex.Position = world.TransformPoint([3]float64{p[0], p[1], p[2]})
+		case "NORMAL":
+			n, err := input.source.element(tuple[input.offset], 3)
+			if err != nil || len(n) < 3 {
+				return Vertex{}, fmt.Errorf("NORMAL: invalid element %d", tuple[input.offset])
+			}
+			vertex.Normal = world.TransformNormal([3]float64{n[0], n[1], n[2]})
+		case "TEXCOORD":
+			uv, err := input.source.element(tuple[input.offset], 2)
+			if err != nil || len(uv) < 2 {
+				return Vertex{}, fmt.Errorf("TEXCOORD: invalid element %d", tuple[input.offset])
+			}
+			vertex.TexCoords = [2]float64{uv[0], uv[1]}
+		case "COLOR":
+			c, err := input.source.element(tuple[input.offset], 4)
+			if err != nil || len(c) < 3 {
+				return Vertex{}, fmt.Errorf("COLOR: invalid element %d", tuple[input.offset])
+			}
+			vertex.Color = [4]float64{c[0], c[1], c[2], 1}
+			if len(c) == 4 {
+				vertex.Color[3] = c[3]
+			}
+			vertex.HasColor = true
+		}
+	}
+	return vertex, nil
+}
+
+// loadMaterials maps COLLADA materials and their common-profile effects
+// onto importer materials
+func (l *colladaLoader) loadMaterials() ([]*Material, error) {
+	materials := make([]*Material, 0, len(l.doc.Materials))
+	names := l.materialNames()
+
+	for i, m := range l.doc.Materials {
+		name := names[i]
+		material := &Material{Name: name, Transparency: 1.0}
+		effect := l.effect(colladaID(m.InstanceEffect.URL))
+		if effect == nil {
+			return nil, fmt.Errorf("effect %q not found", m.InstanceEffect.URL)
+		}
+		if err := l.applyEffect(material, effect); err != nil {
+			return nil, fmt.Errorf("material %q: %w", name, err)
+		}
+		materials = append(materials, material)
+	}
+	return materials, nil
+}
+
+// materialNames returns the importer name of every material. Names are
+// optional; ids are unique.
+func (l *colladaLoader) materialNames() []string {
+	if l.names != nil {
+		return l.names
+	}
+	l.names = make([]string, len(l.doc.Materials))
+	used := make(map[string]bool)
+	for i, m := range l.doc.Materials {
+		name := m.Name
+		if name == "" || used[name] {
+			name = m.ID
+		}
+		if name == "" || used[name] {
+			name = fmt.Sprintf("material_%d", i)
+		}
+		used[name] = true
+		l.names[i] = name
+	}
+	return l.names
+}
+
+func (l *colladaLoader) effect(id string) *colladaEffect {
+	for i := range l.doc.Effects {
+		if l.doc.Effects[i].ID == id {
+			return &l.doc.Effects[i]
+		}
+	}
+	return nil
+}
+
+func (l *colladaLoader) applyEffect(material *Material, effect *colladaEffect) error {
+	technique := &effect.Profile.Technique
+	params := append(append([]colladaNewParam(nil), effect.Profile.NewParams...), technique.NewParams...)
+
+	var shader *colladaShader
+	for i := range technique.Shaders {
+		switch technique.Shaders[i].XMLName.Local {
+		case "phong", "blinn", "lambert", "constant":
+			shader = &technique.Shaders[i]
+		}
+	}
+	if shader == nil {
+		return nil
+	}
+
+	color := func(value *colladaColorOrTexture, target *[3]float64) error {
+		if value == nil || value.Color == "" {
+			return nil
+		}
+		c, err := parseColladaFloats(value.Color)
+		if err != nil || len(c) < 3 {
+			return fmt.Errorf("invalid color %q", value.Color)
+		}
+		*target = [3]float64{c[0], c[1], c[2]}
+		return nil
+	}
+	if err := color(shader.Ambient, &material.AmbientColor); err != nil {
+		return err
+	}
+	if err := color(shader.Diffuse, &material.DiffuseColor); err != nil {
+		return err
+	}
+	if err := color(shader.Specular, &material.SpecularColor); err != nil {
+		return err
+	}
+	if shader.Shininess != nil {
+		material.Shininess = shader.Shininess.Float
+	}
+
+	if shader.Diffuse != nil && shader.Diffuse.Texture != nil {
+		material.DiffuseMap = l.texturePath(params, shader.Diffuse.Texture.Texture)
+	}
+	if shader.Specular != nil && shader.Specular.Texture != nil {
+		material.SpecularMap = l.texturePath(params, shader.Specular.Texture.Texture)
+	}
+	for _, extra := range technique.Extra {
+		for _, t := range extra.Techniques {
+			if t.Bump != nil && t.Bump.Texture != nil {
+				material.NormalMap = l.texturePath(params, t.Bump.Texture.Texture)
+			}
+		}
+	}
+
+	opacity, err := colladaOpacity(shader)
+	if err != nil {
+		return err
+	}
+	material.Transparency = opacity
+	return nil
+}
+
+// colladaOpacity computes opacity from the transparent color and the
+// transparency factor according to the opaque mode
+func colladaOpacity(shader *colladaShader) (float64, error) {
+	factor := 1.0
+	if shader.Transparency != nil {
+		factor = shader.Transparency.Float
+	}
+
+	transparent := []float64{1, 1, 1, 1}
+	mode := "A_ONE"
+	if shader.Transparent != nil {
+		if shader.Transparent.Opaque != "" {
+			mode = shader.Transparent.Opaque
+		}
+		if shader.Transparent.Color != "" {
+			c, err := parseColladaFloats(shader.Transparent.Color)
+			if err != nil || len(c) < 4 {
+				return 0, fmt.Errorf("invalid transparent color %q", shader.Transparent.Color)
+			}
+			transparent = c
+		}
+	} else if shader.Transparency == nil {
+		return 1, nil
+	}
+
+	switch mode {
+	case "RGB_ZERO":
+		luminance := 0.212671*transparent[0] + 0.715160*transparent[1] + 0.072169*transparent[2]
+		return 1 - factor*luminance, nil
+	case "A_ZERO":
+		return 1 - factor*transparent[3], nil
+	case "RGB_ONE":
+		luminance := 0.212671*transparent[0] + 0.715160*transparent[1] + 0.072169*transparent[2]
+		return factor * luminance, nil
+	default:
+		return factor * transparent[3], nil
+	}
+}
+
+// texturePath resolves a texture reference through sampler and surface
+// parameters to an image file. Exporters also reference images directly.
+func (l *colladaLoader) texturePath(params []colladaNewParam, ref string) string {
+	param := func(sid string) *colladaNewParam {
+		for i := range params {
+			if params[i].SID == sid {
+				return &params[i]
+			}
+		}
+		return nil
+	}
+
+	imageID := ref
+	if sampler := param(ref); sampler != nil && sampler.Sampler != nil {
+		if sampler.Sampler.InstanceImage != nil {
+			imageID = colladaID(sampler.Sampler.InstanceImage.URL)
+		} else if surface := param(strings.TrimSpace(sampler.Sampler.Source)); surface != nil && surface.Surface != nil {
+			imageID = strings.TrimSpace(surface.Surface.InitFrom)
+		}
+	}
+
+	for _, image := range l.doc.Images {
+		if image.ID == imageID {
+			if image.InitFrom.Ref != "" {
+				return strings.TrimSpace(image.InitFrom.Ref)
+			}
+			return strings.TrimSpace(image.InitFrom.Value)
+		}
+	}
+	return imageID
+}
+
+// ImportFromCOLLADA imports the geometry instantiated by a COLLADA (.dae)
+// visual scene as one welded, indexed mesh
+func (vi *VertexImporter) ImportFromCOLLADA(reader io.Reader) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read COLLADA file: %w", err)
+	}
+
+	loader, err := newColladaLoader(data)
+	if err != nil {
+		return err
+	}
+
+	welder, err := loader.loadGeometry()
+	if err != nil {
+		return fmt.Errorf("failed to load COLLADA geometry: %w", err)
+	}
+
+	vi.appendWelded(welder)
+	return nil
+}
+
+// ImportFromCOLLADA imports Phong, Blinn, Lambert and constant materials
+// from a COLLADA (.dae) file
+func (mi *MaterialImporter) ImportFromCOLLADA(reader io.Reader) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read COLLADA file: %w", err)
+	}
+
+	loader, err := newColladaLoader(data)
+	if err != nil {
+		return err
+	}
+
+	materials, err := loader.loadMaterials()
+	if err != nil {
+		return fmt.Errorf("failed to load COLLADA materials: %w", err)
+	}
+
+	for _, mat := range materials {
+		mi.materials[mat.Name] = mat
+	}
+	return nil
+}
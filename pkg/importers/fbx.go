@@ -0,0 +1,790 @@
+package importers
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+const (
+	fbxBinaryMagic    = "Kaydara FBX Binary  \x00"
+	fbxHeaderSize     = 27
+	fbxMinVersion     = 7100
+	fbxWideVersion    = 7500 // node headers use 64-bit fields from this version on
+	fbxMaxDepth       = 64
+	fbxMaxInflateRate = 1032 // upper bound of the deflate compression ratio
+	// fbxMaxInflatedSize bounds the bytes of all compressed arrays of a
+	// file once inflated, as a small file can inflate to gigabytes
+	fbxMaxInflatedSize = 256 << 20
+)
+
+// FBXDocument is the node tree of a parsed FBX file
+type FBXDocument struct {
+	Version uint32
+	Nodes   []*FBXNode
+}
+
+// FBXNode is a named FBX node record with typed properties and children
+type FBXNode struct {
+	Name       string
+	Properties []FBXProperty
+	Children   []*FBXNode
+}
+
+// FBXProperty is a typed property value. Type is the FBX type code and
+// Value holds the matching Go type:
+//
+//	'Y' int16, 'C' bool, 'I' int32, 'F' float32, 'D' float64, 'L' int64,
+//	'f' []float32, 'd' []float64, 'l' []int64, 'i' []int32, 'b' []bool,
+//	'S' string, 'R' []byte
+type FBXProperty struct {
+	Type  byte
+	Value interface{}
+}
+
+// Child returns the first child node with the given name
+func (n *FBXNode) Child(name string) *FBXNode {
+	if n == nil {
+		return nil
+	}
+	for _, child := range n.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// ChildrenNamed returns all child nodes with the given name
+func (n *FBXNode) ChildrenNamed(name string) []*FBXNode {
+	if n == nil {
+		return nil
+	}
+	var result []*FBXNode
+	for _, child := range n.Children {
+		if child.Name == name {
+			result = append(result, child)
+		}
+	}
+	return result
+}
+
+// Property returns the property at index i, if present
+func (n *FBXNode) Property(i int) (FBXProperty, bool) {
+	if n == nil || i < 0 || i >= len(n.Properties) {
+		return FBXProperty{}, false
+	}
+	return n.Properties[i], true
+}
+
+// Node returns the first top-level node with the given name
+func (d *FBXDocument) Node(name string) *FBXNode {
+	for _, node := range d.Nodes {
+		if node.Name == name {
+			return node
+		}
+	}
+	return nil
+}
+
+// String returns the property as a string, for 'S' and 'R' values
+func (p FBXProperty) String() (string, bool) {
+	switch v := p.Value.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	}
+	return "", false
+}
+
+// Float64 returns a numeric scalar property as float64
+func (p FBXProperty) Float64() (float64, bool) {
+	switch v := p.Value.(type) {
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// Int64 returns an integer scalar property as int64
+func (p FBXProperty) Int64() (int64, bool) {
+	switch v := p.Value.(type) {
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	}
+	return 0, false
+}
+
+// Float64s returns a numeric array property as []float64
+func (p FBXProperty) Float64s() ([]float64, bool) {
+	switch v := p.Value.(type) {
+	case []float64:
+		return v, true
+	case []float32:
+		result := make([]float64, len(v))
+		for i, f := range v {
+			result[i] = float64(f)
+		}
+		return result, true
+	case []int32:
+		result := make([]float64, len(v))
+		for i, n := range v {
+			result[i] = float64(n)
+		}
+		return result, true
+	case []int64:
+		result := make([]float64, len(v))
+		for i, n := range v {
+			result[i] = float64(n)
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+// Int32s returns an integer array property as []int32
+func (p FBXProperty) Int32s() ([]int32, bool) {
+	switch v := p.Value.(type) {
+	case []int32:
+		return v, true
+	case []int64:
+		result := make([]int32, len(v))
+		for i, n := range v {
+			if n < math.MinInt32 || n > math.MaxInt32 {
+				return nil, false
+			}
+			result[i] = int32(n)
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+// ParseFBXBinary parses a binary FBX file into its node tree
+func ParseFBXBinary(data []byte) (*FBXDocument, error) {
+	if len(data) < fbxHeaderSize || string(data[:len(fbxBinaryMagic)]) != fbxBinaryMagic {
+		return nil, errors.New("invalid FBX binary format")
+	}
+
+	version := binary.LittleEndian.Uint32(data[23:27])
+	if version < fbxMinVersion {
+		return nil, fmt.Errorf("unsupported FBX version: %d", version)
+	}
+
+	r := &fbxBinaryReader{
+		data:   data,
+		offset: fbxHeaderSize,
+		wide:   version >= fbxWideVersion,
+	}
+
+	nodes, err := r.readNodeList(len(data), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FBXDocument{Version: version, Nodes: nodes}, nil
+}
+
+// fbxBinaryReader walks the node records of a binary FBX file
+type fbxBinaryReader struct {
+	data   []byte
+	offset int
+	wide   bool
+
+	// inflated counts the bytes of the compressed arrays read so far
+	inflated int
+}
+
+func (r *fbxBinaryReader) headerSize() int {
+	if r.wide {
+		return 25
+	}
+	return 13
+}
+
+// readNodeList reads sibling nodes up to end or a terminating null record
+func (r *fbxBinaryReader) readNodeList(end, depth int) ([]*FBXNode, error) {
+	if depth > fbxMaxDepth {
+		return nil, errors.New("FBX node tree is nested too deeply")
+	}
+
+	var nodes []*FBXNode
+	for r.offset < end {
+		// The top-level list may end without a null record before the footer
+		if depth == 0 && end-r.offset < r.headerSize() {
+			break
+		}
+
+		node, err := r.readNode(end, depth)
+		if err != nil {
+			return nil, err
+		}
+		if node == nil {
+			break
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// readNode reads a single node record, returning nil for a null record
+func (r *fbxBinaryReader) readNode(end, depth int) (*FBXNode, error) {
+	start := r.offset
+	if end-start < r.headerSize() {
+		return nil, fmt.Errorf("truncated node header at offset %d", start)
+	}
+
+	var endOffset, numProperties, propertyListLen uint64
+	if r.wide {
+		endOffset = binary.LittleEndian.Uint64(r.data[start:])
+		numProperties = binary.LittleEndian.Uint64(r.data[start+8:])
+		propertyListLen = binary.LittleEndian.Uint64(r.data[start+16:])
+	} else {
+		endOffset = uint64(binary.LittleEndian.Uint32(r.data[start:]))
+		numProperties = uint64(binary.LittleEndian.Uint32(r.data[start+4:]))
+		propertyListLen = uint64(binary.LittleEndian.Uint32(r.data[start+8:]))
+	}
+	nameLen := int(r.data[start+r.headerSize()-1])
+	r.offset = start + r.headerSize()
+
+	if endOffset == 0 {
+		// Null record terminating a node list
+		return nil, nil
+	}
+	if endOffset > uint64(end) || endOffset < uint64(r.offset+nameLen) {
+		return nil, fmt.Errorf("node at offset %d has invalid end offset %d", start, endOffset)
+	}
+	if propertyListLen > endOffset-uint64(r.offset+nameLen) {
+		return nil, fmt.Errorf("node at offset %d has invalid property list length %d", start, propertyListLen)
+	}
+
+	node := &FBXNode{Name: string(r.data[r.offset : r.offset+nameLen])}
+	r.offset += nameLen
+
+	propertiesEnd := r.offset + int(propertyListLen)
+	// Every property takes at least two bytes, which bounds the allocation
+	node.Properties = make([]FBXProperty, 0, min(numProperties, propertyListLen/2))
+	for i := uint64(0); i < numProperties; i++ {
+		prop, err := r.readProperty(propertiesEnd)
+		if err != nil {
+			return nil, fmt.Errorf("node %q property %d: %w", node.Name, i, err)
+		}
+		node.Properties = append(node.Properties, prop)
+	}
+	if r.offset != propertiesEnd {
+		return nil, fmt.Errorf("node %q property list length mismatch", node.Name)
+	}
+
+	if r.offset < int(endOffset) {
+		children, err := r.readNodeList(int(endOffset), depth+1)
+		if err != nil {
+			return nil, err
+		}
+		node.Children = children
+	}
+	r.offset = int(endOffset)
+
+	return node, nil
+}
+
+// readProperty reads a single typed property record
+func (r *fbxBinaryReader) readProperty(end int) (FBXProperty, error) {
+	if r.offset >= end {
+		return FBXProperty{}, errors.New("truncated property")
+	}
+
+	typeCode := r.data[r.offset]
+	r.offset++
+
+	need := func(n int) ([]byte, error) {
+		if n < 0 || end-r.offset < n {
+			return nil, fmt.Errorf("truncated %q property", typeCode)
+		}
+		b := r.data[r.offset : r.offset+n]
+		r.offset += n
+		return b, nil
+	}
+
+	switch typeCode {
+	case 'Y':
+		b, err := need(2)
+		if err != nil {
+			return FBXProperty{}, err
+		}
+		return FBXProperty{Type: typeCode, Value: int16(binary.LittleEndian.Uint16(b))}, nil
+	case 'C':
+		b, err := need(1)
+		if err != nil {
+			return FBXProperty{}, err
+		}
+		return FBXProperty{Type: typeCode, Value: b[0] != 0}, nil
+	case 'I':
+		b, err := need(4)
+		if err != nil {
+			return FBXProperty{}, err
+		}
+		return FBXProperty{Type: typeCode, Value: int32(binary.LittleEndian.Uint32(b))}, nil
+	case 'F':
+		b, err := need(4)
+		if err != nil {
+			return FBXProperty{}, err
+		}
+		return FBXProperty{Type: typeCode, Value: math.Float32frombits(binary.LittleEndian.Uint32(b))}, nil
+	case 'D':
+		b, err := need(8)
+		if err != nil {
+			return FBXProperty{}, err
+		}
+		return FBXProperty{Type: typeCode, Value: math.Float64frombits(binary.LittleEndian.Uint64(b))}, nil
+	case 'L':
+		b, err := need(8)
+		if err != nil {
+			return FBXProperty{}, err
+		}
+		return FBXProperty{Type: typeCode, Value: int64(binary.LittleEndian.Uint64(b))}, nil
+	case 'S', 'R':
+		b, err := need(4)
+		if err != nil {
+			return FBXProperty{}, err
+		}
+		raw, err := need(int(binary.LittleEndian.Uint32(b)))
+		if err != nil {
+			return FBXProperty{}, err
+		}
+		if typeCode == 'S' {
+			return FBXProperty{Type: typeCode, Value: string(raw)}, nil
+		}
+		return FBXProperty{Type: typeCode, Value: append([]byte(nil), raw...)}, nil
+	case 'f', 'd', 'l', 'i', 'b':
+		return r.readArrayProperty(typeCode, need)
+	default:
+		return FBXProperty{}, fmt.Errorf("unknown property type %q at offset %d", typeCode, r.offset-1)
+	}
+}
+
+// readArrayProperty reads an array property, inflating it if compressed
+func (r *fbxBinaryReader) readArrayProperty(typeCode byte, need func(int) ([]byte, error)) (FBXProperty, error) {
+	header, err := need(12)
+	if err != nil {
+		return FBXProperty{}, err
+	}
+	arrayLength := int(binary.LittleEndian.Uint32(header[0:4]))
+	encoding := binary.LittleEndian.Uint32(header[4:8])
+	compressedLength := int(binary.LittleEndian.Uint32(header[8:12]))
+
+	elementSize := fbxArrayElementSizes[typeCode]
+	size := arrayLength * elementSize
+
+	var raw []byte
+	switch encoding {
+	case 0:
+		if raw, err = need(size); err != nil {
+			return FBXProperty{}, err
+		}
+	case 1:
+		compressed, err := need(compressedLength)
+		if err != nil {
+			return FBXProperty{}, err
+		}
+		if size/fbxMaxInflateRate > compressedLength {
+			return FBXProperty{}, fmt.Errorf("compressed %q array claims implausible size %d", typeCode, size)
+		}
+		if size > fbxMaxInflatedSize-r.inflated {
+			return FBXProperty{}, fmt.Errorf("compressed arrays inflate to more than %d bytes", fbxMaxInflatedSize)
+		}
+		r.inflated += size
+
+		zr, err := zlib.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return FBXProperty{}, fmt.Errorf("invalid compressed %q array: %w", typeCode, err)
+		}
+		// The stream is read through a limit rather than into a buffer of
+		// the claimed size, so arrays shorter than claimed allocate no more
+		// than they hold
+		if raw, err = io.ReadAll(io.LimitReader(zr, int64(size))); err != nil {
+			return FBXProperty{}, fmt.Errorf("invalid compressed %q array: %w", typeCode, err)
+		}
+		if len(raw) != size {
+			return FBXProperty{}, fmt.Errorf("invalid compressed %q array: %w", typeCode, io.ErrUnexpectedEOF)
+		}
+	default:
+		return FBXProperty{}, fmt.Errorf("unknown array encoding %d", encoding)
+	}
+
+	prop := FBXProperty{Type: typeCode}
+	switch typeCode {
+	case 'f':
+		values := make([]float32, arrayLength)
+		for i := range values {
+			values[i] = math.Float32frombits(binary.LittleEndian.Uint32(raw[i*4:]))
+		}
+		prop.Value = values
+	case 'd':
+		values := make([]float64, arrayLength)
+		for i := range values {
+			values[i] = math.Float64frombits(binary.LittleEndian.Uint64(raw[i*8:]))
+		}
+		prop.Value = values
+	case 'l':
+		values := make([]int64, arrayLength)
+		for i := range values {
+			values[i] = int64(binary.LittleEndian.Uint64(raw[i*8:]))
+		}
+		prop.Value = values
+	case 'i':
+		values := make([]int32, arrayLength)
+		for i := range values {
+			values[i] = int32(binary.LittleEndian.Uint32(raw[i*4:]))
+		}
+		prop.Value = values
+	case 'b':
+		values := make([]bool, arrayLength)
+		for i := range values {
+			values[i] = raw[i] != 0
+		}
+		prop.Value = values
+	}
+
+	return prop, nil
+}
+
+var fbxArrayElementSizes = map[byte]int{
+	'f': 4,
+	'd': 8,
+	'l': 8,
+	'i': 4,
+	'b': 1,
+}
+
+// fbxObjectName strips the class suffix from an object name. Binary files
+// store "Name\x00\x01Class", ASCII files "Class::Name".
+func fbxObjectName(name string) string {
+	if before, _, ok := strings.Cut(name, "\x00\x01"); ok {
+		return before
+	}
+	if _, after, ok := strings.Cut(name, "::"); ok {
+		return after
+	}
+	return name
+}
+
+// fbxNodeName returns the object name of an Objects child such as a
+// Geometry or Material, whose properties are id, name and class
+func fbxNodeName(node *FBXNode) string {
+	prop, ok := node.Property(1)
+	if !ok {
+		return ""
+	}
+	name, _ := prop.String()
+	return fbxObjectName(name)
+}
+
+// fbxNodeID returns the object id of an Objects child
+func fbxNodeID(node *FBXNode) (int64, bool) {
+	prop, ok := node.Property(0)
+	if !ok {
+		return 0, false
+	}
+	return prop.Int64()
+}
+
+// fbxGeometryMaterials maps geometry ids to the names of the materials of
+// the models they are connected to, in connection order. LayerElementMaterial
+// indices refer to this list.
+func fbxGeometryMaterials(doc *FBXDocument) map[int64][]string {
+	objects := doc.Node("Objects")
+	names := make(map[int64]string)
+	for _, node := range objects.ChildrenNamed("Material") {
+		if id, ok := fbxNodeID(node); ok {
+			names[id] = fbxNodeName(node)
+		}
+	}
+
+	// Geometries and materials are linked to models by "OO" connections
+	geometryModels := make(map[int64][]int64)
+	modelMaterials := make(map[int64][]string)
+	for _, connection := range doc.Node("Connections").ChildrenNamed("C") {
+		if len(connection.Properties) < 3 {
+			continue
+		}
+		if kind, _ := connection.Properties[0].String(); kind != "OO" {
+			continue
+		}
+		child, _ := connection.Properties[1].Int64()
+		parent, _ := connection.Properties[2].Int64()
+		if name, ok := names[child]; ok {
+			modelMaterials[parent] = append(modelMaterials[parent], name)
+		} else {
+			geometryModels[child] = append(geometryModels[child], parent)
+		}
+	}
+
+	materials := make(map[int64][]string)
+	for geometry, models := range geometryModels {
+		// A geometry shared by several models takes the first one's materials
+		for _, model := range models {
+			if list, ok := modelMaterials[model]; ok {
+				materials[geometry] = list
+				break
+			}
+		}
+	}
+	return materials
+}
+
+// fbxGeometryMesh welds the polygon vertices of a mesh Geometry node into
+// w, resolving normals and UVs through their layer elements. Polygon
+// vertices with equal attributes share one vertex. Polygons are grouped by
+// their LayerElementMaterial entry, naming the groups from materials.
+func fbxGeometryMesh(geometry *FBXNode, materials []string, w *vertexWelder) error {
+	prop, ok := geometry.Child("Vertices").Property(0)
+	if !ok {
+		return nil
+	}
+	positions, ok := prop.Float64s()
+	if !ok || len(positions)%3 != 0 {
+		return errors.New("invalid Vertices array")
+	}
+
+	prop, ok = geometry.Child("PolygonVertexIndex").Property(0)
+	if !ok {
+		return errors.New("missing PolygonVertexIndex")
+	}
+	polygonVertices, ok := prop.Int32s()
+	if !ok {
+		return errors.New("invalid PolygonVertexIndex array")
+	}
+
+	normals, err := newFBXLayerElement(geometry.Child("LayerElementNormal"), "Normals", "NormalsIndex", 3)
+	if err != nil {
+		return err
+	}
+	uvs, err := newFBXLayerElement(geometry.Child("LayerElementUV"), "UV", "UVIndex", 2)
+	if err != nil {
+		return err
+	}
+	polygonMaterials, err := fbxLayerMaterials(geometry.Child("LayerElementMaterial"))
+	if err != nil {
+		return err
+	}
+
+	// Polygons are welded first and added per material afterwards so that
+	// each material's triangles form one range
+	var polygons [][]uint32
+	var corners []uint32
+	polygon := 0
+	for i, index := range polygonVertices {
+		// The last vertex of each polygon is stored as -(index+1)
+		last := index < 0
+		controlPoint := int(index)
+		if last {
+			controlPoint = int(^index)
+		}
+		if (controlPoint+1)*3 > len(positions) {
+			return fmt.Errorf("polygon vertex %d references missing control point %d", i, controlPoint)
+		}
+
+		var vertex Vertex
+		copy(vertex.Position[:], positions[controlPoint*3:])
+
+		if normals != nil {
+			normal, err := normals.lookup(i, controlPoint, polygon)
+			if err != nil {
+				return fmt.Errorf("normals: %w", err)
+			}
+			copy(vertex.Normal[:], normal)
+		}
+		if uvs != nil {
+			uv, err := uvs.lookup(i, controlPoint, polygon)
+			if err != nil {
+				return fmt.Errorf("UVs: %w", err)
+			}
+			copy(vertex.TexCoords[:], uv)
+		}
+
+		corners = append(corners, w.add(vertex))
+		if last {
+			if len(corners) < 3 {
+				return fmt.Errorf("polygon %d has fewer than 3 vertices", polygon)
+			}
+			polygons = append(polygons, corners)
+			corners = nil
+			polygon++
+		}
+	}
+	if len(corners) > 0 {
+		return errors.New("unterminated polygon in PolygonVertexIndex")
+	}
+
+	var order []string
+	byMaterial := make(map[string][]int)
+	for i := range polygons {
+		var material string
+		if len(polygonMaterials) > 0 {
+			// AllSame layers hold a single entry for every polygon
+			index := int32(-1)
+			if len(polygonMaterials) == 1 {
+				index = polygonMaterials[0]
+			} else if i < len(polygonMaterials) {
+				index = polygonMaterials[i]
+			}
+			// Unassigned polygons use -1; others may reference materials
+			// that were not exported
+			if index >= 0 && int(index) < len(materials) {
+				material = materials[index]
+			}
+		} else if len(materials) > 0 {
+			material = materials[0]
+		}
+		if _, ok := byMaterial[material]; !ok {
+			order = append(order, material)
+		}
+		byMaterial[material] = append(byMaterial[material], i)
+	}
+
+	for _, material := range order {
+		group := MeshGroup{
+			Object:   fbxNodeName(geometry),
+			Material: material,
+			Start:    len(w.indices),
+			Faces:    len(byMaterial[material]),
+		}
+		for _, i := range byMaterial[material] {
+			w.addPolygon(polygons[i])
+		}
+		w.addGroup(group)
+	}
+
+	return nil
+}
+
+// fbxLayerMaterials reads the per-polygon material indices of a
+// LayerElementMaterial node. It returns nil if the layer is absent.
+func fbxLayerMaterials(layer *FBXNode) ([]int32, error) {
+	if layer == nil {
+		return nil, nil
+	}
+	prop, ok := layer.Child("Materials").Property(0)
+	if !ok {
+		return nil, nil
+	}
+	indices, ok := prop.Int32s()
+	if !ok {
+		return nil, errors.New("LayerElementMaterial has invalid Materials")
+	}
+
+	if prop, ok := layer.Child("MappingInformationType").Property(0); ok {
+		switch mapping, _ := prop.String(); mapping {
+		case "AllSame":
+			if len(indices) > 1 {
+				indices = indices[:1]
+			}
+		case "ByPolygon", "":
+		default:
+			return nil, fmt.Errorf("LayerElementMaterial has unsupported mapping type %q", mapping)
+		}
+	}
+	return indices, nil
+}
+
+// fbxLayerElement is a per-vertex attribute layer such as normals or UVs
+type fbxLayerElement struct {
+	mapping    string
+	reference  string
+	values     []float64
+	indices    []int32
+	components int
+}
+
+// newFBXLayerElement reads a LayerElement node with the given value and
+// index child names. It returns nil if the layer is absent.
+func newFBXLayerElement(layer *FBXNode, valuesName, indexName string, components int) (*fbxLayerElement, error) {
+	if layer == nil {
+		return nil, nil
+	}
+
+	element := &fbxLayerElement{components: components}
+	if prop, ok := layer.Child("MappingInformationType").Property(0); ok {
+		element.mapping, _ = prop.String()
+	}
+	if prop, ok := layer.Child("ReferenceInformationType").Property(0); ok {
+		element.reference, _ = prop.String()
+	}
+
+	prop, ok := layer.Child(valuesName).Property(0)
+	if !ok {
+		return nil, fmt.Errorf("%s has no %s", layer.Name, valuesName)
+	}
+	if element.values, ok = prop.Float64s(); !ok {
+		return nil, fmt.Errorf("%s has invalid %s", layer.Name, valuesName)
+	}
+
+	if element.reference == "IndexToDirect" || element.reference == "Index" {
+		prop, ok := layer.Child(indexName).Property(0)
+		if !ok {
+			return nil, fmt.Errorf("%s has no %s", layer.Name, indexName)
+		}
+		if element.indices, ok = prop.Int32s(); !ok {
+			return nil, fmt.Errorf("%s has invalid %s", layer.Name, indexName)
+		}
+	}
+
+	return element, nil
+}
+
+// lookup returns the layer value for a polygon vertex, given its index in
+// PolygonVertexIndex, its control point and its polygon
+func (e *fbxLayerElement) lookup(polygonVertex, controlPoint, polygon int) ([]float64, error) {
+	var i int
+	switch e.mapping {
+	case "ByPolygonVertex":
+		i = polygonVertex
+	case "ByVertex", "ByVertice", "ByControlPoint":
+		i = controlPoint
+	case "ByPolygon":
+		i = polygon
+	case "AllSame":
+		i = 0
+	default:
+		return nil, fmt.Errorf("unsupported mapping type %q", e.mapping)
+	}
+
+	switch e.reference {
+	case "Direct":
+	case "IndexToDirect", "Index":
+		if i >= len(e.indices) {
+			return nil, fmt.Errorf("layer index %d out of range", i)
+		}
+		i = int(e.indices[i])
+	default:
+		return nil, fmt.Errorf("unsupported reference type %q", e.reference)
+	}
+
+	if i < 0 || (i+1)*e.components > len(e.values) {
+		return nil, fmt.Errorf("layer value %d out of range", i)
+	}
+	return e.values[i*e.components : (i+1)*e.components], nil
+}
@@ -0,0 +1,378 @@
+package importers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ParseFBX parses an FBX file, detecting whether it is binary or ASCII
+func ParseFBX(data []byte) (*FBXDocument, error) {
+	if bytes.HasPrefix(data, []byte(fbxBinaryMagic[:len(fbxBinaryMagic)-1])) {
+		return ParseFBXBinary(data)
+	}
+	return ParseFBXASCII(data)
+}
+
+// ParseFBXASCII parses an ASCII FBX file into the same node tree the
+// binary parser produces. Integers become int64 properties, reals float64
+// and quoted or bare words strings. Arrays written as "*N { a: ... }"
+// become a single array property: []int32 when every value is an integer
+// that fits, []int64 for larger integers and []float64 otherwise.
+func ParseFBXASCII(data []byte) (*FBXDocument, error) {
+	p := &fbxASCIIParser{lexer: fbxASCIILexer{data: data, line: 1}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	nodes, err := p.parseNodeList(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != fbxTokenEOF {
+		return nil, p.errorf("unexpected %s", p.tok)
+	}
+	if len(nodes) == 0 {
+		return nil, errors.New("invalid FBX ASCII format: no nodes")
+	}
+
+	doc := &FBXDocument{Nodes: nodes, Version: p.lexer.version}
+	if prop, ok := doc.Node("FBXHeaderExtension").Child("FBXVersion").Property(0); ok {
+		if version, ok := prop.Int64(); ok && version > 0 && version <= math.MaxUint32 {
+			doc.Version = uint32(version)
+		}
+	}
+
+	return doc, nil
+}
+
+type fbxTokenKind int
+
+const (
+	fbxTokenEOF fbxTokenKind = iota
+	fbxTokenKey
+	fbxTokenString
+	fbxTokenNumber
+	fbxTokenWord
+	fbxTokenComma
+	fbxTokenOpen
+	fbxTokenClose
+	fbxTokenStar
+)
+
+type fbxToken struct {
+	kind fbxTokenKind
+	text string
+	line int
+}
+
+func (t fbxToken) String() string {
+	switch t.kind {
+	case fbxTokenEOF:
+		return "end of file"
+	case fbxTokenKey:
+		return fmt.Sprintf("key %q", t.text)
+	case fbxTokenString:
+		return fmt.Sprintf("string %q", t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// fbxVersionComment matches the "; FBX 7.4.0 project file" header comment
+var fbxVersionComment = regexp.MustCompile(`^;\s*FBX\s+(\d+)\.(\d+)\.(\d+)`)
+
+// fbxASCIILexer splits ASCII FBX data into tokens
+type fbxASCIILexer struct {
+	data    []byte
+	offset  int
+	line    int
+	version uint32
+}
+
+func (l *fbxASCIILexer) next() (fbxToken, error) {
+	for l.offset < len(l.data) {
+		c := l.data[l.offset]
+		switch {
+		case c == '\n':
+			l.line++
+			l.offset++
+		case c == ' ' || c == '\t' || c == '\r':
+			l.offset++
+		case c == ';':
+			end := bytes.IndexByte(l.data[l.offset:], '\n')
+			if end < 0 {
+				end = len(l.data) - l.offset
+			}
+			l.readVersionComment(l.data[l.offset : l.offset+end])
+			l.offset += end
+		default:
+			return l.scan()
+		}
+	}
+	return fbxToken{kind: fbxTokenEOF, line: l.line}, nil
+}
+
+// readVersionComment takes the file version from the header comment, for
+// files whose FBXHeaderExtension lacks FBXVersion
+func (l *fbxASCIILexer) readVersionComment(comment []byte) {
+	if l.version != 0 {
+		return
+	}
+	if m := fbxVersionComment.FindSubmatch(comment); m != nil {
+		major, _ := strconv.Atoi(string(m[1]))
+		minor, _ := strconv.Atoi(string(m[2]))
+		patch, _ := strconv.Atoi(string(m[3]))
+		l.version = uint32(major*1000 + minor*100 + patch)
+	}
+}
+
+func (l *fbxASCIILexer) scan() (fbxToken, error) {
+	start := l.offset
+	c := l.data[start]
+	tok := fbxToken{line: l.line}
+
+	switch {
+	case c == ',':
+		l.offset++
+		tok.kind, tok.text = fbxTokenComma, ","
+	case c == '{':
+		l.offset++
+		tok.kind, tok.text = fbxTokenOpen, "{"
+	case c == '}':
+		l.offset++
+		tok.kind, tok.text = fbxTokenClose, "}"
+	case c == '*':
+		l.offset++
+		tok.kind, tok.text = fbxTokenStar, "*"
+	case c == '"':
+		end := bytes.IndexByte(l.data[start+1:], '"')
+		if end < 0 {
+			return tok, fmt.Errorf("FBX ASCII line %d: unterminated string", l.line)
+		}
+		raw := string(l.data[start+1 : start+1+end])
+		l.line += strings.Count(raw, "\n")
+		l.offset = start + end + 2
+		tok.kind, tok.text = fbxTokenString, strings.ReplaceAll(raw, "&quot;", `"`)
+	case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+		for l.offset < len(l.data) && isFBXNumberByte(l.data[l.offset]) {
+			l.offset++
+		}
+		tok.kind, tok.text = fbxTokenNumber, string(l.data[start:l.offset])
+	case isFBXWordByte(c):
+		for l.offset < len(l.data) && isFBXWordByte(l.data[l.offset]) {
+			l.offset++
+		}
+		tok.kind, tok.text = fbxTokenWord, string(l.data[start:l.offset])
+		if l.offset < len(l.data) && l.data[l.offset] == ':' {
+			l.offset++
+			tok.kind = fbxTokenKey
+		}
+	default:
+		return tok, fmt.Errorf("FBX ASCII line %d: unexpected character %q", l.line, c)
+	}
+
+	return tok, nil
+}
+
+func isFBXNumberByte(c byte) bool {
+	return (c >= '0' && c <= '9') || c == '-' || c == '+' || c == '.' || c == 'e' || c == 'E'
+}
+
+func isFBXWordByte(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' || c == '|'
+}
+
+// fbxASCIIParser builds the node tree from lexer tokens
+type fbxASCIIParser struct {
+	lexer fbxASCIILexer
+	tok   fbxToken
+}
+
+func (p *fbxASCIIParser) advance() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *fbxASCIIParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("FBX ASCII line %d: %s", p.tok.line, fmt.Sprintf(format, args...))
+}
+
+func (p *fbxASCIIParser) expect(kind fbxTokenKind, what string) error {
+	if p.tok.kind != kind {
+		return p.errorf("expected %s, got %s", what, p.tok)
+	}
+	return p.advance()
+}
+
+// parseNodeList reads nodes until a closing brace or the end of the file
+func (p *fbxASCIIParser) parseNodeList(depth int) ([]*FBXNode, error) {
+	if depth > fbxMaxDepth {
+		return nil, p.errorf("node tree is nested too deeply")
+	}
+
+	var nodes []*FBXNode
+	for p.tok.kind == fbxTokenKey {
+		node, err := p.parseNode(depth)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	if p.tok.kind != fbxTokenClose && p.tok.kind != fbxTokenEOF {
+		return nil, p.errorf("expected node name, got %s", p.tok)
+	}
+	return nodes, nil
+}
+
+// parseNode reads "Name: properties [{ children }]"
+func (p *fbxASCIIParser) parseNode(depth int) (*FBXNode, error) {
+	node := &FBXNode{Name: p.tok.text}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind == fbxTokenStar {
+		array, err := p.parseArray(depth)
+		if err != nil {
+			return nil, fmt.Errorf("node %q: %w", node.Name, err)
+		}
+		node.Properties = []FBXProperty{array}
+		return node, nil
+	}
+
+	for {
+		switch p.tok.kind {
+		case fbxTokenString:
+			node.Properties = append(node.Properties, FBXProperty{Type: 'S', Value: p.tok.text})
+		case fbxTokenWord:
+			node.Properties = append(node.Properties, FBXProperty{Type: 'S', Value: p.tok.text})
+		case fbxTokenNumber:
+			prop, err := parseFBXASCIINumber(p.tok.text)
+			if err != nil {
+				return nil, p.errorf("node %q: %v", node.Name, err)
+			}
+			node.Properties = append(node.Properties, prop)
+		case fbxTokenComma:
+		case fbxTokenOpen:
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			children, err := p.parseNodeList(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(fbxTokenClose, `"}"`); err != nil {
+				return nil, err
+			}
+			node.Children = children
+			return node, nil
+		default:
+			return node, nil
+		}
+
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseArray reads "*N { a: v, v, ... }" into a single array property
+func (p *fbxASCIIParser) parseArray(depth int) (FBXProperty, error) {
+	if err := p.advance(); err != nil {
+		return FBXProperty{}, err
+	}
+	if p.tok.kind != fbxTokenNumber {
+		return FBXProperty{}, p.errorf("expected array length, got %s", p.tok)
+	}
+	length, err := strconv.Atoi(p.tok.text)
+	if err != nil || length < 0 {
+		return FBXProperty{}, p.errorf("invalid array length %q", p.tok.text)
+	}
+	if err := p.advance(); err != nil {
+		return FBXProperty{}, err
+	}
+	if err := p.expect(fbxTokenOpen, `"{"`); err != nil {
+		return FBXProperty{}, err
+	}
+
+	children, err := p.parseNodeList(depth + 1)
+	if err != nil {
+		return FBXProperty{}, err
+	}
+	if err := p.expect(fbxTokenClose, `"}"`); err != nil {
+		return FBXProperty{}, err
+	}
+
+	var values []FBXProperty
+	for _, child := range children {
+		if child.Name == "a" {
+			values = child.Properties
+			break
+		}
+	}
+	if len(values) != length {
+		return FBXProperty{}, fmt.Errorf("array declares %d values, has %d", length, len(values))
+	}
+
+	return fbxASCIIArray(values)
+}
+
+// parseFBXASCIINumber types a number token as int64 or float64
+func parseFBXASCIINumber(text string) (FBXProperty, error) {
+	if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return FBXProperty{Type: 'L', Value: i}, nil
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return FBXProperty{}, fmt.Errorf("invalid number %q", text)
+	}
+	return FBXProperty{Type: 'D', Value: f}, nil
+}
+
+// fbxASCIIArray packs scalar properties into the narrowest array type
+func fbxASCIIArray(values []FBXProperty) (FBXProperty, error) {
+	integers, fitsInt32 := true, true
+	for _, value := range values {
+		switch v := value.Value.(type) {
+		case int64:
+			if v < math.MinInt32 || v > math.MaxInt32 {
+				fitsInt32 = false
+			}
+		case float64:
+			integers = false
+		default:
+			return FBXProperty{}, errors.New("array contains non-numeric values")
+		}
+	}
+
+	switch {
+	case integers && fitsInt32:
+		result := make([]int32, len(values))
+		for i, value := range values {
+			result[i] = int32(value.Value.(int64))
+		}
+		return FBXProperty{Type: 'i', Value: result}, nil
+	case integers:
+		result := make([]int64, len(values))
+		for i, value := range values {
+			result[i] = value.Value.(int64)
+		}
+		return FBXProperty{Type: 'l', Value: result}, nil
+	default:
+		result := make([]float64, len(values))
+		for i, value := range values {
+			result[i], _ = value.Float64()
+		}
+		return FBXProperty{Type: 'd', Value: result}, nil
+	}
+}
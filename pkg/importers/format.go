@@ -0,0 +1,42 @@
+package importers
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// DetectFormat guesses a model format from the first bytes of a file and
+// its total size. Zip archives other than 3MF packages are reported as
+// "zip", meaning a bundle. It returns "" if the data matches no known
+// format.
+func DetectFormat(head []byte, size int64) string {
+	trimmed := bytes.TrimLeft(head, " \t\r\n\xef\xbb\xbf")
+
+	switch {
+	case len(head) >= 4 && binary.LittleEndian.Uint32(head) == glbMagic:
+		return "glb"
+	case bytes.HasPrefix(head, []byte(fbxBinaryMagic[:len(fbxBinaryMagic)-1])):
+		return "fbx"
+	case bytes.HasPrefix(trimmed, []byte("; FBX")):
+		return "fbx"
+	case bytes.HasPrefix(head, []byte("ply\n")) || bytes.HasPrefix(head, []byte("ply\r\n")):
+		return "ply"
+	case len(head) >= stlHeaderSize+4 &&
+		size == stlHeaderSize+4+int64(binary.LittleEndian.Uint32(head[stlHeaderSize:]))*stlTriangleSize:
+		return "stl"
+	case bytes.HasPrefix(trimmed, []byte("solid")) && bytes.Contains(trimmed, []byte("facet")):
+		return "stl"
+	case bytes.HasPrefix(trimmed, []byte("<")) && bytes.Contains(head, []byte("<COLLADA")):
+		return "dae"
+	case bytes.HasPrefix(trimmed, []byte("{")) && bytes.Contains(trimmed, []byte(`"asset"`)):
+		return "gltf"
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		// 3MF packages start with their content types or model part
+		if bytes.Contains(head, []byte("[Content_Types].xml")) || bytes.Contains(head, []byte("3D/")) {
+			return "3mf"
+		}
+		return "zip"
+	}
+
+	return ""
+}
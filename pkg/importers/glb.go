@@ -0,0 +1,132 @@
+package importers
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// GLB container constants
+const (
+	glbMagic      = 0x46546C67 // "glTF"
+	glbVersion    = 2
+	glbHeaderSize = 12
+	glbChunkJSON  = 0x4E4F534A // "JSON"
+	glbChunkBIN   = 0x004E4942 // "BIN\x00"
+)
+
+// GLBContainer holds the chunks of a binary glTF file. JSON and BIN are
+// slices of the parsed data rather than copies.
+type GLBContainer struct {
+	JSON []byte
+	BIN  []byte
+}
+
+// ParseGLB validates a GLB header and its chunks
+func ParseGLB(data []byte) (*GLBContainer, error) {
+	if len(data) < glbHeaderSize {
+		return nil, fmt.Errorf("glb: truncated header: %d bytes, need %d", len(data), glbHeaderSize)
+	}
+
+	if magic := binary.LittleEndian.Uint32(data[0:4]); magic != glbMagic {
+		return nil, fmt.Errorf("glb: invalid magic 0x%08x", magic)
+	}
+	if version := binary.LittleEndian.Uint32(data[4:8]); version != glbVersion {
+		return nil, fmt.Errorf("glb: unsupported container version %d", version)
+	}
+
+	length := binary.LittleEndian.Uint32(data[8:12])
+	if uint64(length) > uint64(len(data)) {
+		return nil, fmt.Errorf("glb: truncated file: header declares %d bytes, got %d", length, len(data))
+	}
+	if length%4 != 0 {
+		return nil, fmt.Errorf("glb: total length %d is not 4-byte aligned", length)
+	}
+	data = data[:length]
+
+	container := &GLBContainer{}
+	offset := glbHeaderSize
+
+	for chunk := 0; offset < len(data); chunk++ {
+		if len(data)-offset < 8 {
+			return nil, fmt.Errorf("glb: truncated header of chunk %d at offset %d", chunk, offset)
+		}
+
+		chunkLength := binary.LittleEndian.Uint32(data[offset : offset+4])
+		chunkType := binary.LittleEndian.Uint32(data[offset+4 : offset+8])
+		start := offset + 8
+
+		if chunkLength%4 != 0 {
+			return nil, fmt.Errorf("glb: chunk %d at offset %d has misaligned length %d", chunk, offset, chunkLength)
+		}
+		if uint64(chunkLength) > uint64(len(data)-start) {
+			return nil, fmt.Errorf("glb: chunk %d at offset %d is truncated: declares %d bytes, %d remain", chunk, offset, chunkLength, len(data)-start)
+		}
+		body := data[start : start+int(chunkLength)]
+
+		switch {
+		case chunk == 0 && chunkType != glbChunkJSON:
+			return nil, fmt.Errorf("glb: first chunk must be JSON, got type 0x%08x", chunkType)
+		case chunkType == glbChunkJSON:
+			if chunk != 0 {
+				return nil, fmt.Errorf("glb: unexpected JSON chunk %d at offset %d", chunk, offset)
+			}
+			container.JSON = body
+		case chunkType == glbChunkBIN:
+			if chunk != 1 {
+				return nil, fmt.Errorf("glb: BIN chunk %d at offset %d must directly follow the JSON chunk", chunk, offset)
+			}
+			container.BIN = body
+		default:
+			// Unknown chunks must be ignored
+		}
+
+		offset = start + int(chunkLength)
+	}
+
+	if container.JSON == nil {
+		return nil, fmt.Errorf("glb: missing JSON chunk")
+	}
+
+	return container, nil
+}
+
+// newGLBLoader parses a GLB container and prepares its glTF document
+func newGLBLoader(data []byte, resolver ResourceResolver) (*gltfLoader, error) {
+	container, err := ParseGLB(data)
+	if err != nil {
+		return nil, err
+	}
+	return newGLTFLoader(container.JSON, container.BIN, resolver)
+}
+
+// ImportFromGLB imports vertices and triangle indices from a binary glTF
+// container
+func (vi *VertexImporter) ImportFromGLB(reader io.Reader, resolver ResourceResolver) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read GLB file: %w", err)
+	}
+
+	loader, err := newGLBLoader(data, resolver)
+	if err != nil {
+		return err
+	}
+
+	return vi.importGLTF(loader)
+}
+
+// ImportFromGLB imports materials from a binary glTF container
+func (mi *MaterialImporter) ImportFromGLB(reader io.Reader, resolver ResourceResolver) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read GLB file: %w", err)
+	}
+
+	loader, err := newGLBLoader(data, resolver)
+	if err != nil {
+		return err
+	}
+
+	return mi.importGLTF(loader)
+}
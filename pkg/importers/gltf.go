@@ -0,0 +1,957 @@
+package importers
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// glTF component types
+const (
+	gltfByte          = 5120
+	gltfUnsignedByte  = 5121
+	gltfShort         = 5122
+	gltfUnsignedShort = 5123
+	gltfUnsignedInt   = 5125
+	gltfFloat         = 5126
+)
+
+// gltfMaxZeroFilledValues bounds the values of an accessor without a
+// buffer view, which is initialized with zeros rather than read from the
+// file and so is not limited by the file's size
+const gltfMaxZeroFilledValues = 1 << 24
+
+// gltfMaxNodeVisits bounds the nodes visited while walking the scene,
+// counting a node once for every path to it
+const gltfMaxNodeVisits = 1 << 18
+
+// glTF primitive modes
+const (
+	gltfModeTriangles     = 4
+	gltfModeTriangleStrip = 5
+	gltfModeTriangleFan   = 6
+)
+
+// gltfDocument mirrors the parts of the glTF 2.0 JSON schema used by the
+// importers
+type gltfDocument struct {
+	Asset struct {
+		Version    string `json:"version"`
+		MinVersion string `json:"minVersion"`
+	} `json:"asset"`
+	Scene              *int             `json:"scene"`
+	Scenes             []gltfScene      `json:"scenes"`
+	Nodes              []gltfNode       `json:"nodes"`
+	Meshes             []gltfMesh       `json:"meshes"`
+	Accessors          []gltfAccessor   `json:"accessors"`
+	BufferViews        []gltfBufferView `json:"bufferViews"`
+	Buffers            []gltfBuffer     `json:"buffers"`
+	Materials          []gltfMaterial   `json:"materials"`
+	Textures           []gltfTexture    `json:"textures"`
+	Images             []gltfImage      `json:"images"`
+	ExtensionsRequired []string         `json:"extensionsRequired"`
+}
+
+type gltfScene struct {
+	Name  string `json:"name"`
+	Nodes []int  `json:"nodes"`
+}
+
+type gltfNode struct {
+	Name        string      `json:"name"`
+	Mesh        *int        `json:"mesh"`
+	Children    []int       `json:"children"`
+	Matrix      []float64   `json:"matrix"`
+	Translation *[3]float64 `json:"translation"`
+	Rotation    *[4]float64 `json:"rotation"`
+	Scale       *[3]float64 `json:"scale"`
+}
+
+type gltfMesh struct {
+	Name       string          `json:"name"`
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices"`
+	Material   *int           `json:"material"`
+	Mode       *int           `json:"mode"`
+}
+
+type gltfAccessor struct {
+	BufferView    *int        `json:"bufferView"`
+	ByteOffset    int         `json:"byteOffset"`
+	ComponentType int         `json:"componentType"`
+	Normalized    bool        `json:"normalized"`
+	Count         int         `json:"count"`
+	Type          string      `json:"type"`
+	Min           []float64   `json:"min"`
+	Max           []float64   `json:"max"`
+	Sparse        *gltfSparse `json:"sparse"`
+}
+
+type gltfSparse struct {
+	Count   int `json:"count"`
+	Indices struct {
+		BufferView    int `json:"bufferView"`
+		ByteOffset    int `json:"byteOffset"`
+		ComponentType int `json:"componentType"`
+	} `json:"indices"`
+	Values struct {
+		BufferView int `json:"bufferView"`
+		ByteOffset int `json:"byteOffset"`
+	} `json:"values"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride"`
+}
+
+type gltfBuffer struct {
+	URI        string `json:"uri"`
+	ByteLength int    `json:"byteLength"`
+}
+
+type gltfTextureInfo struct {
+	Index    int      `json:"index"`
+	TexCoord int      `json:"texCoord"`
+	Scale    *float64 `json:"scale"`
+	Strength *float64 `json:"strength"`
+}
+
+type gltfMaterial struct {
+	Name                 string `json:"name"`
+	PBRMetallicRoughness *struct {
+		BaseColorFactor          *[4]float64      `json:"baseColorFactor"`
+		BaseColorTexture         *gltfTextureInfo `json:"baseColorTexture"`
+		MetallicFactor           *float64         `json:"metallicFactor"`
+		RoughnessFactor          *float64         `json:"roughnessFactor"`
+		MetallicRoughnessTexture *gltfTextureInfo `json:"metallicRoughnessTexture"`
+	} `json:"pbrMetallicRoughness"`
+	NormalTexture    *gltfTextureInfo `json:"normalTexture"`
+	OcclusionTexture *gltfTextureInfo `json:"occlusionTexture"`
+	EmissiveTexture  *gltfTextureInfo `json:"emissiveTexture"`
+	EmissiveFactor   *[3]float64      `json:"emissiveFactor"`
+	AlphaMode        string           `json:"alphaMode"`
+	AlphaCutoff      *float64         `json:"alphaCutoff"`
+	DoubleSided      bool             `json:"doubleSided"`
+}
+
+type gltfTexture struct {
+	Source *int `json:"source"`
+}
+
+type gltfImage struct {
+	Name       string `json:"name"`
+	URI        string `json:"uri"`
+	BufferView *int   `json:"bufferView"`
+	MimeType   string `json:"mimeType"`
+}
+
+// gltfAccessorData holds decoded accessor elements as float64 components
+type gltfAccessorData struct {
+	count      int
+	components int
+	values     []float64
+}
+
+func (a *gltfAccessorData) element(i int) []float64 {
+	return a.values[i*a.components : (i+1)*a.components]
+}
+
+// gltfLoader resolves buffers and accessors of a parsed glTF document.
+// Buffers are loaded lazily so that unused external files are not read.
+type gltfLoader struct {
+	doc      *gltfDocument
+	bin      []byte
+	resolver ResourceResolver
+	buffers  map[int][]byte
+}
+
+// newGLTFLoader parses a glTF JSON document. bin holds the BIN chunk of a
+// GLB container and may be nil.
+func newGLTFLoader(jsonData, bin []byte, resolver ResourceResolver) (*gltfLoader, error) {
+	var doc gltfDocument
+	if err := json.Unmarshal(jsonData, &doc); err != nil {
+		return nil, fmt.Errorf("invalid glTF JSON: %w", err)
+	}
+
+	version := doc.Asset.Version
+	if doc.Asset.MinVersion != "" {
+		version = doc.Asset.MinVersion
+	}
+	if !strings.HasPrefix(version, "2.") {
+		return nil, fmt.Errorf("unsupported glTF version: %q", doc.Asset.Version)
+	}
+
+	if len(doc.ExtensionsRequired) > 0 {
+		return nil, fmt.Errorf("unsupported required glTF extensions: %s", strings.Join(doc.ExtensionsRequired, ", "))
+	}
+
+	return &gltfLoader{
+		doc:      &doc,
+		bin:      bin,
+		resolver: resolver,
+		buffers:  make(map[int][]byte),
+	}, nil
+}
+
+// buffer returns the contents of a buffer, loading it on first use
+func (l *gltfLoader) buffer(index int) ([]byte, error) {
+	if data, ok := l.buffers[index]; ok {
+		return data, nil
+	}
+	if index < 0 || index >= len(l.doc.Buffers) {
+		return nil, fmt.Errorf("buffer index out of range: %d", index)
+	}
+
+	buf := l.doc.Buffers[index]
+	var data []byte
+	var err error
+
+	switch {
+	case buf.URI == "":
+		// Only the first buffer of a GLB may omit its URI
+		if index != 0 || l.bin == nil {
+			return nil, fmt.Errorf("buffer %d has no URI", index)
+		}
+		data = l.bin
+	case strings.HasPrefix(buf.URI, "data:"):
+		data, err = decodeDataURI(buf.URI)
+	case l.resolver == nil:
+		return nil, fmt.Errorf("buffer %d (%s): %w", index, buf.URI, errNoResolver)
+	default:
+		data, err = l.resolver(buf.URI)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load buffer %d: %w", index, err)
+	}
+
+	if buf.ByteLength < 0 {
+		return nil, fmt.Errorf("buffer %d has a negative length", index)
+	}
+	if len(data) < buf.ByteLength {
+		return nil, fmt.Errorf("buffer %d is %d bytes, expected %d", index, len(data), buf.ByteLength)
+	}
+	data = data[:buf.ByteLength]
+
+	l.buffers[index] = data
+	return data, nil
+}
+
+// bufferView returns the bytes of a buffer view and its stride
+func (l *gltfLoader) bufferView(index int) ([]byte, int, error) {
+	if index < 0 || index >= len(l.doc.BufferViews) {
+		return nil, 0, fmt.Errorf("buffer view index out of range: %d", index)
+	}
+
+	view := l.doc.BufferViews[index]
+	buf, err := l.buffer(view.Buffer)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if view.ByteOffset < 0 || view.ByteLength < 0 || view.ByteStride < 0 {
+		return nil, 0, fmt.Errorf("buffer view %d has a negative offset, length or stride", index)
+	}
+	if view.ByteOffset > len(buf) || view.ByteLength > len(buf)-view.ByteOffset {
+		return nil, 0, fmt.Errorf("buffer view %d exceeds buffer %d", index, view.Buffer)
+	}
+
+	return buf[view.ByteOffset : view.ByteOffset+view.ByteLength], view.ByteStride, nil
+}
+
+// readAccessor decodes an accessor, including sparse substitution and
+// normalization of integer components
+func (l *gltfLoader) readAccessor(index int) (*gltfAccessorData, error) {
+	if index < 0 || index >= len(l.doc.Accessors) {
+		return nil, fmt.Errorf("accessor index out of range: %d", index)
+	}
+
+	acc := l.doc.Accessors[index]
+	components, ok := gltfTypeComponents[acc.Type]
+	if !ok {
+		return nil, fmt.Errorf("accessor %d has unknown type %q", index, acc.Type)
+	}
+	componentSize, ok := gltfComponentSizes[acc.ComponentType]
+	if !ok {
+		return nil, fmt.Errorf("accessor %d has unknown component type %d", index, acc.ComponentType)
+	}
+	if acc.Count < 0 || acc.ByteOffset < 0 {
+		return nil, fmt.Errorf("accessor %d has a negative count or offset", index)
+	}
+
+	elementSize := components * componentSize
+	result := &gltfAccessorData{
+		count:      acc.Count,
+		components: components,
+	}
+
+	// Accessors without a buffer view are initialized with zeros
+	if acc.BufferView == nil {
+		if acc.Count > gltfMaxZeroFilledValues/components {
+			return nil, fmt.Errorf("accessor %d has too many elements without a buffer view: %d", index, acc.Count)
+		}
+		result.values = make([]float64, acc.Count*components)
+	} else {
+		data, stride, err := l.bufferView(*acc.BufferView)
+		if err != nil {
+			return nil, fmt.Errorf("accessor %d: %w", index, err)
+		}
+		if stride == 0 {
+			stride = elementSize
+		}
+
+		// The bounds are checked before multiplying so that huge counts
+		// cannot overflow
+		if acc.Count > 0 {
+			available := len(data) - acc.ByteOffset - elementSize
+			if acc.ByteOffset > len(data) || available < 0 || acc.Count-1 > available/stride {
+				return nil, fmt.Errorf("accessor %d exceeds its buffer view", index)
+			}
+		}
+		result.values = make([]float64, acc.Count*components)
+
+		for i := 0; i < acc.Count; i++ {
+			offset := acc.ByteOffset + i*stride
+			for c := 0; c < components; c++ {
+				result.values[i*components+c] = readGLTFComponent(data[offset+c*componentSize:], acc.ComponentType, acc.Normalized)
+			}
+		}
+	}
+
+	if acc.Sparse != nil {
+		if err := l.applySparse(index, acc, result, componentSize); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// applySparse overwrites the elements listed in a sparse accessor
+func (l *gltfLoader) applySparse(index int, acc gltfAccessor, result *gltfAccessorData, componentSize int) error {
+	sparse := acc.Sparse
+	elementSize := result.components * componentSize
+
+	indexSize, ok := gltfComponentSizes[sparse.Indices.ComponentType]
+	if !ok || sparse.Indices.ComponentType == gltfFloat || sparse.Indices.ComponentType == gltfByte || sparse.Indices.ComponentType == gltfShort {
+		return fmt.Errorf("accessor %d has invalid sparse index type %d", index, sparse.Indices.ComponentType)
+	}
+
+	indexData, _, err := l.bufferView(sparse.Indices.BufferView)
+	if err != nil {
+		return fmt.Errorf("accessor %d sparse indices: %w", index, err)
+	}
+	valueData, _, err := l.bufferView(sparse.Values.BufferView)
+	if err != nil {
+		return fmt.Errorf("accessor %d sparse values: %w", index, err)
+	}
+
+	if sparse.Count < 0 || sparse.Indices.ByteOffset < 0 || sparse.Values.ByteOffset < 0 {
+		return fmt.Errorf("accessor %d has a negative sparse count or offset", index)
+	}
+	if sparse.Indices.ByteOffset > len(indexData) || sparse.Count > (len(indexData)-sparse.Indices.ByteOffset)/indexSize {
+		return fmt.Errorf("accessor %d sparse indices exceed their buffer view", index)
+	}
+	if sparse.Values.ByteOffset > len(valueData) || sparse.Count > (len(valueData)-sparse.Values.ByteOffset)/elementSize {
+		return fmt.Errorf("accessor %d sparse values exceed their buffer view", index)
+	}
+
+	for k := 0; k < sparse.Count; k++ {
+		target := int(readGLTFComponent(indexData[sparse.Indices.ByteOffset+k*indexSize:], sparse.Indices.ComponentType, false))
+		if target >= result.count {
+			return fmt.Errorf("accessor %d sparse index out of range: %d", index, target)
+		}
+
+		offset := sparse.Values.ByteOffset + k*elementSize
+		for c := 0; c < result.components; c++ {
+			result.values[target*result.components+c] = readGLTFComponent(valueData[offset+c*componentSize:], acc.ComponentType, acc.Normalized)
+		}
+	}
+
+	return nil
+}
+
+// loadGeometry flattens the default scene into world-space vertices and a
+// triangle index buffer, with a group for every primitive drawn
+func (l *gltfLoader) loadGeometry() ([]Vertex, []uint32, []MeshGroup, error) {
+	var vertices []Vertex
+	var indices []uint32
+	var groups []MeshGroup
+	materials := l.materialNames()
+
+	// Nodes may be the child of several parents, so a small document can
+	// instance a mesh exponentially often
+	instances := 0
+	visit := func(meshIndex int, world Matrix4) error {
+		if meshIndex < 0 || meshIndex >= len(l.doc.Meshes) {
+			return fmt.Errorf("mesh index out of range: %d", meshIndex)
+		}
+		instances++
+		if err := checkInstances(instances, len(indices)/3); err != nil {
+			return err
+		}
+		mesh := l.doc.Meshes[meshIndex]
+		for p, prim := range mesh.Primitives {
+			group := MeshGroup{Object: mesh.Name, Start: len(indices)}
+			if prim.Material != nil {
+				if *prim.Material < 0 || *prim.Material >= len(materials) {
+					return fmt.Errorf("mesh %d primitive %d: material index out of range: %d", meshIndex, p, *prim.Material)
+				}
+				group.Material = materials[*prim.Material]
+			}
+
+			var err error
+			vertices, indices, err = l.appendPrimitive(vertices, indices, prim, world)
+			if err != nil {
+				return fmt.Errorf("mesh %d primitive %d: %w", meshIndex, p, err)
+			}
+			if group.Count = len(indices) - group.Start; group.Count > 0 {
+				group.Faces = group.Count / 3
+				groups = append(groups, group)
+			}
+		}
+		return nil
+	}
+
+	roots, err := l.rootNodes()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Documents without nodes still describe meshes worth importing
+	if roots == nil {
+		for i := range l.doc.Meshes {
+			if err := visit(i, IdentityMatrix()); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+		return vertices, indices, groups, nil
+	}
+
+	onPath := make(map[int]bool)
+	visits := 0
+	var walk func(nodeIndex int, parent Matrix4) error
+	walk = func(nodeIndex int, parent Matrix4) error {
+		if nodeIndex < 0 || nodeIndex >= len(l.doc.Nodes) {
+			return fmt.Errorf("node index out of range: %d", nodeIndex)
+		}
+		if visits++; visits > gltfMaxNodeVisits {
+			return fmt.Errorf("node hierarchy visits more than %d nodes", gltfMaxNodeVisits)
+		}
+		if onPath[nodeIndex] {
+			return fmt.Errorf("node hierarchy contains a cycle at node %d", nodeIndex)
+		}
+		onPath[nodeIndex] = true
+		defer delete(onPath, nodeIndex)
+
+		node := l.doc.Nodes[nodeIndex]
+		local, err := node.localMatrix()
+		if err != nil {
+			return fmt.Errorf("node %d: %w", nodeIndex, err)
+		}
+		world := parent.Mul(local)
+
+		if node.Mesh != nil {
+			if err := visit(*node.Mesh, world); err != nil {
+				return err
+			}
+		}
+		for _, child := range node.Children {
+			if err := walk(child, world); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, root := range roots {
+		if err := walk(root, IdentityMatrix()); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	return vertices, indices, groups, nil
+}
+
+// rootNodes returns the root nodes of the default scene, or of all node
+// trees if the document has no scenes. It returns nil without nodes.
+func (l *gltfLoader) rootNodes() ([]int, error) {
+	if len(l.doc.Scenes) > 0 {
+		scene := 0
+		if l.doc.Scene != nil {
+			scene = *l.doc.Scene
+		}
+		if scene < 0 || scene >= len(l.doc.Scenes) {
+			return nil, fmt.Errorf("scene index out of range: %d", scene)
+		}
+		return append([]int{}, l.doc.Scenes[scene].Nodes...), nil
+	}
+
+	if len(l.doc.Nodes) == 0 {
+		return nil, nil
+	}
+
+	isChild := make(map[int]bool)
+	for _, node := range l.doc.Nodes {
+		for _, child := range node.Children {
+			isChild[child] = true
+		}
+	}
+
+	roots := make([]int, 0)
+	for i := range l.doc.Nodes {
+		if !isChild[i] {
+			roots = append(roots, i)
+		}
+	}
+	return roots, nil
+}
+
+// localMatrix returns the node's transform relative to its parent
+func (n *gltfNode) localMatrix() (Matrix4, error) {
+	if n.Matrix != nil {
+		if len(n.Matrix) != 16 {
+			return Matrix4{}, errors.New("matrix must have 16 elements")
+		}
+		var m Matrix4
+		copy(m[:], n.Matrix)
+		return m, nil
+	}
+
+	t := [3]float64{0, 0, 0}
+	r := [4]float64{0, 0, 0, 1}
+	s := [3]float64{1, 1, 1}
+	if n.Translation != nil {
+		t = *n.Translation
+	}
+	if n.Rotation != nil {
+		r = *n.Rotation
+	}
+	if n.Scale != nil {
+		s = *n.Scale
+	}
+
+	return ComposeMatrix(t, r, s), nil
+}
+
+// appendPrimitive transforms a primitive into world space and appends its
+// vertices and triangle indices
+func (l *gltfLoader) appendPrimitive(vertices []Vertex, indices []uint32, prim gltfPrimitive, world Matrix4) ([]Vertex, []uint32, error) {
+	mode := gltfModeTriangles
+	if prim.Mode != nil {
+		mode = *prim.Mode
+	}
+
+	posIndex, ok := prim.Attributes["POSITION"]
+	if !ok {
+		return nil, nil, errors.New("primitive has no POSITION attribute")
+	}
+	positions, err := l.readAttribute(posIndex, 3, -1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("POSITION: %w", err)
+	}
+
+	var normals, texCoords, colors, tangents *gltfAccessorData
+	if index, ok := prim.Attributes["NORMAL"]; ok {
+		if normals, err = l.readAttribute(index, 3, positions.count); err != nil {
+			return nil, nil, fmt.Errorf("NORMAL: %w", err)
+		}
+	}
+	if index, ok := prim.Attributes["TEXCOORD_0"]; ok {
+		if texCoords, err = l.readAttribute(index, 2, positions.count); err != nil {
+			return nil, nil, fmt.Errorf("TEXCOORD_0: %w", err)
+		}
+	}
+	if index, ok := prim.Attributes["COLOR_0"]; ok {
+		// Colors are RGB or RGBA
+		if colors, err = l.readAttribute(index, 4, positions.count); err != nil {
+			if colors, err = l.readAttribute(index, 3, positions.count); err != nil {
+				return nil, nil, fmt.Errorf("COLOR_0: %w", err)
+			}
+		}
+	}
+	if index, ok := prim.Attributes["TANGENT"]; ok {
+		if tangents, err = l.readAttribute(index, 4, positions.count); err != nil {
+			return nil, nil, fmt.Errorf("TANGENT: %w", err)
+		}
+	}
+
+	// Mirroring transforms flip the winding order and the bitangent
+	flip := world.determinant3() < 0
+
+	base := len(vertices)
+	if base+positions.count > math.MaxUint32 {
+		return nil, nil, errors.New("too many vertices")
+	}
+
+	for i := 0; i < positions.count; i++ {
+		p := positions.element(i)
+		vertex := Vertex{
+			Position: world.TransformPoint([3]float64{p[0], p[1], p[2]}),
+		}
+		if normals != nil {
+			n := normals.element(i)
+			vertex.Normal = world.TransformNormal([3]float64{n[0], n[1], n[2]})
+		}
+		if texCoords != nil {
+			// glTF places the texture origin at the top left, OBJ at the bottom left
+			uv := texCoords.element(i)
+			vertex.TexCoords = [2]float64{uv[0], 1 - uv[1]}
+		}
+		if colors != nil {
+			c := colors.element(i)
+			vertex.Color = [4]float64{c[0], c[1], c[2], 1}
+			if colors.components == 4 {
+				vertex.Color[3] = c[3]
+			}
+			vertex.HasColor = true
+		}
+		if tangents != nil {
+			t := tangents.element(i)
+			direction := world.TransformPoint([3]float64{t[0], t[1], t[2]})
+			direction = normalize3(sub3(direction, world.TransformPoint([3]float64{})))
+			sign := t[3]
+			if flip {
+				sign = -sign
+			}
+			vertex.Tangent = [4]float64{direction[0], direction[1], direction[2], sign}
+			vertex.HasTangent = true
+		}
+		vertices = append(vertices, vertex)
+	}
+
+	// Read or synthesize the primitive's vertex order
+	var order []uint32
+	if prim.Indices != nil {
+		data, err := l.readAccessor(*prim.Indices)
+		if err != nil {
+			return nil, nil, fmt.Errorf("indices: %w", err)
+		}
+		if data.components != 1 {
+			return nil, nil, errors.New("indices accessor must be SCALAR")
+		}
+		order = make([]uint32, data.count)
+		for i, v := range data.values {
+			if v < 0 || int(v) >= positions.count {
+				return nil, nil, fmt.Errorf("index out of bounds: %d (max: %d)", int(v), positions.count)
+			}
+			order[i] = uint32(v)
+		}
+	} else {
+		order = make([]uint32, positions.count)
+		for i := range order {
+			order[i] = uint32(i)
+		}
+	}
+
+	triangles, err := gltfTriangles(order, mode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := 0; i+2 < len(triangles); i += 3 {
+		a, b, c := triangles[i], triangles[i+1], triangles[i+2]
+		if flip {
+			b, c = c, b
+		}
+		indices = append(indices, uint32(base)+a, uint32(base)+b, uint32(base)+c)
+	}
+
+	return vertices, indices, nil
+}
+
+// readAttribute reads a vertex attribute accessor and checks its layout.
+// A negative count skips the element count check.
+func (l *gltfLoader) readAttribute(index, components, count int) (*gltfAccessorData, error) {
+	data, err := l.readAccessor(index)
+	if err != nil {
+		return nil, err
+	}
+	if data.components != components {
+		return nil, fmt.Errorf("expected %d components, got %d", components, data.components)
+	}
+	if count >= 0 && data.count != count {
+		return nil, fmt.Errorf("expected %d elements, got %d", count, data.count)
+	}
+	return data, nil
+}
+
+// gltfTriangles converts a primitive's vertex order into a triangle list
+func gltfTriangles(order []uint32, mode int) ([]uint32, error) {
+	switch mode {
+	case gltfModeTriangles:
+		if len(order)%3 != 0 {
+			return nil, fmt.Errorf("triangle list has %d indices, not a multiple of 3", len(order))
+		}
+		return order, nil
+
+	case gltfModeTriangleStrip:
+		var triangles []uint32
+		for i := 0; i+2 < len(order); i++ {
+			// Every other triangle in a strip has reversed winding
+			if i%2 == 0 {
+				triangles = append(triangles, order[i], order[i+1], order[i+2])
+			} else {
+				triangles = append(triangles, order[i+1], order[i], order[i+2])
+			}
+		}
+		return triangles, nil
+
+	case gltfModeTriangleFan:
+		var triangles []uint32
+		for i := 1; i+1 < len(order); i++ {
+			triangles = append(triangles, order[0], order[i], order[i+1])
+		}
+		return triangles, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported primitive mode: %d", mode)
+	}
+}
+
+// determinant3 returns the determinant of the upper 3x3 part of m
+func (m Matrix4) determinant3() float64 {
+	return m[0]*(m[5]*m[10]-m[9]*m[6]) -
+		m[4]*(m[1]*m[10]-m[9]*m[2]) +
+		m[8]*(m[1]*m[6]-m[5]*m[2])
+}
+
+// loadMaterials converts glTF materials into importer materials
+func (l *gltfLoader) loadMaterials() ([]*Material, error) {
+	materials := make([]*Material, 0, len(l.doc.Materials))
+	names := l.materialNames()
+
+	for i, m := range l.doc.Materials {
+		pbr := &PBRMaterial{
+			BaseColorFactor:   [4]float64{1, 1, 1, 1},
+			MetallicFactor:    1,
+			RoughnessFactor:   1,
+			NormalScale:       1,
+			OcclusionStrength: 1,
+			AlphaMode:         "OPAQUE",
+			AlphaCutoff:       0.5,
+			DoubleSided:       m.DoubleSided,
+		}
+
+		var err error
+		texture := func(info *gltfTextureInfo) string {
+			if info == nil || err != nil {
+				return ""
+			}
+			var name string
+			name, err = l.textureName(info.Index)
+			return name
+		}
+
+		if m.PBRMetallicRoughness != nil {
+			if m.PBRMetallicRoughness.BaseColorFactor != nil {
+				pbr.BaseColorFactor = *m.PBRMetallicRoughness.BaseColorFactor
+			}
+			if m.PBRMetallicRoughness.MetallicFactor != nil {
+				pbr.MetallicFactor = *m.PBRMetallicRoughness.MetallicFactor
+			}
+			if m.PBRMetallicRoughness.RoughnessFactor != nil {
+				pbr.RoughnessFactor = *m.PBRMetallicRoughness.RoughnessFactor
+			}
+			pbr.BaseColorTexture = texture(m.PBRMetallicRoughness.BaseColorTexture)
+			pbr.MetallicRoughnessTexture = texture(m.PBRMetallicRoughness.MetallicRoughnessTexture)
+		}
+
+		pbr.NormalTexture = texture(m.NormalTexture)
+		if m.NormalTexture != nil && m.NormalTexture.Scale != nil {
+			pbr.NormalScale = *m.NormalTexture.Scale
+		}
+		pbr.OcclusionTexture = texture(m.OcclusionTexture)
+		if m.OcclusionTexture != nil && m.OcclusionTexture.Strength != nil {
+			pbr.OcclusionStrength = *m.OcclusionTexture.Strength
+		}
+		pbr.EmissiveTexture = texture(m.EmissiveTexture)
+		if err != nil {
+			return nil, fmt.Errorf("material %d: %w", i, err)
+		}
+
+		if m.EmissiveFactor != nil {
+			pbr.EmissiveFactor = *m.EmissiveFactor
+		}
+		if m.AlphaMode != "" {
+			pbr.AlphaMode = m.AlphaMode
+		}
+		if m.AlphaCutoff != nil {
+			pbr.AlphaCutoff = *m.AlphaCutoff
+		}
+
+		materials = append(materials, pbr.ToPhong(names[i]))
+	}
+
+	return materials, nil
+}
+
+// materialNames returns the importer name of every material. Material
+// names are not unique in glTF, but are keys for importers.
+func (l *gltfLoader) materialNames() []string {
+	names := make([]string, len(l.doc.Materials))
+	used := make(map[string]bool)
+	for i, m := range l.doc.Materials {
+		name := m.Name
+		if name == "" || used[name] {
+			name = fmt.Sprintf("material_%d", i)
+		}
+		used[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+// textureName returns the URI or name of the image behind a texture
+func (l *gltfLoader) textureName(index int) (string, error) {
+	if index < 0 || index >= len(l.doc.Textures) {
+		return "", fmt.Errorf("texture index out of range: %d", index)
+	}
+
+	source := l.doc.Textures[index].Source
+	if source == nil {
+		return "", nil
+	}
+	if *source < 0 || *source >= len(l.doc.Images) {
+		return "", fmt.Errorf("image index out of range: %d", *source)
+	}
+
+	image := l.doc.Images[*source]
+	switch {
+	case image.URI != "" && !strings.HasPrefix(image.URI, "data:"):
+		return image.URI, nil
+	case image.Name != "":
+		return image.Name, nil
+	default:
+		return fmt.Sprintf("image_%d", *source), nil
+	}
+}
+
+var gltfTypeComponents = map[string]int{
+	"SCALAR": 1,
+	"VEC2":   2,
+	"VEC3":   3,
+	"VEC4":   4,
+	"MAT2":   4,
+	"MAT3":   9,
+	"MAT4":   16,
+}
+
+var gltfComponentSizes = map[int]int{
+	gltfByte:          1,
+	gltfUnsignedByte:  1,
+	gltfShort:         2,
+	gltfUnsignedShort: 2,
+	gltfUnsignedInt:   4,
+	gltfFloat:         4,
+}
+
+// readGLTFComponent decodes a single little-endian component, mapping
+// normalized integers to [0, 1] or [-1, 1]
+func readGLTFComponent(data []byte, componentType int, normalized bool) float64 {
+	switch componentType {
+	case gltfByte:
+		v := float64(int8(data[0]))
+		if normalized {
+			return math.Max(v/127, -1)
+		}
+		return v
+	case gltfUnsignedByte:
+		v := float64(data[0])
+		if normalized {
+			return v / 255
+		}
+		return v
+	case gltfShort:
+		v := float64(int16(binary.LittleEndian.Uint16(data)))
+		if normalized {
+			return math.Max(v/32767, -1)
+		}
+		return v
+	case gltfUnsignedShort:
+		v := float64(binary.LittleEndian.Uint16(data))
+		if normalized {
+			return v / 65535
+		}
+		return v
+	case gltfUnsignedInt:
+		return float64(binary.LittleEndian.Uint32(data))
+	default:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(data)))
+	}
+}
+
+// decodeDataURI decodes a base64 data URI as used for embedded buffers
+func decodeDataURI(uri string) ([]byte, error) {
+	header, payload, ok := strings.Cut(uri, ",")
+	if !ok || !strings.HasSuffix(header, ";base64") {
+		return nil, errors.New("only base64 data URIs are supported")
+	}
+	return base64.StdEncoding.DecodeString(payload)
+}
+
+// ImportFromGLTF imports vertices and triangle indices from glTF 2.0 JSON.
+// The resolver loads external buffers and may be nil for self-contained
+// files.
+func (vi *VertexImporter) ImportFromGLTF(reader io.Reader, resolver ResourceResolver) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read glTF file: %w", err)
+	}
+
+	loader, err := newGLTFLoader(data, nil, resolver)
+	if err != nil {
+		return err
+	}
+
+	return vi.importGLTF(loader)
+}
+
+func (vi *VertexImporter) importGLTF(loader *gltfLoader) error {
+	vertices, indices, groups, err := loader.loadGeometry()
+	if err != nil {
+		return fmt.Errorf("failed to load glTF geometry: %w", err)
+	}
+
+	vi.appendGroups(groups, len(vi.indices))
+	vi.appendIndexed(vertices, indices)
+	return nil
+}
+
+// ImportFromGLTF imports materials from glTF 2.0 JSON
+func (mi *MaterialImporter) ImportFromGLTF(reader io.Reader, resolver ResourceResolver) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read glTF file: %w", err)
+	}
+
+	loader, err := newGLTFLoader(data, nil, resolver)
+	if err != nil {
+		return err
+	}
+
+	return mi.importGLTF(loader)
+}
+
+func (mi *MaterialImporter) importGLTF(loader *gltfLoader) error {
+	materials, err := loader.loadMaterials()
+	if err != nil {
+		return fmt.Errorf("failed to load glTF materials: %w", err)
+	}
+
+	for _, mat := range materials {
+		mi.materials[mat.Name] = mat
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package importers
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrUnsupportedFormat is returned by Import for unknown format names
+var ErrUnsupportedFormat = errors.New("unsupported format")
+
+// ImportOptions selects optional processing applied by Import
+type ImportOptions struct {
+	// GenerateNormals fills in missing vertex normals as configured by
+	// Normals
+	GenerateNormals bool
+	Normals         NormalOptions
+	// GenerateTangents computes vertex tangents. Missing normals are
+	// generated first since tangents are built around them.
+	GenerateTangents bool
+	// Resolver loads the external buffers and images of glTF files. Without
+	// one, only embedded resources can be used.
+	Resolver ResourceResolver
+}
+
+// Import imports a model in the named format ("obj", "fbx", "gltf", "glb",
+// "stl", "ply", "3mf" or "dae") and applies the processing selected by
+// opts
+func (vi *VertexImporter) Import(format string, reader io.Reader, opts ImportOptions) error {
+	var err error
+	switch format {
+	case "obj":
+		err = vi.ImportFromOBJ(reader)
+	case "fbx":
+		err = vi.ImportFromFBX(reader)
+	case "gltf":
+		err = vi.ImportFromGLTF(reader, opts.Resolver)
+	case "glb":
+		err = vi.ImportFromGLB(reader, opts.Resolver)
+	case "stl":
+		err = vi.ImportFromSTL(reader)
+	case "ply":
+		err = vi.ImportFromPLY(reader)
+	case "3mf":
+		err = vi.ImportFrom3MF(reader)
+	case "dae":
+		err = vi.ImportFromCOLLADA(reader)
+	default:
+		return ErrUnsupportedFormat
+	}
+	if err != nil {
+		return err
+	}
+
+	if opts.GenerateNormals || opts.GenerateTangents {
+		vi.GenerateNormals(opts.Normals)
+	}
+	if opts.GenerateTangents {
+		vi.GenerateTangents()
+	}
+	return nil
+}
+
+// GenerateNormals fills in missing normals of the imported mesh. Unless
+// opts sets its own, the smoothing groups of an imported OBJ file are
+// respected.
+func (vi *VertexImporter) GenerateNormals(opts NormalOptions) {
+	if opts.SmoothingGroups == nil && vi.smoothing {
+		opts.SmoothingGroups = vi.smoothingGroups()
+	}
+	mesh := vi.GetMesh()
+	mesh.GenerateNormals(opts)
+	vi.setMesh(mesh)
+}
+
+// GenerateTangents computes tangents for the imported mesh
+func (vi *VertexImporter) GenerateTangents() {
+	mesh := vi.GetMesh()
+	mesh.GenerateTangents()
+	vi.setMesh(mesh)
+}
+
+// setMesh replaces the imported data with a processed mesh
+func (vi *VertexImporter) setMesh(mesh *Mesh) {
+	vi.vertices = mesh.Vertices
+	vi.indices = mesh.Indices
+	vi.polygons = mesh.Polygons
+}
+
+// smoothingGroups returns the smoothing group of every triangle from the
+// OBJ face ranges. Triangles outside any range are smoothed together.
+func (vi *VertexImporter) smoothingGroups() []int {
+	groups := make([]int, len(vi.indices)/3)
+	for i := range groups {
+		groups[i] = 1
+	}
+	for _, group := range vi.groups {
+		for t := group.Start / 3; t < (group.Start+group.Count)/3; t++ {
+			groups[t] = group.SmoothingGroup
+		}
+	}
+	return groups
+}
+
+// Import imports the materials embedded in a model of the named format.
+// Formats without embedded materials, including OBJ whose materials live
+// in separate MTL files, import nothing. The resolver is used for external
+// glTF resources and may be nil.
+func (mi *MaterialImporter) Import(format string, reader io.Reader, resolver ResourceResolver) error {
+	switch format {
+	case "fbx":
+		return mi.ImportFromFBX(reader)
+	case "gltf":
+		return mi.ImportFromGLTF(reader, resolver)
+	case "glb":
+		return mi.ImportFromGLB(reader, resolver)
+	case "3mf":
+		return mi.ImportFrom3MF(reader)
+	case "dae":
+		return mi.ImportFromCOLLADA(reader)
+	case "obj", "stl", "ply":
+		return nil
+	default:
+		return ErrUnsupportedFormat
+	}
+}
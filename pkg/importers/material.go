@@ -2,11 +2,9 @@ package importers
 
 import (
 	"bufio"
-	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
-	"math"
 	"strconv"
 	"strings"
 )
@@ -17,11 +15,37 @@ type Material struct {
 	AmbientColor  [3]float64
 	DiffuseColor  [3]float64
 	SpecularColor [3]float64
+	EmissiveColor [3]float64
 	Shininess     float64
 	DiffuseMap    string
 	NormalMap     string
 	SpecularMap   string
+	EmissiveMap   string
 	Transparency  float64
+	PBR           *PBRMaterial
+}
+
+// PBRMaterial holds metallic-roughness material parameters as defined by
+// glTF 2.0. Texture fields hold image URIs or names. glTF packs metallic
+// and roughness into MetallicRoughnessTexture, while MTL files reference
+// separate MetallicTexture and RoughnessTexture maps.
+type PBRMaterial struct {
+	BaseColorFactor          [4]float64
+	BaseColorTexture         string
+	MetallicFactor           float64
+	RoughnessFactor          float64
+	MetallicRoughnessTexture string
+	MetallicTexture          string
+	RoughnessTexture         string
+	NormalTexture            string
+	NormalScale              float64
+	OcclusionTexture         string
+	OcclusionStrength        float64
+	EmissiveFactor           [3]float64
+	EmissiveTexture          string
+	AlphaMode                string
+	AlphaCutoff              float64
+	DoubleSided              bool
 }
 
 // MaterialImporter handles importing materials from different 3D model formats
@@ -36,10 +60,28 @@ func NewMaterialImporter() *MaterialImporter {
 	}
 }
 
-// ImportFromOBJ imports materials from MTL format (OBJ materials)
+// ImportFromOBJ imports materials from MTL format (OBJ materials). The PBR
+// extensions Pr, Pm, map_Pr and map_Pm give a material PBR parameters,
+// completed from its Phong fields once the file is read.
 func (mi *MaterialImporter) ImportFromOBJ(reader io.Reader) error {
 	scanner := bufio.NewScanner(reader)
 	var currentMaterial *Material
+	var pbrMaterials []*Material
+	roughnessSet := make(map[*Material]bool)
+
+	// pbr returns the PBR parameters of the current material, creating
+	// them on the first PBR statement
+	pbr := func() *PBRMaterial {
+		if currentMaterial.PBR == nil {
+			currentMaterial.PBR = &PBRMaterial{
+				NormalScale:       1,
+				OcclusionStrength: 1,
+				AlphaCutoff:       0.5,
+			}
+			pbrMaterials = append(pbrMaterials, currentMaterial)
+		}
+		return currentMaterial.PBR
+	}
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -128,13 +170,81 @@ func (mi *MaterialImporter) ImportFromOBJ(reader io.Reader) error {
 				return errors.New("specular map specified before material")
 			}
 			currentMaterial.SpecularMap = strings.Join(fields[1:], " ")
+
+		case "Ke":
+			if currentMaterial == nil {
+				return errors.New("emissive color specified before material")
+			}
+			color, err := ParseVector3(fields[1:])
+			if err != nil {
+				return fmt.Errorf("invalid emissive color: %w", err)
+			}
+			currentMaterial.EmissiveColor = color
+
+		case "map_Ke":
+			if currentMaterial == nil {
+				return errors.New("emissive map specified before material")
+			}
+			currentMaterial.EmissiveMap = strings.Join(fields[1:], " ")
+
+		case "Pr", "Pm":
+			if currentMaterial == nil {
+				return errors.New("PBR parameter specified before material")
+			}
+			value, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s value: %w", fields[0], err)
+			}
+			if fields[0] == "Pr" {
+				pbr().RoughnessFactor = value
+				roughnessSet[currentMaterial] = true
+			} else {
+				pbr().MetallicFactor = value
+			}
+
+		case "map_Pr", "map_Pm":
+			if currentMaterial == nil {
+				return errors.New("PBR map specified before material")
+			}
+			if fields[0] == "map_Pr" {
+				pbr().RoughnessTexture = strings.Join(fields[1:], " ")
+			} else {
+				pbr().MetallicTexture = strings.Join(fields[1:], " ")
+			}
+
+		case "norm":
+			if currentMaterial == nil {
+				return errors.New("normal map specified before material")
+			}
+			currentMaterial.NormalMap = strings.Join(fields[1:], " ")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	// Complete the PBR parameters from the Phong fields
+	for _, material := range pbrMaterials {
+		phong := *material
+		phong.PBR = nil
+		converted := phong.ToPBR()
+
+		p := material.PBR
+		p.BaseColorFactor = converted.BaseColorFactor
+		p.BaseColorTexture = converted.BaseColorTexture
+		p.NormalTexture = converted.NormalTexture
+		p.EmissiveFactor = converted.EmissiveFactor
+		p.EmissiveTexture = converted.EmissiveTexture
+		p.AlphaMode = converted.AlphaMode
+		if !roughnessSet[material] {
+			p.RoughnessFactor = converted.RoughnessFactor
 		}
 	}
 
-	return scanner.Err()
+	return nil
 }
 
-// ImportFromFBX imports materials from FBX format
+// ImportFromFBX imports materials from binary or ASCII FBX format
 func (mi *MaterialImporter) ImportFromFBX(reader io.Reader) error {
 	// Read the entire FBX file
 	data, err := io.ReadAll(reader)
@@ -142,15 +252,70 @@ func (mi *MaterialImporter) ImportFromFBX(reader io.Reader) error {
 		return fmt.Errorf("failed to read FBX file: %w", err)
 	}
 
-	// Parse FBX binary format
-	materials, err := parseFBXMaterials(data)
+	doc, err := ParseFBX(data)
 	if err != nil {
-		return fmt.Errorf("failed to parse FBX materials: %w", err)
+		return fmt.Errorf("failed to parse FBX: %w", err)
 	}
 
-	// Store the materials
-	for name, mat := range materials {
-		mi.materials[name] = mat
+	return mi.importFBX(doc)
+}
+
+// importFBX imports the materials of a parsed FBX document and attaches
+// the textures connected to them
+func (mi *MaterialImporter) importFBX(doc *FBXDocument) error {
+	objects := doc.Node("Objects")
+
+	byID := make(map[int64]*Material)
+	for _, node := range objects.ChildrenNamed("Material") {
+		material := parseFBXMaterial(node)
+		mi.materials[material.Name] = material
+		if id, ok := fbxNodeID(node); ok {
+			byID[id] = material
+		}
+	}
+
+	textures := make(map[int64]string)
+	for _, node := range objects.ChildrenNamed("Texture") {
+		id, ok := fbxNodeID(node)
+		if !ok {
+			continue
+		}
+		for _, field := range []string{"RelativeFilename", "FileName"} {
+			if prop, ok := node.Child(field).Property(0); ok {
+				if filename, _ := prop.String(); filename != "" {
+					textures[id] = filename
+					break
+				}
+			}
+		}
+	}
+
+	// Textures are linked to material properties by "OP" connections
+	for _, connection := range doc.Node("Connections").ChildrenNamed("C") {
+		if len(connection.Properties) < 4 {
+			continue
+		}
+		if kind, _ := connection.Properties[0].String(); kind != "OP" {
+			continue
+		}
+		child, _ := connection.Properties[1].Int64()
+		parent, _ := connection.Properties[2].Int64()
+		property, _ := connection.Properties[3].String()
+
+		texture, ok := textures[child]
+		material := byID[parent]
+		if !ok || material == nil {
+			continue
+		}
+
+		switch property {
+		case "DiffuseColor", "Diffuse":
+			material.DiffuseMap = texture
+		case "NormalMap", "Bump":
+			material.NormalMap = texture
+		case "SpecularColor", "SpecularFactor":
+			material.SpecularMap = texture
+		}
 	}
 
 	return nil
@@ -167,114 +332,69 @@ func (mi *MaterialImporter) GetMaterials() map[string]*Material {
 	return mi.materials
 }
 
-// parseFBXMaterials extracts materials from FBX binary data
-func parseFBXMaterials(data []byte) (map[string]*Material, error) {
-	// Check FBX magic number and version
-	magic := []byte("Kaydara FBX Binary  ")
-	if len(data) < len(magic) || string(data[:len(magic)]) != string(magic) {
-		return nil, errors.New("invalid FBX binary format")
-	}
+// parseFBXMaterial reads a Material node's Properties70 (or legacy
+// Properties60) block. FBX materials are opaque unless stated otherwise.
+func parseFBXMaterial(node *FBXNode) *Material {
+	material := &Material{Name: fbxNodeName(node), Transparency: 1.0}
 
-	// Parse FBX version (located at offset 23)
-	version := binary.LittleEndian.Uint32(data[23:27])
-	if version < 7100 {
-		return nil, fmt.Errorf("unsupported FBX version: %d", version)
+	properties := node.Child("Properties70").ChildrenNamed("P")
+	if properties == nil {
+		properties = node.Child("Properties60").ChildrenNamed("Property")
 	}
 
-	materials := make(map[string]*Material)
-	offset := uint32(27) // Start after header and version
-
-	for offset < uint32(len(data)) {
-		// Check if we have enough data left to read a node header
-		if offset+4 > uint32(len(data)) {
-			break
-		}
-
-		// Read node header
-		endOffset := binary.LittleEndian.Uint32(data[offset : offset+4])
-		if endOffset == 0 || endOffset > uint32(len(data)) {
-			break
-		}
-
-		// Skip header
-		offset += 13 // Standard FBX node header size
-
-		// Read node name length
-		nameLen := uint8(data[offset])
-		offset++
-
-		// Read node name
-		if offset+uint32(nameLen) > uint32(len(data)) {
-			break
+	hasOpacity := false
+	for _, p := range properties {
+		name, values := fbxPropertyValues(p)
+		if len(values) == 0 {
+			continue
 		}
-		nodeName := string(data[offset : offset+uint32(nameLen)])
-		offset += uint32(nameLen)
 
-		// Process material nodes
-		if nodeName == "Material" {
-			material, err := parseMaterialNode(data[offset:endOffset])
-			if err != nil {
-				return nil, err
+		switch name {
+		case "AmbientColor", "Ambient":
+			if len(values) >= 3 {
+				material.AmbientColor = [3]float64{values[0], values[1], values[2]}
+			}
+		case "DiffuseColor", "Diffuse":
+			if len(values) >= 3 {
+				material.DiffuseColor = [3]float64{values[0], values[1], values[2]}
+			}
+		case "SpecularColor", "Specular":
+			if len(values) >= 3 {
+				material.SpecularColor = [3]float64{values[0], values[1], values[2]}
+			}
+		case "EmissiveColor", "Emissive":
+			if len(values) >= 3 {
+				material.EmissiveColor = [3]float64{values[0], values[1], values[2]}
+			}
+		case "Shininess", "ShininessExponent":
+			material.Shininess = values[0]
+		case "Opacity":
+			material.Transparency = values[0]
+			hasOpacity = true
+		case "TransparencyFactor":
+			if !hasOpacity {
+				material.Transparency = 1.0 - values[0]
 			}
-			materials[material.Name] = material
 		}
-
-		// Move to next node
-		offset = endOffset
 	}
 
-	return materials, nil
+	return material
 }
 
-// parseMaterialNode parses a single material node from FBX data
-func parseMaterialNode(data []byte) (*Material, error) {
-	material := &Material{}
-	offset := uint32(0)
-
-	for offset < uint32(len(data)) {
-		// Read property header
-		if offset+4 > uint32(len(data)) {
-			break
-		}
-
-		propLen := binary.LittleEndian.Uint32(data[offset : offset+4])
-		offset += 4
-
-		// Read property name
-		nameLen := uint8(data[offset])
-		offset++
-
-		if offset+uint32(nameLen) > uint32(len(data)) {
-			break
-		}
-		propName := string(data[offset : offset+uint32(nameLen)])
-		offset += uint32(nameLen)
-
-		// Parse property value based on name
-		switch propName {
-		case "Name":
-			material.Name = string(data[offset : offset+propLen])
-		case "AmbientColor":
-			material.AmbientColor = parseColor(data[offset : offset+24])
-		case "DiffuseColor":
-			material.DiffuseColor = parseColor(data[offset : offset+24])
-		case "SpecularColor":
-			material.SpecularColor = parseColor(data[offset : offset+24])
-		case "Shininess":
-			material.Shininess = math.Float64frombits(binary.LittleEndian.Uint64(data[offset : offset+8]))
-		}
-
-		offset += propLen
+// fbxPropertyValues splits a P or Property node into its name and numeric
+// values, skipping the type and flag strings in between
+func fbxPropertyValues(p *FBXNode) (string, []float64) {
+	prop, ok := p.Property(0)
+	if !ok {
+		return "", nil
 	}
+	name, _ := prop.String()
 
-	return material, nil
-}
-
-// parseColor converts FBX color data to [3]float64
-func parseColor(data []byte) [3]float64 {
-	return [3]float64{
-		math.Float64frombits(binary.LittleEndian.Uint64(data[0:8])),
-		math.Float64frombits(binary.LittleEndian.Uint64(data[8:16])),
-		math.Float64frombits(binary.LittleEndian.Uint64(data[16:24])),
+	var values []float64
+	for _, prop := range p.Properties[1:] {
+		if value, ok := prop.Float64(); ok {
+			values = append(values, value)
+		}
 	}
+	return name, values
 }
@@ -0,0 +1,123 @@
+package importers
+
+import "math"
+
+// Matrix4 is a 4x4 transformation matrix stored in column-major order, as
+// used by glTF and COLLADA after transposition
+type Matrix4 [16]float64
+
+// IdentityMatrix returns the 4x4 identity matrix
+func IdentityMatrix() Matrix4 {
+	return Matrix4{
+		1, 0, 0, 0,
+		0, 1, 0, 0,
+		0, 0, 1, 0,
+		0, 0, 0, 1,
+	}
+}
+
+// Mul returns m * other
+func (m Matrix4) Mul(other Matrix4) Matrix4 {
+	var result Matrix4
+	for col := 0; col < 4; col++ {
+		for row := 0; row < 4; row++ {
+			var sum float64
+			for k := 0; k < 4; k++ {
+				sum += m[k*4+row] * other[col*4+k]
+			}
+			result[col*4+row] = sum
+		}
+	}
+	return result
+}
+
+// IsIdentity reports whether m is the identity matrix
+func (m Matrix4) IsIdentity() bool {
+	return m == IdentityMatrix()
+}
+
+// TransformPoint applies the full transformation to a position
+func (m Matrix4) TransformPoint(p [3]float64) [3]float64 {
+	return [3]float64{
+		m[0]*p[0] + m[4]*p[1] + m[8]*p[2] + m[12],
+		m[1]*p[0] + m[5]*p[1] + m[9]*p[2] + m[13],
+		m[2]*p[0] + m[6]*p[1] + m[10]*p[2] + m[14],
+	}
+}
+
+// TransformNormal applies the inverse transpose of the upper 3x3 part of
+// the matrix to a normal and renormalizes it
+func (m Matrix4) TransformNormal(n [3]float64) [3]float64 {
+	// Cofactors of the upper 3x3 matrix equal the inverse transpose up to
+	// a scale factor, which normalization removes
+	a, b, c := m[0], m[4], m[8]
+	d, e, f := m[1], m[5], m[9]
+	g, h, i := m[2], m[6], m[10]
+
+	cof := [9]float64{
+		e*i - f*h, -(d*i - f*g), d*h - e*g,
+		-(b*i - c*h), a*i - c*g, -(a*h - b*g),
+		b*f - c*e, -(a*f - c*d), a*e - b*d,
+	}
+
+	det := a*cof[0] + b*cof[1] + c*cof[2]
+	result := [3]float64{
+		cof[0]*n[0] + cof[1]*n[1] + cof[2]*n[2],
+		cof[3]*n[0] + cof[4]*n[1] + cof[5]*n[2],
+		cof[6]*n[0] + cof[7]*n[1] + cof[8]*n[2],
+	}
+	if det < 0 {
+		result = scale3(result, -1)
+	}
+	return normalize3(result)
+}
+
+// ComposeMatrix builds a matrix from a translation, a rotation quaternion
+// (x, y, z, w) and a scale, applied in scale, rotate, translate order
+func ComposeMatrix(t [3]float64, r [4]float64, s [3]float64) Matrix4 {
+	x, y, z, w := r[0], r[1], r[2], r[3]
+
+	return Matrix4{
+		(1 - 2*(y*y+z*z)) * s[0], (2 * (x*y + z*w)) * s[0], (2 * (x*z - y*w)) * s[0], 0,
+		(2 * (x*y - z*w)) * s[1], (1 - 2*(x*x+z*z)) * s[1], (2 * (y*z + x*w)) * s[1], 0,
+		(2 * (x*z + y*w)) * s[2], (2 * (y*z - x*w)) * s[2], (1 - 2*(x*x+y*y)) * s[2], 0,
+		t[0], t[1], t[2], 1,
+	}
+}
+
+func add3(a, b [3]float64) [3]float64 {
+	return [3]float64{a[0] + b[0], a[1] + b[1], a[2] + b[2]}
+}
+
+func sub3(a, b [3]float64) [3]float64 {
+	return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func scale3(a [3]float64, s float64) [3]float64 {
+	return [3]float64{a[0] * s, a[1] * s, a[2] * s}
+}
+
+func dot3(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func cross3(a, b [3]float64) [3]float64 {
+	return [3]float64{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+func length3(a [3]float64) float64 {
+	return math.Sqrt(dot3(a, a))
+}
+
+// normalize3 returns a unit vector, or the zero vector if a has no length
+func normalize3(a [3]float64) [3]float64 {
+	l := length3(a)
+	if l == 0 {
+		return [3]float64{}
+	}
+	return scale3(a, 1/l)
+}
@@ -0,0 +1,105 @@
+package importers
+
+import "fmt"
+
+// maxInstancedTriangles bounds the triangles of formats that instance
+// shared geometry, where a small file can repeat an object exponentially
+// often through nested references
+const maxInstancedTriangles = 1 << 22
+
+// Mesh is an indexed vertex buffer. For PrimitiveTriangles every three
+// indices form a counter-clockwise triangle; point clouds have no indices.
+// Polygons optionally records the source faces the triangles came from.
+type Mesh struct {
+	Vertices  []Vertex
+	Indices   []uint32
+	Primitive PrimitiveType
+	Polygons  []Polygon
+}
+
+// TriangleCount returns the number of triangles in the index buffer
+func (m *Mesh) TriangleCount() int {
+	if m.Primitive != PrimitiveTriangles {
+		return 0
+	}
+	return len(m.Indices) / 3
+}
+
+// vertexWelder builds an indexed mesh, merging identical vertices
+type vertexWelder struct {
+	lookup   map[Vertex]uint32
+	vertices []Vertex
+	indices  []uint32
+	polygons []Polygon
+	groups   []MeshGroup
+
+	// instances counts the instantiations of shared geometry
+	instances int
+}
+
+func newVertexWelder() *vertexWelder {
+	return &vertexWelder{lookup: make(map[Vertex]uint32)}
+}
+
+// add returns the index of vertex, appending it if it is new
+func (w *vertexWelder) add(vertex Vertex) uint32 {
+	if index, ok := w.lookup[vertex]; ok {
+		return index
+	}
+	index := uint32(len(w.vertices))
+	w.lookup[vertex] = index
+	w.vertices = append(w.vertices, vertex)
+	return index
+}
+
+// addGroup records the triangles added since group.Start as a group.
+// Groups without triangles are left out.
+func (w *vertexWelder) addGroup(group MeshGroup) {
+	group.Count = len(w.indices) - group.Start
+	if group.Count > 0 {
+		w.groups = append(w.groups, group)
+	}
+}
+
+// instance records an instantiation of shared geometry. It fails once the
+// instances and triangles produced so far exceed maxInstancedTriangles;
+// instances count as a triangle each so that empty ones are bounded too.
+func (w *vertexWelder) instance() error {
+	w.instances++
+	return checkInstances(w.instances, len(w.indices)/3)
+}
+
+// checkInstances fails once the instances and triangles produced so far
+// exceed maxInstancedTriangles
+func checkInstances(instances, triangles int) error {
+	if instances+triangles > maxInstancedTriangles {
+		return fmt.Errorf("model instantiates more than %d triangles", maxInstancedTriangles)
+	}
+	return nil
+}
+
+// addFacet fans a planar facet into triangles. A zero normal is replaced
+// by the one implied by the counter-clockwise winding.
+func (w *vertexWelder) addFacet(normal [3]float64, corners [][3]float64) {
+	if normal == ([3]float64{}) {
+		normal = normalize3(cross3(sub3(corners[1], corners[0]), sub3(corners[2], corners[0])))
+	}
+
+	for i := 1; i+1 < len(corners); i++ {
+		for _, corner := range [][3]float64{corners[0], corners[i], corners[i+1]} {
+			w.indices = append(w.indices, w.add(Vertex{Position: corner, Normal: normal}))
+		}
+	}
+}
+
+// addPolygon triangulates a polygon given by vertex indices and records
+// its corners
+func (w *vertexWelder) addPolygon(corners []uint32) {
+	triangles := triangulate(corners, w.vertices)
+	w.polygons = append(w.polygons, Polygon{
+		Corners: append([]uint32(nil), corners...),
+		Start:   len(w.indices),
+		Count:   len(triangles),
+	})
+	w.indices = append(w.indices, triangles...)
+}
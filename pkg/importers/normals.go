@@ -0,0 +1,224 @@
+package importers
+
+import "math"
+
+// NormalOptions controls how GenerateNormals computes vertex normals
+type NormalOptions struct {
+	// Flat gives every triangle corner the normal of its face
+	Flat bool
+	// AngleThreshold is the largest angle in degrees between two face
+	// normals that are still smoothed together. Zero smooths all faces.
+	AngleThreshold float64
+	// SmoothingGroups optionally holds one OBJ style smoothing group per
+	// triangle. Faces are only smoothed with faces of the same group and
+	// group 0 is flat shaded.
+	SmoothingGroups []int
+	// Overwrite replaces existing normals instead of only filling in
+	// zero ones
+	Overwrite bool
+}
+
+// GenerateNormals fills in missing vertex normals from the triangles. A
+// corner's normal averages the normals of the faces sharing its position,
+// weighted by the corner angle. Vertices are split where a shared
+// position ends up with different normals.
+func (m *Mesh) GenerateNormals(opts NormalOptions) {
+	if m.Primitive != PrimitiveTriangles || len(m.Indices) < 3 {
+		return
+	}
+
+	faceNormals := make([][3]float64, len(m.Indices)/3)
+	for t := range faceNormals {
+		a, b, c := m.trianglePositions(t)
+		faceNormals[t] = normalize3(cross3(sub3(b, a), sub3(c, a)))
+	}
+
+	group := func(t int) int {
+		if t < len(opts.SmoothingGroups) {
+			return opts.SmoothingGroups[t]
+		}
+		return 1
+	}
+
+	minCos := -2.0
+	if opts.AngleThreshold > 0 {
+		minCos = math.Cos(opts.AngleThreshold * math.Pi / 180)
+	}
+
+	// Corners grouped by position so faces smooth across attribute seams
+	byPosition := make(map[[3]float64][]int)
+	for corner, index := range m.Indices {
+		position := m.Vertices[index].Position
+		byPosition[position] = append(byPosition[position], corner)
+	}
+
+	corners := make([]Vertex, len(m.Indices))
+	for corner, index := range m.Indices {
+		vertex := m.Vertices[index]
+		if !opts.Overwrite && vertex.Normal != ([3]float64{}) {
+			corners[corner] = vertex
+			continue
+		}
+
+		t := corner / 3
+		normal := faceNormals[t]
+		if !opts.Flat && group(t) != 0 {
+			var sum [3]float64
+			for _, other := range byPosition[vertex.Position] {
+				ot := other / 3
+				if group(ot) != group(t) || dot3(faceNormals[ot], normal) < minCos {
+					continue
+				}
+				sum = add3(sum, scale3(faceNormals[ot], m.cornerAngle(other)))
+			}
+			if length3(sum) > 0 {
+				normal = normalize3(sum)
+			}
+		}
+		vertex.Normal = normal
+		corners[corner] = vertex
+	}
+
+	m.rebuild(corners)
+}
+
+// tangentKey identifies the tangent space of a vertex. Corners of
+// triangles with mirrored texture coordinates get their own tangent.
+type tangentKey struct {
+	index    uint32
+	mirrored bool
+}
+
+// GenerateTangents computes per-vertex tangents from the texture
+// coordinates and normals, following the MikkTSpace conventions: per-face
+// tangents are projected onto the vertex normal, weighted by the corner
+// angle and Tangent[3] holds the bitangent sign, so that bitangent =
+// Tangent[3] * cross(normal, tangent). Vertices shared by faces with
+// mirrored texture coordinates are split. Normals should be present,
+// for instance by calling GenerateNormals first.
+func (m *Mesh) GenerateTangents() {
+	if m.Primitive != PrimitiveTriangles || len(m.Indices) < 3 {
+		return
+	}
+
+	type tangentSum struct {
+		tangent, bitangent [3]float64
+	}
+	sums := make(map[tangentKey]*tangentSum)
+	keys := make([]tangentKey, len(m.Indices))
+
+	for t := 0; t < len(m.Indices)/3; t++ {
+		v0, v1, v2 := m.Vertices[m.Indices[t*3]], m.Vertices[m.Indices[t*3+1]], m.Vertices[m.Indices[t*3+2]]
+		e1, e2 := sub3(v1.Position, v0.Position), sub3(v2.Position, v0.Position)
+		du1, dv1 := v1.TexCoords[0]-v0.TexCoords[0], v1.TexCoords[1]-v0.TexCoords[1]
+		du2, dv2 := v2.TexCoords[0]-v0.TexCoords[0], v2.TexCoords[1]-v0.TexCoords[1]
+
+		det := du1*dv2 - du2*dv1
+		var sdir, tdir [3]float64
+		if det != 0 {
+			sdir = scale3(sub3(scale3(e1, dv2), scale3(e2, dv1)), 1/det)
+			tdir = scale3(sub3(scale3(e2, du1), scale3(e1, du2)), 1/det)
+		}
+
+		for k := 0; k < 3; k++ {
+			corner := t*3 + k
+			key := tangentKey{index: m.Indices[corner], mirrored: det < 0}
+			keys[corner] = key
+
+			sum, ok := sums[key]
+			if !ok {
+				sum = &tangentSum{}
+				sums[key] = sum
+			}
+			normal := m.Vertices[key.index].Normal
+			weight := m.cornerAngle(corner)
+			sum.tangent = add3(sum.tangent, scale3(normalize3(projectOnPlane(sdir, normal)), weight))
+			sum.bitangent = add3(sum.bitangent, scale3(normalize3(projectOnPlane(tdir, normal)), weight))
+		}
+	}
+
+	tangents := make(map[tangentKey][4]float64, len(sums))
+	for key, sum := range sums {
+		normal := m.Vertices[key.index].Normal
+		tangent := normalize3(projectOnPlane(sum.tangent, normal))
+		if length3(tangent) == 0 {
+			tangent = orthogonal3(normal)
+		}
+		sign := 1.0
+		if dot3(cross3(normal, tangent), sum.bitangent) < 0 {
+			sign = -1
+		}
+		tangents[key] = [4]float64{tangent[0], tangent[1], tangent[2], sign}
+	}
+
+	corners := make([]Vertex, len(m.Indices))
+	for corner, key := range keys {
+		vertex := m.Vertices[key.index]
+		vertex.Tangent = tangents[key]
+		vertex.HasTangent = true
+		corners[corner] = vertex
+	}
+
+	m.rebuild(corners)
+}
+
+// trianglePositions returns the corner positions of triangle t
+func (m *Mesh) trianglePositions(t int) ([3]float64, [3]float64, [3]float64) {
+	return m.Vertices[m.Indices[t*3]].Position,
+		m.Vertices[m.Indices[t*3+1]].Position,
+		m.Vertices[m.Indices[t*3+2]].Position
+}
+
+// cornerAngle returns the interior angle of a triangle at the given
+// position in the index buffer
+func (m *Mesh) cornerAngle(corner int) float64 {
+	t, k := corner/3, corner%3
+	a, b, c := m.trianglePositions(t)
+	points := [3][3]float64{a, b, c}
+	p := points[k]
+	u := normalize3(sub3(points[(k+1)%3], p))
+	v := normalize3(sub3(points[(k+2)%3], p))
+	if u == ([3]float64{}) || v == ([3]float64{}) {
+		return 0
+	}
+	return math.Acos(max(-1, min(1, dot3(u, v))))
+}
+
+// rebuild replaces the mesh with one vertex per index buffer corner,
+// welding identical vertices and remapping the polygon corners
+func (m *Mesh) rebuild(corners []Vertex) {
+	welder := newVertexWelder()
+	indices := make([]uint32, len(corners))
+	for i, vertex := range corners {
+		indices[i] = welder.add(vertex)
+	}
+
+	for p, polygon := range m.Polygons {
+		remap := make(map[uint32]uint32, len(polygon.Corners))
+		for i := polygon.Start; i < polygon.Start+polygon.Count; i++ {
+			remap[m.Indices[i]] = indices[i]
+		}
+		remapped := make([]uint32, len(polygon.Corners))
+		for i, corner := range polygon.Corners {
+			remapped[i] = remap[corner]
+		}
+		m.Polygons[p].Corners = remapped
+	}
+
+	m.Vertices = welder.vertices
+	m.Indices = indices
+}
+
+// projectOnPlane removes the component of v along the unit normal n
+func projectOnPlane(v, n [3]float64) [3]float64 {
+	return sub3(v, scale3(n, dot3(n, v)))
+}
+
+// orthogonal3 returns a unit vector perpendicular to the unit vector n
+func orthogonal3(n [3]float64) [3]float64 {
+	axis := [3]float64{1, 0, 0}
+	if math.Abs(n[0]) > 0.9 {
+		axis = [3]float64{0, 1, 0}
+	}
+	return normalize3(cross3(n, axis))
+}
@@ -0,0 +1,94 @@
+package importers
+
+import "math"
+
+// Conversions between the Phong fields of Material and PBRMaterial use
+// these rules:
+//
+//   - Base color is the diffuse color (Kd) with the opacity (d) as alpha.
+//     Materials with an opacity below one blend, others are opaque.
+//   - Roughness and the Phong exponent (Ns) are related through the GGX
+//     alpha: alpha = roughness², Ns = 2/alpha² - 2.
+//   - The specular color (Ks) of a PBR material is the dielectric
+//     reflectance of 0.04 blended towards the base color by metallic.
+//     Phong materials convert to dielectrics with metallic 0.
+//   - Emissive color and texture, the base color texture (map_Kd) and the
+//     normal map carry over unchanged.
+
+// minRoughness keeps the Phong exponent finite for mirror-like materials
+const minRoughness = 0.01
+
+// dielectricSpecular is the reflectance of non-metals at normal incidence
+const dielectricSpecular = 0.04
+
+// ToPBR returns the metallic-roughness parameters of the material: its PBR
+// parameters if it has any, otherwise ones converted from the Phong
+// fields. A zero Transparency, as left by MTL files without d or Tr, is
+// treated as opaque.
+func (m *Material) ToPBR() *PBRMaterial {
+	if m.PBR != nil {
+		return m.PBR
+	}
+
+	opacity := m.Transparency
+	if opacity == 0 {
+		opacity = 1
+	}
+	alphaMode := "OPAQUE"
+	if opacity < 1 {
+		alphaMode = "BLEND"
+	}
+
+	return &PBRMaterial{
+		BaseColorFactor:   [4]float64{m.DiffuseColor[0], m.DiffuseColor[1], m.DiffuseColor[2], opacity},
+		BaseColorTexture:  m.DiffuseMap,
+		MetallicFactor:    0,
+		RoughnessFactor:   ShininessToRoughness(m.Shininess),
+		NormalTexture:     m.NormalMap,
+		NormalScale:       1,
+		OcclusionStrength: 1,
+		EmissiveFactor:    m.EmissiveColor,
+		EmissiveTexture:   m.EmissiveMap,
+		AlphaMode:         alphaMode,
+		AlphaCutoff:       0.5,
+	}
+}
+
+// ToPhong converts metallic-roughness parameters into a material with
+// equivalent Phong fields, keeping p as its PBR parameters
+func (p *PBRMaterial) ToPhong(name string) *Material {
+	opacity := p.BaseColorFactor[3]
+	if p.AlphaMode == "OPAQUE" {
+		opacity = 1
+	}
+
+	var specular [3]float64
+	for i := range specular {
+		specular[i] = dielectricSpecular + (p.BaseColorFactor[i]-dielectricSpecular)*p.MetallicFactor
+	}
+
+	return &Material{
+		Name:          name,
+		DiffuseColor:  [3]float64{p.BaseColorFactor[0], p.BaseColorFactor[1], p.BaseColorFactor[2]},
+		SpecularColor: specular,
+		Shininess:     RoughnessToShininess(p.RoughnessFactor),
+		DiffuseMap:    p.BaseColorTexture,
+		NormalMap:     p.NormalTexture,
+		EmissiveColor: p.EmissiveFactor,
+		EmissiveMap:   p.EmissiveTexture,
+		Transparency:  opacity,
+		PBR:           p,
+	}
+}
+
+// ShininessToRoughness converts a Phong exponent into a roughness in [0, 1]
+func ShininessToRoughness(shininess float64) float64 {
+	alpha := math.Sqrt(2 / (max(shininess, 0) + 2))
+	return math.Sqrt(alpha)
+}
+
+// RoughnessToShininess converts a roughness in [0, 1] into a Phong exponent
+func RoughnessToShininess(roughness float64) float64 {
+	alpha := math.Pow(min(max(roughness, minRoughness), 1), 2)
+	return 2/(alpha*alpha) - 2
+}
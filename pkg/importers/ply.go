@@ -0,0 +1,451 @@
+package importers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type plyFormat int
+
+const (
+	plyASCII plyFormat = iota
+	plyBinaryLittleEndian
+	plyBinaryBigEndian
+)
+
+// plyType is a PLY scalar type
+type plyType struct {
+	size    int
+	integer bool
+	signed  bool
+	max     float64 // range used to normalize integer colors
+}
+
+var plyTypes = map[string]plyType{
+	"char":    {size: 1, integer: true, signed: true, max: math.MaxInt8},
+	"int8":    {size: 1, integer: true, signed: true, max: math.MaxInt8},
+	"uchar":   {size: 1, integer: true, max: math.MaxUint8},
+	"uint8":   {size: 1, integer: true, max: math.MaxUint8},
+	"short":   {size: 2, integer: true, signed: true, max: math.MaxInt16},
+	"int16":   {size: 2, integer: true, signed: true, max: math.MaxInt16},
+	"ushort":  {size: 2, integer: true, max: math.MaxUint16},
+	"uint16":  {size: 2, integer: true, max: math.MaxUint16},
+	"int":     {size: 4, integer: true, signed: true, max: math.MaxInt32},
+	"int32":   {size: 4, integer: true, signed: true, max: math.MaxInt32},
+	"uint":    {size: 4, integer: true, max: math.MaxUint32},
+	"uint32":  {size: 4, integer: true, max: math.MaxUint32},
+	"float":   {size: 4},
+	"float32": {size: 4},
+	"double":  {size: 8},
+	"float64": {size: 8},
+}
+
+// plyProperty is a scalar or list property of an element
+type plyProperty struct {
+	name      string
+	valueType plyType
+	list      bool
+	countType plyType
+}
+
+// plyElement is an element declaration such as "element vertex 8"
+type plyElement struct {
+	name       string
+	count      int
+	properties []plyProperty
+}
+
+// plyValueReader reads scalar values from the body of a PLY file
+type plyValueReader interface {
+	read(t plyType) (float64, error)
+}
+
+// ImportFromPLY imports an ASCII or binary PLY file. Files without faces
+// are imported as point clouds; faces are fanned into triangles.
+func (vi *VertexImporter) ImportFromPLY(reader io.Reader) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read PLY file: %w", err)
+	}
+
+	format, elements, body, err := parsePLYHeader(data)
+	if err != nil {
+		return err
+	}
+
+	var values plyValueReader
+	switch format {
+	case plyASCII:
+		values = &plyASCIIReader{data: body}
+	case plyBinaryLittleEndian:
+		values = &plyBinaryReader{data: body, order: binary.LittleEndian}
+	case plyBinaryBigEndian:
+		values = &plyBinaryReader{data: body, order: binary.BigEndian}
+	}
+
+	var (
+		vertices []Vertex
+		indices  []uint32
+		hasFaces bool
+	)
+	for _, element := range elements {
+		switch element.name {
+		case "vertex":
+			if vertices != nil {
+				return errors.New("PLY file declares more than one vertex element")
+			}
+			vertices, err = readPLYVertices(values, element, len(body))
+		case "face":
+			hasFaces = true
+			indices, err = readPLYFaces(values, element, len(body))
+		default:
+			err = skipPLYElement(values, element)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid PLY %s element: %w", element.name, err)
+		}
+	}
+
+	for _, index := range indices {
+		if int(index) >= len(vertices) {
+			return fmt.Errorf("PLY face references missing vertex %d", index)
+		}
+	}
+
+	if vertices == nil {
+		vertices = []Vertex{}
+	}
+	vi.appendIndexed(vertices, indices)
+	vi.primitive = PrimitiveTriangles
+	if !hasFaces {
+		vi.primitive = PrimitivePoints
+	}
+	return nil
+}
+
+// parsePLYHeader reads the header and returns the body that follows it
+func parsePLYHeader(data []byte) (plyFormat, []*plyElement, []byte, error) {
+	if !bytes.HasPrefix(data, []byte("ply\n")) && !bytes.HasPrefix(data, []byte("ply\r\n")) {
+		return 0, nil, nil, errors.New("invalid PLY file: missing ply magic")
+	}
+
+	var (
+		format    plyFormat
+		hasFormat bool
+		elements  []*plyElement
+		offset    int
+	)
+
+	for line := 1; ; line++ {
+		end := bytes.IndexByte(data[offset:], '\n')
+		if end < 0 {
+			return 0, nil, nil, errors.New("invalid PLY file: missing end_header")
+		}
+		fields := strings.Fields(string(data[offset : offset+end]))
+		offset += end + 1
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "ply", "comment", "obj_info":
+		case "format":
+			if len(fields) != 3 || fields[2] != "1.0" {
+				return 0, nil, nil, fmt.Errorf("PLY header line %d: unsupported format", line)
+			}
+			switch fields[1] {
+			case "ascii":
+				format = plyASCII
+			case "binary_little_endian":
+				format = plyBinaryLittleEndian
+			case "binary_big_endian":
+				format = plyBinaryBigEndian
+			default:
+				return 0, nil, nil, fmt.Errorf("PLY header line %d: unknown format %q", line, fields[1])
+			}
+			hasFormat = true
+
+		case "element":
+			if len(fields) != 3 {
+				return 0, nil, nil, fmt.Errorf("PLY header line %d: invalid element", line)
+			}
+			count, err := strconv.Atoi(fields[2])
+			if err != nil || count < 0 || count > len(data) {
+				return 0, nil, nil, fmt.Errorf("PLY header line %d: invalid element count %q", line, fields[2])
+			}
+			elements = append(elements, &plyElement{name: fields[1], count: count})
+
+		case "property":
+			if len(elements) == 0 {
+				return 0, nil, nil, fmt.Errorf("PLY header line %d: property before element", line)
+			}
+			prop, err := parsePLYProperty(fields[1:])
+			if err != nil {
+				return 0, nil, nil, fmt.Errorf("PLY header line %d: %w", line, err)
+			}
+			element := elements[len(elements)-1]
+			element.properties = append(element.properties, prop)
+
+		case "end_header":
+			if !hasFormat {
+				return 0, nil, nil, errors.New("invalid PLY file: missing format")
+			}
+			return format, elements, data[offset:], nil
+
+		default:
+			return 0, nil, nil, fmt.Errorf("PLY header line %d: unknown keyword %q", line, fields[0])
+		}
+	}
+}
+
+// parsePLYProperty parses "type name" or "list countType valueType name"
+func parsePLYProperty(fields []string) (plyProperty, error) {
+	if len(fields) == 4 && fields[0] == "list" {
+		countType, ok := plyTypes[fields[1]]
+		if !ok || !countType.integer {
+			return plyProperty{}, fmt.Errorf("invalid list count type %q", fields[1])
+		}
+		valueType, ok := plyTypes[fields[2]]
+		if !ok {
+			return plyProperty{}, fmt.Errorf("unknown property type %q", fields[2])
+		}
+		return plyProperty{name: fields[3], valueType: valueType, list: true, countType: countType}, nil
+	}
+
+	if len(fields) != 2 {
+		return plyProperty{}, errors.New("invalid property")
+	}
+	valueType, ok := plyTypes[fields[0]]
+	if !ok {
+		return plyProperty{}, fmt.Errorf("unknown property type %q", fields[0])
+	}
+	return plyProperty{name: fields[1], valueType: valueType}, nil
+}
+
+// readPLYRow reads one element row, calling scalar for each scalar
+// property and list for each list property
+func readPLYRow(values plyValueReader, element *plyElement, scalar func(i int, v float64), list func(i int, v []float64)) error {
+	for i, prop := range element.properties {
+		if !prop.list {
+			v, err := values.read(prop.valueType)
+			if err != nil {
+				return err
+			}
+			scalar(i, v)
+			continue
+		}
+
+		count, err := values.read(prop.countType)
+		if err != nil {
+			return err
+		}
+		if count < 0 || count > math.MaxUint16 {
+			return fmt.Errorf("invalid list length %v", count)
+		}
+		items := make([]float64, int(count))
+		for j := range items {
+			if items[j], err = values.read(prop.valueType); err != nil {
+				return err
+			}
+		}
+		list(i, items)
+	}
+	return nil
+}
+
+// readPLYVertices reads the vertex element, mapping the conventional
+// property names onto positions, normals, texture coordinates and colors
+func readPLYVertices(values plyValueReader, element *plyElement, bodySize int) ([]Vertex, error) {
+	type slot struct {
+		target *float64
+		scale  float64
+	}
+
+	var vertex Vertex
+	slots := make([]slot, len(element.properties))
+	hasColor := false
+	hasAlpha := false
+	for i, prop := range element.properties {
+		scale := 1.0
+		if prop.valueType.integer {
+			scale = 1 / prop.valueType.max
+		}
+
+		switch prop.name {
+		case "x":
+			slots[i] = slot{&vertex.Position[0], 1}
+		case "y":
+			slots[i] = slot{&vertex.Position[1], 1}
+		case "z":
+			slots[i] = slot{&vertex.Position[2], 1}
+		case "nx":
+			slots[i] = slot{&vertex.Normal[0], 1}
+		case "ny":
+			slots[i] = slot{&vertex.Normal[1], 1}
+		case "nz":
+			slots[i] = slot{&vertex.Normal[2], 1}
+		case "s", "u", "texture_u", "texture_s":
+			slots[i] = slot{&vertex.TexCoords[0], 1}
+		case "t", "v", "texture_v", "texture_t":
+			slots[i] = slot{&vertex.TexCoords[1], 1}
+		case "red", "diffuse_red", "r":
+			slots[i], hasColor = slot{&vertex.Color[0], scale}, true
+		case "green", "diffuse_green", "g":
+			slots[i], hasColor = slot{&vertex.Color[1], scale}, true
+		case "blue", "diffuse_blue", "b":
+			slots[i], hasColor = slot{&vertex.Color[2], scale}, true
+		case "alpha", "diffuse_alpha", "a":
+			slots[i], hasAlpha = slot{&vertex.Color[3], scale}, true
+		}
+	}
+
+	vertices := make([]Vertex, 0, min(element.count, bodySize))
+	for n := 0; n < element.count; n++ {
+		vertex = Vertex{}
+		if hasColor && !hasAlpha {
+			vertex.Color[3] = 1
+		}
+
+		err := readPLYRow(values, element, func(i int, v float64) {
+			if s := slots[i]; s.target != nil {
+				*s.target = v * s.scale
+			}
+		}, func(int, []float64) {})
+		if err != nil {
+			return nil, fmt.Errorf("vertex %d: %w", n, err)
+		}
+
+		vertex.HasColor = hasColor
+		vertices = append(vertices, vertex)
+	}
+
+	return vertices, nil
+}
+
+// readPLYFaces reads the face element's vertex index lists as triangles
+func readPLYFaces(values plyValueReader, element *plyElement, bodySize int) ([]uint32, error) {
+	indexProperty := -1
+	for i, prop := range element.properties {
+		if prop.list && (prop.name == "vertex_indices" || prop.name == "vertex_index") {
+			indexProperty = i
+		}
+	}
+	if indexProperty < 0 {
+		return nil, errors.New("missing vertex_indices list")
+	}
+
+	indices := make([]uint32, 0, min(element.count*3, bodySize))
+	for n := 0; n < element.count; n++ {
+		var polygon []float64
+		err := readPLYRow(values, element, func(int, float64) {}, func(i int, v []float64) {
+			if i == indexProperty {
+				polygon = v
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("face %d: %w", n, err)
+		}
+		if len(polygon) < 3 {
+			return nil, fmt.Errorf("face %d has %d vertices", n, len(polygon))
+		}
+
+		for _, index := range polygon {
+			if index < 0 || index > math.MaxUint32 || index != math.Trunc(index) {
+				return nil, fmt.Errorf("face %d has invalid vertex index %v", n, index)
+			}
+		}
+		for i := 1; i+1 < len(polygon); i++ {
+			indices = append(indices, uint32(polygon[0]), uint32(polygon[i]), uint32(polygon[i+1]))
+		}
+	}
+
+	return indices, nil
+}
+
+// skipPLYElement reads past an element this importer does not use
+func skipPLYElement(values plyValueReader, element *plyElement) error {
+	for n := 0; n < element.count; n++ {
+		if err := readPLYRow(values, element, func(int, float64) {}, func(int, []float64) {}); err != nil {
+			return fmt.Errorf("row %d: %w", n, err)
+		}
+	}
+	return nil
+}
+
+// plyASCIIReader reads whitespace-separated values
+type plyASCIIReader struct {
+	data   []byte
+	offset int
+}
+
+func (r *plyASCIIReader) read(t plyType) (float64, error) {
+	for r.offset < len(r.data) && isPLYSpace(r.data[r.offset]) {
+		r.offset++
+	}
+	start := r.offset
+	for r.offset < len(r.data) && !isPLYSpace(r.data[r.offset]) {
+		r.offset++
+	}
+	if start == r.offset {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	token := string(r.data[start:r.offset])
+	if t.integer {
+		v, err := strconv.ParseInt(token, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid integer %q", token)
+		}
+		return float64(v), nil
+	}
+	v, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", token)
+	}
+	return v, nil
+}
+
+func isPLYSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// plyBinaryReader reads fixed-size values in the file's byte order
+type plyBinaryReader struct {
+	data   []byte
+	offset int
+	order  binary.ByteOrder
+}
+
+func (r *plyBinaryReader) read(t plyType) (float64, error) {
+	if len(r.data)-r.offset < t.size {
+		return 0, io.ErrUnexpectedEOF
+	}
+	b := r.data[r.offset : r.offset+t.size]
+	r.offset += t.size
+
+	switch {
+	case t.size == 1 && t.signed:
+		return float64(int8(b[0])), nil
+	case t.size == 1:
+		return float64(b[0]), nil
+	case t.size == 2 && t.signed:
+		return float64(int16(r.order.Uint16(b))), nil
+	case t.size == 2:
+		return float64(r.order.Uint16(b)), nil
+	case t.size == 4 && t.integer && t.signed:
+		return float64(int32(r.order.Uint32(b))), nil
+	case t.size == 4 && t.integer:
+		return float64(r.order.Uint32(b)), nil
+	case t.size == 4:
+		return float64(math.Float32frombits(r.order.Uint32(b))), nil
+	default:
+		return math.Float64frombits(r.order.Uint64(b)), nil
+	}
+}
@@ -0,0 +1,45 @@
+package importers
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ResourceResolver loads an external resource referenced by a model file,
+// such as a glTF buffer or a texture, by its relative URI
+type ResourceResolver func(uri string) ([]byte, error)
+
+// DirResolver returns a resolver that reads resources relative to dir.
+// URIs that would escape dir are rejected.
+func DirResolver(dir string) ResourceResolver {
+	return func(uri string) ([]byte, error) {
+		name, err := cleanResourcePath(uri)
+		if err != nil {
+			return nil, err
+		}
+		return os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	}
+}
+
+// cleanResourcePath unescapes a relative URI and makes sure it stays
+// within the directory it is resolved against
+func cleanResourcePath(uri string) (string, error) {
+	unescaped, err := url.PathUnescape(uri)
+	if err != nil {
+		return "", fmt.Errorf("invalid resource URI %q: %w", uri, err)
+	}
+
+	name := path.Clean(strings.ReplaceAll(unescaped, "\\", "/"))
+	if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") || strings.Contains(name, ":") {
+		return "", fmt.Errorf("resource URI %q is not a relative path", uri)
+	}
+
+	return name, nil
+}
+
+var errNoResolver = errors.New("external resource referenced but no resolver provided")
@@ -0,0 +1,535 @@
+package importers
+
+import (
+	"container/heap"
+	"math"
+)
+
+// simplifyNormalAngle is the crease angle in degrees used to regenerate
+// the normals of vertices that merged several source normals
+const simplifyNormalAngle = 45
+
+// SimplifyOptions controls how Simplify reduces a mesh
+type SimplifyOptions struct {
+	// TargetTriangles is the triangle count to reduce the mesh to. The
+	// result keeps more triangles when no edge is left that can be
+	// collapsed without breaking a border or folding the surface.
+	TargetTriangles int
+	// Labels optionally holds one label per triangle, such as the index
+	// of its material group. Edges between triangles with different
+	// labels are kept like borders.
+	Labels []int
+}
+
+// SimplifyResult is a mesh reduced by Simplify
+type SimplifyResult struct {
+	Mesh *Mesh
+	// Labels holds the label of every triangle of Mesh, or nil if no
+	// labels were given
+	Labels []int
+	// Error estimates how far the surface moved: the square root of the
+	// largest quadric error of a collapse, the area weighted mean squared
+	// distance of the merged vertices to their original planes
+	Error float64
+}
+
+// Simplify reduces a triangle mesh with the quadric error metric of
+// Garland and Heckbert. Edges are collapsed cheapest first onto one of
+// their vertices, so the remaining vertices keep their attributes. Open
+// borders, texture coordinate and color seams and label borders are
+// preserved: their vertices only move along them, and vertices where they
+// meet or branch stay in place. Collapses that would flip a triangle or
+// make the mesh non-manifold are skipped.
+//
+// Normals play no part in the seams, so flat shaded meshes simplify like
+// smooth ones; vertices that end up merging different normals get new
+// ones with a crease angle of 45 degrees. Degenerate triangles are
+// removed and recorded polygons are dropped. Point clouds are returned as
+// they are.
+func (m *Mesh) Simplify(opts SimplifyOptions) *SimplifyResult {
+	if m.Primitive != PrimitiveTriangles {
+		return &SimplifyResult{Mesh: &Mesh{Vertices: m.Vertices, Primitive: m.Primitive}}
+	}
+
+	s := newSimplifier(m, opts.Labels)
+	s.run(opts.TargetTriangles)
+	return s.result(m, opts.Labels != nil)
+}
+
+// quadric is a weighted sum of plane quadrics: the upper triangle of the
+// symmetric 4x4 matrix, a², ab, ac, ad, b², bc, bd, c², cd, d² for the
+// plane ax + by + cz + d = 0, followed by the total weight
+type quadric [11]float64
+
+// planeQuadric returns the quadric measuring the squared distance to the
+// plane through point with the unit normal n, scaled by weight
+func planeQuadric(n, point [3]float64, weight float64) quadric {
+	a, b, c := n[0], n[1], n[2]
+	d := -dot3(n, point)
+	q := quadric{a * a, a * b, a * c, a * d, b * b, b * c, b * d, c * c, c * d, d * d}
+	for i := range q {
+		q[i] *= weight
+	}
+	q[10] = weight
+	return q
+}
+
+func (q *quadric) add(o quadric) {
+	for i := range q {
+		q[i] += o[i]
+	}
+}
+
+// eval returns the weighted mean squared distance of point to the planes
+func (q *quadric) eval(p [3]float64) float64 {
+	if q[10] == 0 {
+		return 0
+	}
+	x, y, z := p[0], p[1], p[2]
+	sum := q[0]*x*x + 2*q[1]*x*y + 2*q[2]*x*z + 2*q[3]*x +
+		q[4]*y*y + 2*q[5]*y*z + 2*q[6]*y +
+		q[7]*z*z + 2*q[8]*z +
+		q[9]
+	return max(sum/q[10], 0)
+}
+
+// collapse is a candidate moving position from onto position to. The
+// versions detect candidates made stale by later collapses.
+type collapse struct {
+	cost                 float64
+	from, to             int
+	fromVersion, version int
+}
+
+type collapseHeap []collapse
+
+func (h collapseHeap) Len() int           { return len(h) }
+func (h collapseHeap) Less(i, j int) bool { return h[i].cost < h[j].cost }
+func (h collapseHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *collapseHeap) Push(x any)        { *h = append(*h, x.(collapse)) }
+func (h *collapseHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// simplifier holds the mesh being reduced. Triangles reference wedges,
+// the distinct vertices ignoring normals and tangents, and collapses act
+// on positions, which may have several wedges along seams.
+type simplifier struct {
+	wedges []Vertex
+	// mixed marks wedges merging vertices with different normals or
+	// tangents
+	mixed    []bool
+	position []int // position of every wedge
+
+	points   [][3]float64
+	quadrics []quadric
+	around   [][]int // triangles around every position
+	version  []int
+	dead     []bool
+
+	triangles [][3]int
+	labels    []int
+	removed   []bool
+	live      int
+
+	queue    collapseHeap
+	maxError float64
+}
+
+func newSimplifier(m *Mesh, labels []int) *simplifier {
+	s := &simplifier{}
+
+	wedgeIndex := make(map[Vertex]int)
+	positionIndex := make(map[[3]float64]int)
+	wedgeOf := make([]int, len(m.Vertices))
+	for i, vertex := range m.Vertices {
+		key := vertex
+		key.Normal, key.Tangent, key.HasTangent = [3]float64{}, [4]float64{}, false
+
+		w, ok := wedgeIndex[key]
+		if !ok {
+			w = len(s.wedges)
+			wedgeIndex[key] = w
+			s.wedges = append(s.wedges, vertex)
+			s.mixed = append(s.mixed, false)
+
+			p, ok := positionIndex[vertex.Position]
+			if !ok {
+				p = len(s.points)
+				positionIndex[vertex.Position] = p
+				s.points = append(s.points, vertex.Position)
+			}
+			s.position = append(s.position, p)
+		} else if first := s.wedges[w]; first.Normal != vertex.Normal || first.Tangent != vertex.Tangent || first.HasTangent != vertex.HasTangent {
+			s.mixed[w] = true
+		}
+		wedgeOf[i] = w
+	}
+
+	s.quadrics = make([]quadric, len(s.points))
+	s.around = make([][]int, len(s.points))
+	s.version = make([]int, len(s.points))
+	s.dead = make([]bool, len(s.points))
+
+	for t := 0; t < len(m.Indices)/3; t++ {
+		var triangle [3]int
+		valid := true
+		for k := range triangle {
+			index := m.Indices[t*3+k]
+			if int(index) >= len(m.Vertices) {
+				valid = false
+				break
+			}
+			triangle[k] = wedgeOf[index]
+		}
+		if !valid {
+			continue
+		}
+		a, b, c := s.position[triangle[0]], s.position[triangle[1]], s.position[triangle[2]]
+		if a == b || b == c || a == c {
+			continue
+		}
+
+		label := 0
+		if t < len(labels) {
+			label = labels[t]
+		}
+		index := len(s.triangles)
+		s.triangles = append(s.triangles, triangle)
+		s.labels = append(s.labels, label)
+		for _, p := range [3]int{a, b, c} {
+			s.around[p] = append(s.around[p], index)
+		}
+	}
+	s.removed = make([]bool, len(s.triangles))
+	s.live = len(s.triangles)
+
+	// Every position starts with the planes of its triangles weighted by
+	// their area, and the ends of preserved edges with planes through the
+	// edge perpendicular to the triangle, which keep borders from bending
+	for t := range s.triangles {
+		a, b, c := s.points[s.corner(t, 0)], s.points[s.corner(t, 1)], s.points[s.corner(t, 2)]
+		cross := cross3(sub3(b, a), sub3(c, a))
+		area := length3(cross) / 2
+		if area == 0 {
+			continue
+		}
+		normal := normalize3(cross)
+		q := planeQuadric(normal, a, area)
+		for k := 0; k < 3; k++ {
+			p, r := s.corner(t, k), s.corner(t, (k+1)%3)
+			s.quadrics[p].add(q)
+			if s.preserved(p, r) {
+				edge := sub3(s.points[r], s.points[p])
+				border := normalize3(cross3(edge, normal))
+				q := planeQuadric(border, s.points[p], dot3(edge, edge))
+				s.quadrics[p].add(q)
+				s.quadrics[r].add(q)
+			}
+		}
+	}
+
+	return s
+}
+
+// corner returns the position of corner k of triangle t
+func (s *simplifier) corner(t, k int) int {
+	return s.position[s.triangles[t][k]]
+}
+
+// wedgeAt returns the wedge of triangle t at position p, or -1
+func (s *simplifier) wedgeAt(t, p int) int {
+	for _, w := range s.triangles[t] {
+		if s.position[w] == p {
+			return w
+		}
+	}
+	return -1
+}
+
+// normal returns the unit normal of triangle t, with position moved to
+// point if it is not negative
+func (s *simplifier) normal(t, moved int, point [3]float64) [3]float64 {
+	var corners [3][3]float64
+	for k := range corners {
+		p := s.corner(t, k)
+		corners[k] = s.points[p]
+		if p == moved {
+			corners[k] = point
+		}
+	}
+	return normalize3(cross3(sub3(corners[1], corners[0]), sub3(corners[2], corners[0])))
+}
+
+// edgeTriangles returns the remaining triangles containing positions p
+// and q
+func (s *simplifier) edgeTriangles(p, q int) []int {
+	var triangles []int
+	for _, t := range s.around[p] {
+		if !s.removed[t] && s.wedgeAt(t, q) >= 0 {
+			triangles = append(triangles, t)
+		}
+	}
+	return triangles
+}
+
+// neighbors returns the positions sharing a remaining triangle with p
+func (s *simplifier) neighbors(p int) []int {
+	var neighbors []int
+	seen := map[int]bool{p: true}
+	for _, t := range s.around[p] {
+		if s.removed[t] {
+			continue
+		}
+		for k := 0; k < 3; k++ {
+			if q := s.corner(t, k); !seen[q] {
+				seen[q] = true
+				neighbors = append(neighbors, q)
+			}
+		}
+	}
+	return neighbors
+}
+
+// preserved reports whether the edge between p and q is an open border,
+// a seam, a label border or non-manifold
+func (s *simplifier) preserved(p, q int) bool {
+	triangles := s.edgeTriangles(p, q)
+	if len(triangles) != 2 {
+		return true
+	}
+	a, b := triangles[0], triangles[1]
+	return s.labels[a] != s.labels[b] || s.wedgeAt(a, p) != s.wedgeAt(b, p) || s.wedgeAt(a, q) != s.wedgeAt(b, q)
+}
+
+// plan checks whether p can collapse onto q and returns the wedge of q
+// replacing each wedge of p
+func (s *simplifier) plan(p, q int) (map[int]int, bool) {
+	// A vertex on preserved edges may only move along them, and only if
+	// it lies on exactly two of them; non-manifold vertices stay put
+	var borders []int
+	neighbors := s.neighbors(p)
+	for _, r := range neighbors {
+		if len(s.edgeTriangles(p, r)) > 2 {
+			return nil, false
+		}
+		if s.preserved(p, r) {
+			borders = append(borders, r)
+		}
+	}
+	switch len(borders) {
+	case 0:
+	case 2:
+		if borders[0] != q && borders[1] != q {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+
+	shared := s.edgeTriangles(p, q)
+	if len(shared) == 0 {
+		return nil, false
+	}
+	remap := make(map[int]int)
+	for _, t := range shared {
+		from, to := s.wedgeAt(t, p), s.wedgeAt(t, q)
+		if previous, ok := remap[from]; ok && previous != to {
+			return nil, false
+		}
+		remap[from] = to
+	}
+
+	// Every wedge of p needs a counterpart at q, and no triangle may flip
+	// or collapse to a sliver
+	for _, t := range s.around[p] {
+		if s.removed[t] {
+			continue
+		}
+		if _, ok := remap[s.wedgeAt(t, p)]; !ok {
+			return nil, false
+		}
+		if s.wedgeAt(t, q) >= 0 {
+			continue
+		}
+		if s.duplicates(t, p, q) {
+			return nil, false
+		}
+		before := s.normal(t, -1, [3]float64{})
+		after := s.normal(t, p, s.points[q])
+		if after == ([3]float64{}) || dot3(before, after) < 0.2 {
+			return nil, false
+		}
+	}
+
+	// Link condition: p and q may only share the neighbors opposite the
+	// collapsed edge, or the mesh would pinch
+	others := make(map[int]bool)
+	for _, r := range s.neighbors(q) {
+		others[r] = true
+	}
+	common := 0
+	for _, r := range neighbors {
+		if others[r] {
+			common++
+		}
+	}
+	if common != len(shared) {
+		return nil, false
+	}
+
+	return remap, true
+}
+
+// duplicates reports whether triangle t, moved from p to q, would have
+// the same corners as a triangle already around q, as when collapsing an
+// edge of a tetrahedron
+func (s *simplifier) duplicates(t, p, q int) bool {
+	for _, u := range s.around[q] {
+		if s.removed[u] {
+			continue
+		}
+		same := true
+		for k := 0; k < 3; k++ {
+			if r := s.corner(t, k); r != p && s.wedgeAt(u, r) < 0 {
+				same = false
+			}
+		}
+		if same {
+			return true
+		}
+	}
+	return false
+}
+
+// push queues the collapses of p onto q and of q onto p
+func (s *simplifier) push(p, q int) {
+	for _, c := range [2][2]int{{p, q}, {q, p}} {
+		from, to := c[0], c[1]
+		sum := s.quadrics[from]
+		sum.add(s.quadrics[to])
+		heap.Push(&s.queue, collapse{
+			cost:        sum.eval(s.points[to]),
+			from:        from,
+			to:          to,
+			fromVersion: s.version[from],
+			version:     s.version[to],
+		})
+	}
+}
+
+// run collapses edges until at most target triangles remain or no
+// collapse is possible
+func (s *simplifier) run(target int) {
+	seen := make(map[[2]int]bool)
+	for t := range s.triangles {
+		for k := 0; k < 3; k++ {
+			p, q := s.corner(t, k), s.corner(t, (k+1)%3)
+			edge := [2]int{min(p, q), max(p, q)}
+			if !seen[edge] {
+				seen[edge] = true
+				s.push(p, q)
+			}
+		}
+	}
+
+	for s.live > target && s.queue.Len() > 0 {
+		c := heap.Pop(&s.queue).(collapse)
+		if s.dead[c.from] || s.dead[c.to] || s.version[c.from] != c.fromVersion || s.version[c.to] != c.version {
+			continue
+		}
+		remap, ok := s.plan(c.from, c.to)
+		if !ok {
+			continue
+		}
+		s.apply(c.from, c.to, remap)
+		s.maxError = max(s.maxError, c.cost)
+	}
+}
+
+// apply collapses p onto q
+func (s *simplifier) apply(p, q int, remap map[int]int) {
+	for _, t := range s.around[p] {
+		if s.removed[t] {
+			continue
+		}
+		if s.wedgeAt(t, q) >= 0 {
+			s.removed[t] = true
+			s.live--
+			continue
+		}
+		for k, w := range s.triangles[t] {
+			if s.position[w] == p {
+				s.triangles[t][k] = remap[w]
+			}
+		}
+		s.around[q] = append(s.around[q], t)
+	}
+
+	kept := s.around[q][:0]
+	for _, t := range s.around[q] {
+		if !s.removed[t] {
+			kept = append(kept, t)
+		}
+	}
+	s.around[q] = kept
+	s.around[p] = nil
+	s.dead[p] = true
+
+	s.quadrics[q].add(s.quadrics[p])
+	s.version[p]++
+	s.version[q]++
+	for _, r := range s.neighbors(q) {
+		s.push(q, r)
+	}
+}
+
+// result builds the reduced mesh from the remaining triangles in their
+// original order
+func (s *simplifier) result(m *Mesh, hasLabels bool) *SimplifyResult {
+	result := &SimplifyResult{
+		Mesh:  &Mesh{Primitive: PrimitiveTriangles, Vertices: []Vertex{}},
+		Error: math.Sqrt(s.maxError),
+	}
+
+	var hasNormals, hasTangents, regenerate bool
+	for _, vertex := range m.Vertices {
+		hasNormals = hasNormals || vertex.Normal != ([3]float64{})
+		hasTangents = hasTangents || vertex.HasTangent
+	}
+
+	index := make(map[int]uint32)
+	for t, triangle := range s.triangles {
+		if s.removed[t] {
+			continue
+		}
+		for _, w := range triangle {
+			i, ok := index[w]
+			if !ok {
+				i = uint32(len(result.Mesh.Vertices))
+				index[w] = i
+				vertex := s.wedges[w]
+				if s.mixed[w] {
+					vertex.Normal, vertex.Tangent, vertex.HasTangent = [3]float64{}, [4]float64{}, false
+					regenerate = true
+				}
+				result.Mesh.Vertices = append(result.Mesh.Vertices, vertex)
+			}
+			result.Mesh.Indices = append(result.Mesh.Indices, i)
+		}
+		if hasLabels {
+			result.Labels = append(result.Labels, s.labels[t])
+		}
+	}
+
+	if regenerate && hasNormals {
+		result.Mesh.GenerateNormals(NormalOptions{AngleThreshold: simplifyNormalAngle})
+		if hasTangents {
+			result.Mesh.GenerateTangents()
+		}
+	}
+	return result
+}
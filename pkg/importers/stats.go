@@ -0,0 +1,122 @@
+package importers
+
+import "math"
+
+// BoundingBox is an axis-aligned box
+type BoundingBox struct {
+	Min [3]float64 `json:"min"`
+	Max [3]float64 `json:"max"`
+}
+
+// BoundingSphere encloses all vertices of a mesh
+type BoundingSphere struct {
+	Center [3]float64 `json:"center"`
+	Radius float64    `json:"radius"`
+}
+
+// MeshStats summarizes the geometry of an imported model
+type MeshStats struct {
+	Vertices    int            `json:"vertices"`
+	Triangles   int            `json:"triangles"`
+	SubMeshes   int            `json:"sub_meshes"`
+	Materials   int            `json:"materials"`
+	Bounds      BoundingBox    `json:"bounds"`
+	Sphere      BoundingSphere `json:"bounding_sphere"`
+	SurfaceArea float64        `json:"surface_area"`
+	// Closed reports whether every triangle edge is shared by exactly two
+	// triangles with opposite winding. Volume is only set for closed
+	// meshes.
+	Closed bool     `json:"closed"`
+	Volume *float64 `json:"volume,omitempty"`
+}
+
+// edgeKey is a directed triangle edge between two positions
+type edgeKey struct {
+	from, to [3]float64
+}
+
+// Stats computes vertex and triangle counts, bounding volumes, surface
+// area and, for closed meshes, the enclosed volume. Counts of sub-meshes
+// and materials are left to the caller.
+func (m *Mesh) Stats() MeshStats {
+	stats := MeshStats{
+		Vertices:  len(m.Vertices),
+		Triangles: m.TriangleCount(),
+	}
+	if len(m.Vertices) == 0 {
+		return stats
+	}
+
+	stats.Bounds = BoundingBox{Min: m.Vertices[0].Position, Max: m.Vertices[0].Position}
+	for _, vertex := range m.Vertices[1:] {
+		for i, v := range vertex.Position {
+			stats.Bounds.Min[i] = min(stats.Bounds.Min[i], v)
+			stats.Bounds.Max[i] = max(stats.Bounds.Max[i], v)
+		}
+	}
+
+	// A sphere around the box center is not minimal but is cheap and
+	// deterministic
+	center := scale3(add3(stats.Bounds.Min, stats.Bounds.Max), 0.5)
+	var radius float64
+	for _, vertex := range m.Vertices {
+		radius = max(radius, length3(sub3(vertex.Position, center)))
+	}
+	stats.Sphere = BoundingSphere{Center: center, Radius: radius}
+
+	if stats.Triangles == 0 {
+		return stats
+	}
+
+	// Edges are keyed by position since vertices are split at attribute
+	// seams. A closed mesh matches every directed edge with its reverse.
+	edges := make(map[edgeKey]int)
+	var volume float64
+	for t := 0; t < stats.Triangles; t++ {
+		a, b, c := m.trianglePositions(t)
+		stats.SurfaceArea += length3(cross3(sub3(b, a), sub3(c, a))) / 2
+		volume += dot3(a, cross3(b, c)) / 6
+
+		edges[edgeKey{a, b}]++
+		edges[edgeKey{b, c}]++
+		edges[edgeKey{c, a}]++
+	}
+
+	stats.Closed = true
+	for edge, count := range edges {
+		if count != 1 || edges[edgeKey{edge.to, edge.from}] != 1 {
+			stats.Closed = false
+			break
+		}
+	}
+	if stats.Closed {
+		volume = math.Abs(volume)
+		stats.Volume = &volume
+	}
+
+	return stats
+}
+
+// Stats computes the statistics of the imported mesh. Every face range
+// recorded at import counts as a sub-mesh, such as an OBJ group, a glTF
+// primitive, a COLLADA primitive element or the triangles of an FBX
+// geometry or 3MF object using one material. Materials are counted by the
+// distinct names the ranges reference. Formats without ranges, such as STL
+// and PLY, count as a single sub-mesh.
+func (vi *VertexImporter) Stats() MeshStats {
+	stats := vi.GetMesh().Stats()
+
+	materials := make(map[string]struct{})
+	for _, group := range vi.groups {
+		if group.Material != "" {
+			materials[group.Material] = struct{}{}
+		}
+	}
+	stats.Materials = len(materials)
+
+	stats.SubMeshes = len(vi.groups)
+	if stats.SubMeshes == 0 && len(vi.vertices) > 0 {
+		stats.SubMeshes = 1
+	}
+	return stats
+}
@@ -0,0 +1,166 @@
+package importers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+const (
+	stlHeaderSize   = 80
+	stlTriangleSize = 50
+)
+
+// ImportFromSTL imports an ASCII or binary STL file. Facets are welded
+// into an indexed mesh: corners with the same position and facet normal
+// share one vertex.
+func (vi *VertexImporter) ImportFromSTL(reader io.Reader) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read STL file: %w", err)
+	}
+
+	welder := newVertexWelder()
+	if IsBinarySTL(data) {
+		err = parseBinarySTL(data, welder)
+	} else {
+		err = parseASCIISTL(data, welder)
+	}
+	if err != nil {
+		return err
+	}
+
+	vi.appendIndexed(welder.vertices, welder.indices)
+	return nil
+}
+
+// IsBinarySTL reports whether data is a binary STL file. Its size must
+// match the triangle count in the header, which also identifies binary
+// files whose header starts with "solid".
+func IsBinarySTL(data []byte) bool {
+	if len(data) < stlHeaderSize+4 {
+		return false
+	}
+	count := uint64(binary.LittleEndian.Uint32(data[stlHeaderSize:]))
+	return uint64(len(data)) == stlHeaderSize+4+count*stlTriangleSize
+}
+
+// parseBinarySTL reads the triangles of a binary STL file
+func parseBinarySTL(data []byte, welder *vertexWelder) error {
+	count := int(binary.LittleEndian.Uint32(data[stlHeaderSize:]))
+	offset := stlHeaderSize + 4
+
+	readVector := func(at int) [3]float64 {
+		return [3]float64{
+			float64(math.Float32frombits(binary.LittleEndian.Uint32(data[at:]))),
+			float64(math.Float32frombits(binary.LittleEndian.Uint32(data[at+4:]))),
+			float64(math.Float32frombits(binary.LittleEndian.Uint32(data[at+8:]))),
+		}
+	}
+
+	for i := 0; i < count; i++ {
+		normal := readVector(offset)
+		corners := [][3]float64{readVector(offset + 12), readVector(offset + 24), readVector(offset + 36)}
+		welder.addFacet(normal, corners)
+		offset += stlTriangleSize
+	}
+
+	return nil
+}
+
+// parseASCIISTL reads the facets of one or more ASCII STL solids
+func parseASCIISTL(data []byte, welder *vertexWelder) error {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	if !bytes.HasPrefix(trimmed, []byte("solid")) {
+		if len(data) >= stlHeaderSize+4 {
+			return errors.New("invalid STL file: binary size does not match triangle count")
+		}
+		return errors.New("invalid STL file: missing solid header")
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	var (
+		inSolid bool
+		inFacet bool
+		normal  [3]float64
+		corners [][3]float64
+		line    int
+	)
+
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "solid":
+			if inSolid {
+				return fmt.Errorf("STL line %d: nested solid", line)
+			}
+			inSolid = true
+
+		case "facet":
+			if !inSolid || inFacet {
+				return fmt.Errorf("STL line %d: unexpected facet", line)
+			}
+			if len(fields) != 5 || fields[1] != "normal" {
+				return fmt.Errorf("STL line %d: invalid facet normal", line)
+			}
+			n, err := ParseVector3(fields[2:])
+			if err != nil {
+				return fmt.Errorf("STL line %d: invalid facet normal: %w", line, err)
+			}
+			inFacet, normal, corners = true, n, corners[:0]
+
+		case "outer", "endloop":
+			if !inFacet {
+				return fmt.Errorf("STL line %d: %s outside facet", line, fields[0])
+			}
+
+		case "vertex":
+			if !inFacet {
+				return fmt.Errorf("STL line %d: vertex outside facet", line)
+			}
+			position, err := ParseVector3(fields[1:])
+			if err != nil || len(fields) != 4 {
+				return fmt.Errorf("STL line %d: invalid vertex", line)
+			}
+			corners = append(corners, position)
+
+		case "endfacet":
+			if !inFacet {
+				return fmt.Errorf("STL line %d: unexpected endfacet", line)
+			}
+			if len(corners) < 3 {
+				return fmt.Errorf("STL line %d: facet has %d vertices", line, len(corners))
+			}
+			welder.addFacet(normal, corners)
+			inFacet = false
+
+		case "endsolid":
+			if !inSolid || inFacet {
+				return fmt.Errorf("STL line %d: unexpected endsolid", line)
+			}
+			inSolid = false
+
+		default:
+			return fmt.Errorf("STL line %d: unknown keyword %q", line, fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read STL file: %w", err)
+	}
+
+	if inFacet {
+		return errors.New("invalid STL file: unterminated facet")
+	}
+
+	return nil
+}
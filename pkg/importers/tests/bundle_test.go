@@ -0,0 +1,174 @@
+package importers_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+// texturedOBJ references a material library in a subdirectory
+const texturedOBJ = `mtllib materials/crate.mtl
+v 0 0 0
+v 1 0 0
+v 0 1 0
+vt 0 0
+vt 1 0
+vt 0 1
+usemtl Crate
+f 1/1 2/2 3/3
+`
+
+// crateMTL references its textures relative to its own directory
+const crateMTL = `newmtl Crate
+Kd 1 1 1
+map_Kd textures/crate.png
+map_Bump -bm 0.5 textures/crate_normal.png
+`
+
+func zipFiles(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestOpenBundle(t *testing.T) {
+	data := zipFiles(t, map[string]string{
+		"crate.obj":                             texturedOBJ,
+		"materials/crate.mtl":                   crateMTL,
+		"materials/textures/crate.png":          "png",
+		"./materials/textures/crate_normal.png": "png",
+	})
+
+	bundle, err := importers.OpenBundle(data)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"crate.obj",
+		"materials/crate.mtl",
+		"materials/textures/crate.png",
+		"materials/textures/crate_normal.png",
+	}, bundle.Paths())
+
+	content, ok := bundle.File("materials/crate.mtl")
+	require.True(t, ok)
+	assert.Equal(t, crateMTL, string(content))
+	_, ok = bundle.File("missing.png")
+	assert.False(t, ok)
+
+	main, format, err := bundle.MainFile()
+	require.NoError(t, err)
+	assert.Equal(t, "crate.obj", main)
+	assert.Equal(t, "obj", format)
+
+	// The archive written by Zip opens to the same bundle
+	archive, err := bundle.Zip()
+	require.NoError(t, err)
+	reopened, err := importers.OpenBundle(archive)
+	require.NoError(t, err)
+	assert.Equal(t, bundle.Paths(), reopened.Paths())
+}
+
+func TestNewBundleErrors(t *testing.T) {
+	_, err := importers.NewBundle(map[string][]byte{"../escape.obj": nil})
+	assert.Error(t, err)
+
+	_, err = importers.NewBundle(map[string][]byte{"a.obj": nil, "./a.obj": nil})
+	assert.Error(t, err)
+
+	bundle, err := importers.NewBundle(map[string][]byte{"readme.txt": nil})
+	require.NoError(t, err)
+	_, _, err = bundle.MainFile()
+	assert.ErrorIs(t, err, importers.ErrNoModelFile)
+
+	_, err = importers.OpenBundle([]byte("not a zip"))
+	assert.Error(t, err)
+
+	// The unpacked size is limited, not the archive size
+	archive := zipFiles(t, map[string]string{"zeros.obj": strings.Repeat("\x00", 1<<16)})
+	_, err = importers.OpenBundleLimit(archive, 1<<16-1)
+	assert.ErrorIs(t, err, importers.ErrBundleTooLarge)
+	_, err = importers.OpenBundleLimit(archive, 1<<16)
+	assert.NoError(t, err)
+}
+
+func TestBundleMainFilePriority(t *testing.T) {
+	bundle, err := importers.NewBundle(map[string][]byte{
+		"preview/model.stl": nil,
+		"model.stl":         nil,
+		"scene.gltf":        nil,
+	})
+	require.NoError(t, err)
+
+	main, format, err := bundle.MainFile()
+	require.NoError(t, err)
+	assert.Equal(t, "scene.gltf", main)
+	assert.Equal(t, "gltf", format)
+}
+
+func TestBundleImportOBJ(t *testing.T) {
+	bundle, err := importers.NewBundle(map[string][]byte{
+		"crate.obj":                    []byte(texturedOBJ),
+		"materials/crate.mtl":          []byte(crateMTL),
+		"materials/textures/crate.png": []byte("png"),
+	})
+	require.NoError(t, err)
+
+	result, err := bundle.Import("crate.obj", importers.ImportOptions{})
+	require.NoError(t, err)
+	assert.Len(t, result.Vertices.GetIndices(), 3)
+
+	crate, ok := result.Materials.GetMaterial("Crate")
+	require.True(t, ok)
+	assert.Equal(t, "textures/crate.png", crate.DiffuseMap)
+	assert.Equal(t, []string{"textures/crate_normal.png"}, result.Missing)
+	assert.Equal(t, map[string][]string{"materials/textures/crate.png": {"Crate"}}, result.Textures)
+
+	// A missing material library is reported as well
+	bundle, err = importers.NewBundle(map[string][]byte{"crate.obj": []byte(texturedOBJ)})
+	require.NoError(t, err)
+	result, err = bundle.Import("crate.obj", importers.ImportOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, result.Materials.GetMaterials())
+	assert.Equal(t, []string{"materials/crate.mtl"}, result.Missing)
+}
+
+func TestBundleImportGLTF(t *testing.T) {
+	doc := quadGLTF("buffers/quad.bin")
+	bundle, err := importers.NewBundle(map[string][]byte{
+		"scene.gltf":       encodeGLTF(t, doc),
+		"buffers/quad.bin": quadBuffer(),
+	})
+	require.NoError(t, err)
+
+	result, err := bundle.Import("scene.gltf", importers.ImportOptions{})
+	require.NoError(t, err)
+	assert.Len(t, result.Vertices.GetVertices(), 4)
+	assert.Equal(t, []string{"albedo.png"}, result.Missing)
+
+	// Without its buffer the import fails, still listing what is missing
+	bundle, err = importers.NewBundle(map[string][]byte{"scene.gltf": encodeGLTF(t, doc)})
+	require.NoError(t, err)
+	result, err = bundle.Import("scene.gltf", importers.ImportOptions{})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"albedo.png", "buffers/quad.bin"}, result.Missing)
+}
+
+func TestDetectFormatZip(t *testing.T) {
+	bundle := zipFiles(t, map[string]string{"crate.obj": texturedOBJ})
+	assert.Equal(t, "zip", importers.DetectFormat(bundle, int64(len(bundle))))
+
+	threeMF := zipFiles(t, map[string]string{"3D/3dmodel.model": "<model/>"})
+	assert.Equal(t, "3mf", importers.DetectFormat(threeMF, int64(len(threeMF))))
+}
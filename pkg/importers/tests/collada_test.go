@@ -0,0 +1,224 @@
+package importers_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+// colladaQuad has a quad as a polylist with shared position and normal
+// offsets, placed by a translated node holding a rotated child instance
+const colladaQuad = `<?xml version="1.0" encoding="utf-8"?>
+<COLLADA xmlns="http://www.collada.org/2005/11/COLLADASchema" version="1.4.1">
+  <library_images>
+    <image id="wood-img"><init_from>textures/wood.png</init_from></image>
+  </library_images>
+  <library_effects>
+    <effect id="wood-fx">
+      <profile_COMMON>
+        <newparam sid="wood-surface"><surface type="2D"><init_from>wood-img</init_from></surface></newparam>
+        <newparam sid="wood-sampler"><sampler2D><source>wood-surface</source></sampler2D></newparam>
+        <technique sid="common">
+          <phong>
+            <ambient><color>0.1 0.1 0.1 1</color></ambient>
+            <diffuse><texture texture="wood-sampler" texcoord="UVMap"/></diffuse>
+            <specular><color>0.5 0.5 0.5 1</color></specular>
+            <shininess><float>50</float></shininess>
+            <transparency><float>0.75</float></transparency>
+          </phong>
+        </technique>
+      </profile_COMMON>
+    </effect>
+    <effect id="paint-fx">
+      <profile_COMMON>
+        <technique sid="common">
+          <lambert>
+            <diffuse><color>0 0 1 1</color></diffuse>
+          </lambert>
+        </technique>
+      </profile_COMMON>
+    </effect>
+  </library_effects>
+  <library_materials>
+    <material id="wood-mat" name="Wood"><instance_effect url="#wood-fx"/></material>
+    <material id="paint-mat"><instance_effect url="#paint-fx"/></material>
+  </library_materials>
+  <library_geometries>
+    <geometry id="quad" name="Quad">
+      <mesh>
+        <source id="quad-pos">
+          <float_array id="quad-pos-array" count="12">0 0 0 1 0 0 1 1 0 0 1 0</float_array>
+          <technique_common><accessor source="#quad-pos-array" count="4" stride="3"/></technique_common>
+        </source>
+        <source id="quad-nrm">
+          <float_array id="quad-nrm-array" count="3">0 0 1</float_array>
+          <technique_common><accessor source="#quad-nrm-array" count="1" stride="3"/></technique_common>
+        </source>
+        <source id="quad-uv">
+          <float_array id="quad-uv-array" count="8">0 0 1 0 1 1 0 1</float_array>
+          <technique_common><accessor source="#quad-uv-array" count="4" stride="2"/></technique_common>
+        </source>
+        <vertices id="quad-vtx">
+          <input semantic="POSITION" source="#quad-pos"/>
+          <input semantic="TEXCOORD" source="#quad-uv"/>
+        </vertices>
+        <polylist material="wood" count="1">
+          <input semantic="VERTEX" source="#quad-vtx" offset="0"/>
+          <input semantic="NORMAL" source="#quad-nrm" offset="1"/>
+          <vcount>4</vcount>
+          <p>0 0 1 0 2 0 3 0</p>
+        </polylist>
+      </mesh>
+    </geometry>
+  </library_geometries>
+  <library_visual_scenes>
+    <visual_scene id="scene">
+      <node id="root">
+        <translate>10 0 0</translate>
+        <instance_geometry url="#quad"/>
+        <node id="child">
+          <matrix>0 -1 0 0  1 0 0 0  0 0 1 0  0 0 0 1</matrix>
+          <instance_geometry url="#quad"/>
+        </node>
+      </node>
+    </visual_scene>
+  </library_visual_scenes>
+  <scene><instance_visual_scene url="#scene"/></scene>
+</COLLADA>`
+
+func TestImportFromCOLLADA(t *testing.T) {
+	importer := importers.NewVertexImporter()
+	require.NoError(t, importer.ImportFromCOLLADA(strings.NewReader(colladaQuad)))
+
+	vertices := importer.GetVertices()
+	indices := importer.GetIndices()
+	// Two instances of a quad fanned into two triangles, sharing the
+	// corner at the node origin
+	require.Len(t, indices, 12)
+	require.Len(t, vertices, 7)
+	assert.Equal(t, []uint32{0, 1, 2, 0, 2, 3}, indices[:6])
+
+	assert.Equal(t, [3]float64{11, 1, 0}, vertices[2].Position)
+	assert.Equal(t, [3]float64{0, 0, 1}, vertices[2].Normal)
+	assert.Equal(t, [2]float64{1, 1}, vertices[2].TexCoords)
+
+	// The child matrix rotates 90 degrees around Z: (1, 0, 0) -> (0, 1, 0)
+	rotated := vertices[indices[7]].Position
+	assert.InDelta(t, 10, rotated[0], 1e-9)
+	assert.InDelta(t, 1, rotated[1], 1e-9)
+}
+
+func TestImportFromCOLLADATriangles(t *testing.T) {
+	doc := strings.Replace(colladaQuad, `<polylist material="wood" count="1">
+          <input semantic="VERTEX" source="#quad-vtx" offset="0"/>
+          <input semantic="NORMAL" source="#quad-nrm" offset="1"/>
+          <vcount>4</vcount>
+          <p>0 0 1 0 2 0 3 0</p>
+        </polylist>`, `<triangles count="2">
+          <input semantic="VERTEX" source="#quad-vtx" offset="0"/>
+          <p>0 1 2 0 2 3</p>
+        </triangles>`, 1)
+	doc = strings.Replace(doc, `<scene><instance_visual_scene url="#scene"/></scene>`, "", 1)
+	doc = strings.Replace(doc, `<library_visual_scenes>`, `<library_visual_scenes_unused>`, 1)
+	doc = strings.Replace(doc, `</library_visual_scenes>`, `</library_visual_scenes_unused>`, 1)
+
+	// Without a visual scene every geometry is imported as is
+	importer := importers.NewVertexImporter()
+	require.NoError(t, importer.ImportFromCOLLADA(strings.NewReader(doc)))
+	assert.Len(t, importer.GetVertices(), 4)
+	assert.Equal(t, []uint32{0, 1, 2, 0, 2, 3}, importer.GetIndices())
+}
+
+func TestImportFromCOLLADAGroups(t *testing.T) {
+	// The root instance binds the polylist's symbol; the child does not
+	doc := strings.Replace(colladaQuad, `<instance_geometry url="#quad"/>`, `<instance_geometry url="#quad">
+          <bind_material><technique_common>
+            <instance_material symbol="wood" target="#wood-mat"/>
+          </technique_common></bind_material>
+        </instance_geometry>`, 1)
+
+	importer := importers.NewVertexImporter()
+	require.NoError(t, importer.ImportFromCOLLADA(strings.NewReader(doc)))
+	assert.Equal(t, []importers.MeshGroup{
+		{Object: "Quad", Material: "Wood", Start: 0, Count: 6, Faces: 1},
+		{Object: "Quad", Start: 6, Count: 6, Faces: 1},
+	}, importer.GetGroups())
+}
+
+func TestImportFromCOLLADAErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     string
+		new     string
+		rawData string
+	}{
+		{name: "invalid xml", rawData: "<COLLADA"},
+		{name: "index out of range", old: "<p>0 0 1 0 2 0 3 0</p>", new: "<p>0 0 1 0 2 0 9 0</p>"},
+		{name: "index count mismatch", old: "<vcount>4</vcount>", new: "<vcount>5</vcount>"},
+		{name: "negative vcount", old: "<vcount>4</vcount>", new: "<vcount>-4 8</vcount>"},
+		{
+			name: "triangle count mismatch",
+			old:  `<polylist material="wood" count="1">`,
+			new:  `<triangles count="400000000"><input semantic="VERTEX" source="#quad-vtx" offset="0"/><p>0 1 2</p></triangles><polylist material="wood" count="1">`,
+		},
+		{name: "missing geometry", old: `<instance_geometry url="#quad"/>`, new: `<instance_geometry url="#missing"/>`},
+		{name: "missing source", old: `source="#quad-nrm" offset="1"`, new: `source="#nope" offset="1"`},
+		{name: "invalid matrix", old: "0 -1 0 0  1 0 0 0", new: "0 -1 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.rawData
+			if data == "" {
+				data = strings.Replace(colladaQuad, tt.old, tt.new, 1)
+			}
+			importer := importers.NewVertexImporter()
+			assert.Error(t, importer.ImportFromCOLLADA(strings.NewReader(data)))
+		})
+	}
+}
+
+func TestImportFromCOLLADAMaterials(t *testing.T) {
+	importer := importers.NewMaterialImporter()
+	require.NoError(t, importer.ImportFromCOLLADA(strings.NewReader(colladaQuad)))
+
+	wood, ok := importer.GetMaterial("Wood")
+	require.True(t, ok)
+	assert.Equal(t, [3]float64{0.1, 0.1, 0.1}, wood.AmbientColor)
+	assert.Equal(t, [3]float64{0.5, 0.5, 0.5}, wood.SpecularColor)
+	assert.Equal(t, 50.0, wood.Shininess)
+	assert.Equal(t, "textures/wood.png", wood.DiffuseMap)
+	assert.Equal(t, 0.75, wood.Transparency)
+
+	// Unnamed materials fall back to their id
+	paint, ok := importer.GetMaterial("paint-mat")
+	require.True(t, ok)
+	assert.Equal(t, [3]float64{0, 0, 1}, paint.DiffuseColor)
+	assert.Equal(t, 1.0, paint.Transparency)
+}
+
+func TestImportFromCOLLADAInstanceBudget(t *testing.T) {
+	// Every library node instantiates the previous one twice, so the
+	// visual scene holds 2^40 nodes
+	var nodes strings.Builder
+	nodes.WriteString(`<library_nodes><node id="n0"/>`)
+	for i := 1; i <= 40; i++ {
+		fmt.Fprintf(&nodes, `<node id="n%d"><instance_node url="#n%d"/><instance_node url="#n%d"/></node>`, i, i-1, i-1)
+	}
+	nodes.WriteString(`</library_nodes>`)
+
+	doc := strings.Replace(colladaQuad, `<library_visual_scenes>`, nodes.String()+`<library_visual_scenes>`, 1)
+	doc = strings.Replace(doc, `<instance_geometry url="#quad"/>
+        <node id="child">`, `<instance_node url="#n40"/>
+        <node id="child">`, 1)
+
+	importer := importers.NewVertexImporter()
+	err := importer.ImportFromCOLLADA(strings.NewReader(doc))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "instantiates more than")
+}
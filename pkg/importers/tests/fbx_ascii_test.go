@@ -0,0 +1,139 @@
+package importers_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+// asciiQuadFBX is the ASCII equivalent of fbxQuadScene
+const asciiQuadFBX = `; FBX 7.4.0 project file
+; ----------------------------------------------------
+
+FBXHeaderExtension:  {
+	FBXHeaderVersion: 1003
+	FBXVersion: 7400
+}
+
+; Object properties
+;------------------------------------------------------------------
+
+Objects:  {
+	Geometry: 100, "Geometry::Quad", "Mesh" {
+		Vertices: *12 {
+			a: 0,0,0,1,0,0,1,1,0,
+			0,1,0
+		}
+		PolygonVertexIndex: *4 {
+			a: 0,1,2,-4
+		}
+		LayerElementNormal: 0 {
+			Version: 101
+			Name: ""
+			MappingInformationType: "ByVertice"
+			ReferenceInformationType: "Direct"
+			Normals: *12 {
+				a: 0,0,1,0,0,1,0,0,1,0,0,1
+			}
+		}
+		LayerElementUV: 0 {
+			MappingInformationType: "ByPolygonVertex"
+			ReferenceInformationType: "IndexToDirect"
+			UV: *8 {
+				a: 0,0,1,0,1,1,0,1
+			}
+			UVIndex: *4 {
+				a: 0,1,2,3
+			}
+		}
+	}
+	Material: 200, "Material::Red", "" {
+		ShadingModel: "phong"
+		MultiLayer: 0
+		Properties70:  {
+			P: "DiffuseColor", "Color", "", "A",1,0,0
+			P: "SpecularColor", "Color", "", "A",0.5,0.5,0.5
+			P: "Shininess", "double", "Number", "",32
+			P: "Opacity", "double", "Number", "",0.5
+		}
+	}
+	Texture: 300, "Texture::Albedo", "" {
+		FileName: "C:/art/red.png"
+		RelativeFilename: "textures/red.png"
+	}
+}
+
+Connections:  {
+	C: "OO",100,0
+	C: "OP",300,200, "DiffuseColor"
+}
+`
+
+func TestParseFBXASCII(t *testing.T) {
+	doc, err := importers.ParseFBXASCII([]byte(asciiQuadFBX))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(7400), doc.Version)
+
+	geometry := doc.Node("Objects").Child("Geometry")
+	require.NotNil(t, geometry)
+	require.Len(t, geometry.Properties, 3)
+	assert.Equal(t, int64(100), geometry.Properties[0].Value)
+	assert.Equal(t, "Geometry::Quad", geometry.Properties[1].Value)
+
+	indices, ok := geometry.Child("PolygonVertexIndex").Property(0)
+	require.True(t, ok)
+	assert.Equal(t, []int32{0, 1, 2, -4}, indices.Value)
+
+	// Arrays with any real value are stored as float64
+	specular, ok := doc.Node("Objects").Child("Material").Child("Properties70").ChildrenNamed("P")[1].Property(4)
+	require.True(t, ok)
+	assert.Equal(t, 0.5, specular.Value)
+
+	// The version comment is used when the header has no FBXVersion
+	doc, err = importers.ParseFBXASCII([]byte("; FBX 7.3.0 project file\nObjects:  {\n}\n"))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(7300), doc.Version)
+}
+
+func TestParseFBXASCIIErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "empty", data: ""},
+		{name: "comments only", data: "; FBX 7.4.0 project file\n"},
+		{name: "missing key", data: "Objects {\n}\n"},
+		{name: "unclosed block", data: "Objects:  {\n\tGeometry: 1 {\n}\n"},
+		{name: "unterminated string", data: `Creator: "3DS`},
+		{name: "array length mismatch", data: "Vertices: *3 {\n\ta: 0,1\n}\n"},
+		{name: "non-numeric array", data: "Vertices: *2 {\n\ta: 0,\"x\"\n}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := importers.ParseFBXASCII([]byte(tt.data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestImportFromFBXDetectsEncoding(t *testing.T) {
+	binaryImporter := importers.NewVertexImporter()
+	require.NoError(t, binaryImporter.ImportFromFBX(bytes.NewReader(encodeFBX(7400, fbxQuadScene(), false))))
+
+	asciiImporter := importers.NewVertexImporter()
+	require.NoError(t, asciiImporter.ImportFromFBX(strings.NewReader(asciiQuadFBX)))
+	assert.Equal(t, binaryImporter.GetVertices(), asciiImporter.GetVertices())
+
+	binaryMaterials := importers.NewMaterialImporter()
+	require.NoError(t, binaryMaterials.ImportFromFBX(bytes.NewReader(encodeFBX(7400, fbxQuadScene(), false))))
+
+	asciiMaterials := importers.NewMaterialImporter()
+	require.NoError(t, asciiMaterials.ImportFromFBX(strings.NewReader(asciiQuadFBX)))
+	assert.Equal(t, binaryMaterials.GetMaterials(), asciiMaterials.GetMaterials())
+}
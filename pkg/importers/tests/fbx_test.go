@@ -0,0 +1,408 @@
+package importers_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+// fbxNode describes a node for encodeFBX. Property types follow their Go
+// types: int16 Y, bool C, int32 I, float32 F, float64 D, int64 L,
+// string S, []byte R and slices for the array types.
+type fbxNode struct {
+	name     string
+	props    []interface{}
+	children []fbxNode
+}
+
+// encodeFBX writes a binary FBX file. Versions from 7500 on use 64-bit
+// node headers; compress stores arrays zlib-encoded.
+func encodeFBX(version uint32, nodes []fbxNode, compress bool) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("Kaydara FBX Binary  \x00\x1a\x00")
+	binary.Write(&buf, binary.LittleEndian, version)
+
+	wide := version >= 7500
+	for _, node := range nodes {
+		writeFBXNode(&buf, node, wide, compress)
+	}
+	writeFBXNullRecord(&buf, wide)
+
+	// Footer padding, ignored by readers
+	buf.Write(make([]byte, 16))
+	return buf.Bytes()
+}
+
+func writeFBXNullRecord(buf *bytes.Buffer, wide bool) {
+	if wide {
+		buf.Write(make([]byte, 25))
+	} else {
+		buf.Write(make([]byte, 13))
+	}
+}
+
+func writeFBXNode(buf *bytes.Buffer, node fbxNode, wide, compress bool) {
+	var props bytes.Buffer
+	for _, prop := range node.props {
+		writeFBXProperty(&props, prop, compress)
+	}
+
+	start := buf.Len()
+	if wide {
+		buf.Write(make([]byte, 24))
+	} else {
+		buf.Write(make([]byte, 12))
+	}
+	buf.WriteByte(byte(len(node.name)))
+	buf.WriteString(node.name)
+	buf.Write(props.Bytes())
+
+	if len(node.children) > 0 {
+		for _, child := range node.children {
+			writeFBXNode(buf, child, wide, compress)
+		}
+		writeFBXNullRecord(buf, wide)
+	}
+
+	header := buf.Bytes()[start:]
+	if wide {
+		binary.LittleEndian.PutUint64(header[0:], uint64(buf.Len()))
+		binary.LittleEndian.PutUint64(header[8:], uint64(len(node.props)))
+		binary.LittleEndian.PutUint64(header[16:], uint64(props.Len()))
+	} else {
+		binary.LittleEndian.PutUint32(header[0:], uint32(buf.Len()))
+		binary.LittleEndian.PutUint32(header[4:], uint32(len(node.props)))
+		binary.LittleEndian.PutUint32(header[8:], uint32(props.Len()))
+	}
+}
+
+func writeFBXProperty(buf *bytes.Buffer, prop interface{}, compress bool) {
+	writeArray := func(code byte, length int, values interface{}) {
+		var raw bytes.Buffer
+		binary.Write(&raw, binary.LittleEndian, values)
+
+		data := raw.Bytes()
+		encoding := uint32(0)
+		if compress {
+			var compressed bytes.Buffer
+			zw := zlib.NewWriter(&compressed)
+			zw.Write(data)
+			zw.Close()
+			data = compressed.Bytes()
+			encoding = 1
+		}
+
+		buf.WriteByte(code)
+		binary.Write(buf, binary.LittleEndian, uint32(length))
+		binary.Write(buf, binary.LittleEndian, encoding)
+		binary.Write(buf, binary.LittleEndian, uint32(len(data)))
+		buf.Write(data)
+	}
+
+	switch v := prop.(type) {
+	case int16:
+		buf.WriteByte('Y')
+		binary.Write(buf, binary.LittleEndian, v)
+	case bool:
+		buf.WriteByte('C')
+		binary.Write(buf, binary.LittleEndian, v)
+	case int32:
+		buf.WriteByte('I')
+		binary.Write(buf, binary.LittleEndian, v)
+	case float32:
+		buf.WriteByte('F')
+		binary.Write(buf, binary.LittleEndian, v)
+	case float64:
+		buf.WriteByte('D')
+		binary.Write(buf, binary.LittleEndian, v)
+	case int64:
+		buf.WriteByte('L')
+		binary.Write(buf, binary.LittleEndian, v)
+	case string:
+		buf.WriteByte('S')
+		binary.Write(buf, binary.LittleEndian, uint32(len(v)))
+		buf.WriteString(v)
+	case []byte:
+		buf.WriteByte('R')
+		binary.Write(buf, binary.LittleEndian, uint32(len(v)))
+		buf.Write(v)
+	case []float32:
+		writeArray('f', len(v), v)
+	case []float64:
+		writeArray('d', len(v), v)
+	case []int64:
+		writeArray('l', len(v), v)
+	case []int32:
+		writeArray('i', len(v), v)
+	case []bool:
+		writeArray('b', len(v), v)
+	default:
+		panic("unsupported FBX test property type")
+	}
+}
+
+// fbxQuadGeometry returns a Geometry node for a unit quad with per-vertex
+// normals and indexed per-polygon-vertex UVs
+func fbxQuadGeometry() fbxNode {
+	return fbxNode{
+		name:  "Geometry",
+		props: []interface{}{int64(100), "Quad\x00\x01Geometry", "Mesh"},
+		children: []fbxNode{
+			{name: "Vertices", props: []interface{}{[]float64{0, 0, 0, 1, 0, 0, 1, 1, 0, 0, 1, 0}}},
+			{name: "PolygonVertexIndex", props: []interface{}{[]int32{0, 1, 2, -4}}},
+			{
+				name:  "LayerElementNormal",
+				props: []interface{}{int32(0)},
+				children: []fbxNode{
+					{name: "MappingInformationType", props: []interface{}{"ByVertice"}},
+					{name: "ReferenceInformationType", props: []interface{}{"Direct"}},
+					{name: "Normals", props: []interface{}{[]float64{0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 0, 1}}},
+				},
+			},
+			{
+				name:  "LayerElementUV",
+				props: []interface{}{int32(0)},
+				children: []fbxNode{
+					{name: "MappingInformationType", props: []interface{}{"ByPolygonVertex"}},
+					{name: "ReferenceInformationType", props: []interface{}{"IndexToDirect"}},
+					{name: "UV", props: []interface{}{[]float64{0, 0, 1, 0, 1, 1, 0, 1}}},
+					{name: "UVIndex", props: []interface{}{[]int32{0, 1, 2, 3}}},
+				},
+			},
+		},
+	}
+}
+
+// fbxQuadScene returns a scene with the quad geometry, a material and a
+// diffuse texture connected to it
+func fbxQuadScene() []fbxNode {
+	return []fbxNode{
+		{name: "FBXHeaderExtension", children: []fbxNode{
+			{name: "FBXVersion", props: []interface{}{int32(7400)}},
+		}},
+		{name: "Objects", children: []fbxNode{
+			fbxQuadGeometry(),
+			{
+				name:  "Material",
+				props: []interface{}{int64(200), "Red\x00\x01Material", ""},
+				children: []fbxNode{
+					{name: "Properties70", children: []fbxNode{
+						{name: "P", props: []interface{}{"DiffuseColor", "Color", "", "A", 1.0, 0.0, 0.0}},
+						{name: "P", props: []interface{}{"SpecularColor", "Color", "", "A", 0.5, 0.5, 0.5}},
+						{name: "P", props: []interface{}{"Shininess", "double", "Number", "", 32.0}},
+						{name: "P", props: []interface{}{"Opacity", "double", "Number", "", 0.5}},
+					}},
+				},
+			},
+			{
+				name:  "Texture",
+				props: []interface{}{int64(300), "Albedo\x00\x01Texture", ""},
+				children: []fbxNode{
+					{name: "FileName", props: []interface{}{"C:/art/red.png"}},
+					{name: "RelativeFilename", props: []interface{}{"textures/red.png"}},
+				},
+			},
+		}},
+		{name: "Connections", children: []fbxNode{
+			{name: "C", props: []interface{}{"OO", int64(100), int64(0)}},
+			{name: "C", props: []interface{}{"OP", int64(300), int64(200), "DiffuseColor"}},
+		}},
+	}
+}
+
+func TestParseFBXBinary(t *testing.T) {
+	for _, version := range []uint32{7400, 7500} {
+		for _, compress := range []bool{false, true} {
+			data := encodeFBX(version, []fbxNode{{
+				name: "Root",
+				props: []interface{}{
+					int16(-2), true, int32(7), float32(1.5), 2.5, int64(1) << 40,
+					"text", []byte{1, 2},
+					[]float32{1, 2}, []float64{3, 4}, []int64{5}, []int32{-6}, []bool{true, false},
+				},
+				children: []fbxNode{{name: "Child", children: []fbxNode{{name: "Leaf"}}}},
+			}}, compress)
+
+			doc, err := importers.ParseFBXBinary(data)
+			require.NoError(t, err)
+			assert.Equal(t, version, doc.Version)
+			require.Len(t, doc.Nodes, 1)
+
+			root := doc.Node("Root")
+			require.NotNil(t, root)
+			values := make([]interface{}, len(root.Properties))
+			for i, prop := range root.Properties {
+				values[i] = prop.Value
+			}
+			assert.Equal(t, []interface{}{
+				int16(-2), true, int32(7), float32(1.5), 2.5, int64(1) << 40,
+				"text", []byte{1, 2},
+				[]float32{1, 2}, []float64{3, 4}, []int64{5}, []int32{-6}, []bool{true, false},
+			}, values)
+
+			assert.NotNil(t, root.Child("Child").Child("Leaf"))
+			assert.Nil(t, root.Child("Missing"))
+		}
+	}
+}
+
+func TestParseFBXBinaryErrors(t *testing.T) {
+	valid := encodeFBX(7400, []fbxNode{{name: "Node", props: []interface{}{[]float64{1, 2, 3}}}}, true)
+
+	corrupt := append([]byte(nil), valid...)
+	// Damage the zlib stream after its header
+	corrupt[27+13+len("Node")+1+12+4] ^= 0xff
+
+	badEnd := append([]byte(nil), valid...)
+	binary.LittleEndian.PutUint32(badEnd[27:], uint32(len(valid)+100))
+
+	unknownType := encodeFBX(7400, []fbxNode{{name: "Node", props: []interface{}{int32(1)}}}, false)
+	unknownType[27+13+len("Node")] = 'Z'
+
+	// A plausibly compressed array of zeros inflating past the limit
+	var zeros bytes.Buffer
+	zw, err := zlib.NewWriterLevel(&zeros, zlib.BestSpeed)
+	require.NoError(t, err)
+	chunk := make([]byte, 1<<20)
+	const length = 257 << 20
+	for i := 0; i < length>>20; i++ {
+		zw.Write(chunk)
+	}
+	require.NoError(t, zw.Close())
+	var bomb bytes.Buffer
+	bomb.WriteByte('b')
+	binary.Write(&bomb, binary.LittleEndian, []uint32{length, 1, uint32(zeros.Len())})
+	bomb.Write(zeros.Bytes())
+	inflated := encodeFBX(7400, []fbxNode{{name: "Node", props: []interface{}{int32(1)}}}, false)
+	inflated = append(inflated[:27+13+len("Node")], append(bomb.Bytes(), inflated[27+13+len("Node")+5:]...)...)
+	binary.LittleEndian.PutUint32(inflated[27:], uint32(27+13+len("Node")+bomb.Len()))
+	binary.LittleEndian.PutUint32(inflated[27+8:], uint32(bomb.Len()))
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "old version", data: encodeFBX(6100, nil, false)},
+		{name: "truncated node", data: valid[:40]},
+		{name: "end offset past file", data: badEnd},
+		{name: "unknown property type", data: unknownType},
+		{name: "corrupt compressed array", data: corrupt},
+		{name: "compressed arrays too large", data: inflated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := importers.ParseFBXBinary(tt.data)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestImportFromFBXGeometry(t *testing.T) {
+	for _, version := range []uint32{7400, 7500} {
+		importer := importers.NewVertexImporter()
+		require.NoError(t, importer.ImportFromFBX(bytes.NewReader(encodeFBX(version, fbxQuadScene(), true))))
+
+		vertices := importer.GetVertices()
+		require.Len(t, vertices, 4)
+		assert.Equal(t, []uint32{0, 1, 2, 0, 2, 3}, importer.GetIndices())
+		assert.Equal(t, []importers.Polygon{{Corners: []uint32{0, 1, 2, 3}, Start: 0, Count: 6}}, importer.GetMesh().Polygons)
+		assert.Equal(t, [3]float64{1, 1, 0}, vertices[2].Position)
+		assert.Equal(t, [3]float64{0, 1, 0}, vertices[3].Position)
+		assert.Equal(t, [3]float64{0, 0, 1}, vertices[3].Normal)
+		assert.Equal(t, [2]float64{1, 1}, vertices[2].TexCoords)
+	}
+}
+
+func TestImportFromFBXLayerMappings(t *testing.T) {
+	geometry := fbxQuadGeometry()
+	geometry.children[2].children = []fbxNode{
+		{name: "MappingInformationType", props: []interface{}{"ByPolygon"}},
+		{name: "ReferenceInformationType", props: []interface{}{"Direct"}},
+		{name: "Normals", props: []interface{}{[]float64{0, 0, -1}}},
+	}
+	geometry.children[3].children = []fbxNode{
+		{name: "MappingInformationType", props: []interface{}{"AllSame"}},
+		{name: "ReferenceInformationType", props: []interface{}{"Direct"}},
+		{name: "UV", props: []interface{}{[]float32{0.5, 0.25}}},
+	}
+
+	importer := importers.NewVertexImporter()
+	data := encodeFBX(7400, []fbxNode{{name: "Objects", children: []fbxNode{geometry}}}, false)
+	require.NoError(t, importer.ImportFromFBX(bytes.NewReader(data)))
+
+	for _, vertex := range importer.GetVertices() {
+		assert.Equal(t, [3]float64{0, 0, -1}, vertex.Normal)
+		assert.Equal(t, [2]float64{0.5, 0.25}, vertex.TexCoords)
+	}
+
+	// Layer indices must stay within the value array
+	geometry.children[3].children = []fbxNode{
+		{name: "MappingInformationType", props: []interface{}{"ByPolygonVertex"}},
+		{name: "ReferenceInformationType", props: []interface{}{"IndexToDirect"}},
+		{name: "UV", props: []interface{}{[]float64{0, 0}}},
+		{name: "UVIndex", props: []interface{}{[]int32{0, 0, 0, 9}}},
+	}
+	importer = importers.NewVertexImporter()
+	data = encodeFBX(7400, []fbxNode{{name: "Objects", children: []fbxNode{geometry}}}, false)
+	assert.Error(t, importer.ImportFromFBX(bytes.NewReader(data)))
+}
+
+func TestImportFromFBXGroups(t *testing.T) {
+	// Two quads on a model with two materials, the first polygon using the
+	// second material
+	geometry := fbxQuadGeometry()
+	geometry.children[0].props = []interface{}{[]float64{0, 0, 0, 1, 0, 0, 1, 1, 0, 0, 1, 0, 0, 0, 1, 1, 0, 1, 1, 1, 1, 0, 1, 1}}
+	geometry.children[1].props = []interface{}{[]int32{0, 1, 2, -4, 4, 5, 6, -8}}
+	geometry.children = append(geometry.children[:2], fbxNode{
+		name:  "LayerElementMaterial",
+		props: []interface{}{int32(0)},
+		children: []fbxNode{
+			{name: "MappingInformationType", props: []interface{}{"ByPolygon"}},
+			{name: "ReferenceInformationType", props: []interface{}{"IndexToDirect"}},
+			{name: "Materials", props: []interface{}{[]int32{1, 0}}},
+		},
+	})
+	scene := []fbxNode{
+		{name: "Objects", children: []fbxNode{
+			geometry,
+			{name: "Model", props: []interface{}{int64(10), "Box\x00\x01Model", "Mesh"}},
+			{name: "Material", props: []interface{}{int64(20), "Red\x00\x01Material", ""}},
+			{name: "Material", props: []interface{}{int64(30), "Blue\x00\x01Material", ""}},
+		}},
+		{name: "Connections", children: []fbxNode{
+			{name: "C", props: []interface{}{"OO", int64(10), int64(0)}},
+			{name: "C", props: []interface{}{"OO", int64(100), int64(10)}},
+			{name: "C", props: []interface{}{"OO", int64(20), int64(10)}},
+			{name: "C", props: []interface{}{"OO", int64(30), int64(10)}},
+		}},
+	}
+
+	importer := importers.NewVertexImporter()
+	require.NoError(t, importer.ImportFromFBX(bytes.NewReader(encodeFBX(7400, scene, false))))
+	assert.Equal(t, []importers.MeshGroup{
+		{Object: "Quad", Material: "Blue", Start: 0, Count: 6, Faces: 1},
+		{Object: "Quad", Material: "Red", Start: 6, Count: 6, Faces: 1},
+	}, importer.GetGroups())
+	assert.Equal(t, [3]float64{0, 0, 0}, importer.GetVertices()[importer.GetIndices()[0]].Position)
+	assert.Equal(t, [3]float64{0, 0, 1}, importer.GetVertices()[importer.GetIndices()[6]].Position)
+}
+
+func TestImportFromFBXMaterialProperties(t *testing.T) {
+	importer := importers.NewMaterialImporter()
+	require.NoError(t, importer.ImportFromFBX(bytes.NewReader(encodeFBX(7500, fbxQuadScene(), false))))
+
+	material, ok := importer.GetMaterial("Red")
+	require.True(t, ok)
+	assert.Equal(t, [3]float64{1, 0, 0}, material.DiffuseColor)
+	assert.Equal(t, [3]float64{0.5, 0.5, 0.5}, material.SpecularColor)
+	assert.Equal(t, 32.0, material.Shininess)
+	assert.Equal(t, 0.5, material.Transparency)
+	assert.Equal(t, "textures/red.png", material.DiffuseMap)
+}
@@ -0,0 +1,128 @@
+package importers_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+// buildGLB assembles a GLB container from a JSON chunk and an optional BIN
+// chunk, padding both to 4 bytes as required by the spec
+func buildGLB(jsonChunk, binChunk []byte) []byte {
+	pad := func(data []byte, fill byte) []byte {
+		for len(data)%4 != 0 {
+			data = append(data, fill)
+		}
+		return data
+	}
+
+	jsonChunk = pad(append([]byte{}, jsonChunk...), ' ')
+	length := 12 + 8 + len(jsonChunk)
+	if binChunk != nil {
+		binChunk = pad(append([]byte{}, binChunk...), 0)
+		length += 8 + len(binChunk)
+	}
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, uint32(0x46546C67))
+	binary.Write(&buf, binary.LittleEndian, uint32(2))
+	binary.Write(&buf, binary.LittleEndian, uint32(length))
+	binary.Write(&buf, binary.LittleEndian, uint32(len(jsonChunk)))
+	binary.Write(&buf, binary.LittleEndian, uint32(0x4E4F534A))
+	buf.Write(jsonChunk)
+	if binChunk != nil {
+		binary.Write(&buf, binary.LittleEndian, uint32(len(binChunk)))
+		binary.Write(&buf, binary.LittleEndian, uint32(0x004E4942))
+		buf.Write(binChunk)
+	}
+	return buf.Bytes()
+}
+
+func quadGLB(t *testing.T) []byte {
+	doc := quadGLTF("")
+	doc["buffers"] = []interface{}{map[string]interface{}{"byteLength": 92}}
+	return buildGLB(encodeGLTF(t, doc), quadBuffer())
+}
+
+func TestImportFromGLB(t *testing.T) {
+	data := quadGLB(t)
+
+	vertexImporter := importers.NewVertexImporter()
+	require.NoError(t, vertexImporter.ImportFromGLB(bytes.NewReader(data), nil))
+	assert.Len(t, vertexImporter.GetVertices(), 4)
+	assert.Equal(t, []uint32{0, 1, 2, 0, 2, 3}, vertexImporter.GetIndices())
+
+	materialImporter := importers.NewMaterialImporter()
+	require.NoError(t, materialImporter.ImportFromGLB(bytes.NewReader(data), nil))
+	_, ok := materialImporter.GetMaterial("Painted")
+	assert.True(t, ok)
+}
+
+func TestParseGLB(t *testing.T) {
+	valid := quadGLB(t)
+
+	container, err := importers.ParseGLB(valid)
+	require.NoError(t, err)
+	assert.Equal(t, byte('{'), container.JSON[0])
+	assert.Len(t, container.BIN, 92)
+
+	withLength := func(data []byte, length uint32) []byte {
+		data = append([]byte{}, data...)
+		binary.LittleEndian.PutUint32(data[8:12], length)
+		return data
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{
+			name:    "truncated header",
+			data:    valid[:8],
+			wantErr: "truncated header",
+		},
+		{
+			name:    "invalid magic",
+			data:    append([]byte("glTX"), valid[4:]...),
+			wantErr: "invalid magic",
+		},
+		{
+			name:    "truncated file",
+			data:    valid[:len(valid)-4],
+			wantErr: "truncated file",
+		},
+		{
+			name:    "truncated chunk",
+			data:    withLength(valid[:len(valid)-8], uint32(len(valid)-8)),
+			wantErr: "chunk 1 at offset",
+		},
+		{
+			name: "misaligned chunk",
+			data: func() []byte {
+				data := append([]byte{}, valid...)
+				binary.LittleEndian.PutUint32(data[12:16], binary.LittleEndian.Uint32(data[12:16])-1)
+				return data
+			}(),
+			wantErr: "misaligned length",
+		},
+		{
+			name:    "BIN before JSON",
+			data:    withLength(append(append([]byte{}, valid[:12]...), 4, 0, 0, 0, 'B', 'I', 'N', 0, 0, 0, 0, 0), 24),
+			wantErr: "first chunk must be JSON",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := importers.ParseGLB(tt.data)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package importers_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/3FT-io/3DS/pkg/importers"
+	"github.com/3FT-io/3DS/pkg/testutil"
+)
+
+// quadBuffer returns a binary buffer holding a unit quad: four float32
+// positions at offset 0, four float32 UVs at offset 48 and six uint16
+// indices at offset 80
+func quadBuffer() []byte {
+	var buf bytes.Buffer
+	for _, v := range []float32{0, 0, 0, 1, 0, 0, 1, 1, 0, 0, 1, 0} {
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+	for _, v := range []float32{0, 1, 1, 1, 1, 0, 0, 0} {
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+	for _, v := range []uint16{0, 1, 2, 0, 2, 3} {
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+	return buf.Bytes()
+}
+
+// quadGLTF returns a glTF document drawing the quad through a translated
+// node, with the given buffer URI
+func quadGLTF(uri string) map[string]interface{} {
+	return map[string]interface{}{
+		"asset":  map[string]interface{}{"version": "2.0"},
+		"scene":  0,
+		"scenes": []interface{}{map[string]interface{}{"nodes": []int{0}}},
+		"nodes": []interface{}{
+			map[string]interface{}{"mesh": 0, "translation": []float64{10, 0, 0}},
+		},
+		"meshes": []interface{}{
+			map[string]interface{}{
+				"primitives": []interface{}{
+					map[string]interface{}{
+						"attributes": map[string]int{"POSITION": 0, "TEXCOORD_0": 1},
+						"indices":    2,
+						"material":   0,
+					},
+				},
+			},
+		},
+		"accessors": []interface{}{
+			map[string]interface{}{"bufferView": 0, "componentType": 5126, "count": 4, "type": "VEC3"},
+			map[string]interface{}{"bufferView": 1, "componentType": 5126, "count": 4, "type": "VEC2"},
+			map[string]interface{}{"bufferView": 2, "componentType": 5123, "count": 6, "type": "SCALAR"},
+		},
+		"bufferViews": []interface{}{
+			map[string]interface{}{"buffer": 0, "byteOffset": 0, "byteLength": 48},
+			map[string]interface{}{"buffer": 0, "byteOffset": 48, "byteLength": 32},
+			map[string]interface{}{"buffer": 0, "byteOffset": 80, "byteLength": 12},
+		},
+		"buffers": []interface{}{
+			map[string]interface{}{"uri": uri, "byteLength": 92},
+		},
+		"materials": []interface{}{
+			map[string]interface{}{
+				"name": "Painted",
+				"pbrMetallicRoughness": map[string]interface{}{
+					"baseColorFactor":  []float64{0.5, 0.25, 1, 0.8},
+					"baseColorTexture": map[string]int{"index": 0},
+					"metallicFactor":   0.1,
+				},
+				"alphaMode": "BLEND",
+			},
+		},
+		"textures": []interface{}{map[string]int{"source": 0}},
+		"images":   []interface{}{map[string]string{"uri": "albedo.png"}},
+	}
+}
+
+func encodeGLTF(t *testing.T, doc map[string]interface{}) []byte {
+	data, err := json.Marshal(doc)
+	require.NoError(t, err)
+	return data
+}
+
+func dataURI(data []byte) string {
+	return "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+func TestImportFromGLTF(t *testing.T) {
+	importer := importers.NewVertexImporter()
+	err := importer.ImportFromGLTF(bytes.NewReader(encodeGLTF(t, quadGLTF(dataURI(quadBuffer())))), nil)
+	require.NoError(t, err)
+
+	vertices := importer.GetVertices()
+	require.Len(t, vertices, 4)
+	assert.Equal(t, [3]float64{10, 0, 0}, vertices[0].Position)
+	assert.Equal(t, [3]float64{11, 1, 0}, vertices[2].Position)
+	// UVs are flipped to a bottom-left origin
+	assert.Equal(t, [2]float64{0, 0}, vertices[0].TexCoords)
+	assert.Equal(t, [2]float64{1, 1}, vertices[2].TexCoords)
+
+	assert.Equal(t, []uint32{0, 1, 2, 0, 2, 3}, importer.GetIndices())
+}
+
+func TestImportFromGLTFExternalBuffer(t *testing.T) {
+	dir, cleanup := testutil.CreateTempDir(t, "3ds-gltf-test-*")
+	defer cleanup()
+	testutil.CreateTestFile(t, dir, "quad data.bin", string(quadBuffer()))
+
+	doc := encodeGLTF(t, quadGLTF("quad%20data.bin"))
+
+	importer := importers.NewVertexImporter()
+	require.NoError(t, importer.ImportFromGLTF(bytes.NewReader(doc), importers.DirResolver(dir)))
+	assert.Len(t, importer.GetVertices(), 4)
+
+	// External buffers cannot be loaded without a resolver
+	importer = importers.NewVertexImporter()
+	assert.Error(t, importer.ImportFromGLTF(bytes.NewReader(doc), nil))
+
+	// Resources must not escape the resolver's directory
+	escaping := encodeGLTF(t, quadGLTF("../quad.bin"))
+	importer = importers.NewVertexImporter()
+	assert.Error(t, importer.ImportFromGLTF(bytes.NewReader(escaping), importers.DirResolver(dir)))
+}
+
+func TestImportFromGLTFSparseAccessor(t *testing.T) {
+	buffer := quadBuffer()
+	// Sparse substitution: move vertex 2 to (5, 5, 5)
+	var sparse bytes.Buffer
+	binary.Write(&sparse, binary.LittleEndian, uint16(2))
+	binary.Write(&sparse, binary.LittleEndian, uint16(0)) // padding
+	for _, v := range []float32{5, 5, 5} {
+		binary.Write(&sparse, binary.LittleEndian, v)
+	}
+	buffer = append(buffer, sparse.Bytes()...)
+
+	doc := quadGLTF(dataURI(buffer))
+	doc["buffers"] = []interface{}{map[string]interface{}{"uri": dataURI(buffer), "byteLength": len(buffer)}}
+	doc["bufferViews"] = append(doc["bufferViews"].([]interface{}),
+		map[string]interface{}{"buffer": 0, "byteOffset": 92, "byteLength": 2},
+		map[string]interface{}{"buffer": 0, "byteOffset": 96, "byteLength": 12},
+	)
+	doc["accessors"].([]interface{})[0].(map[string]interface{})["sparse"] = map[string]interface{}{
+		"count":   1,
+		"indices": map[string]int{"bufferView": 3, "componentType": 5123},
+		"values":  map[string]int{"bufferView": 4},
+	}
+
+	importer := importers.NewVertexImporter()
+	require.NoError(t, importer.ImportFromGLTF(bytes.NewReader(encodeGLTF(t, doc)), nil))
+
+	vertices := importer.GetVertices()
+	require.Len(t, vertices, 4)
+	assert.Equal(t, [3]float64{15, 5, 5}, vertices[2].Position)
+	assert.Equal(t, [3]float64{11, 0, 0}, vertices[1].Position)
+}
+
+func TestImportFromGLTFNodeTransforms(t *testing.T) {
+	doc := quadGLTF(dataURI(quadBuffer()))
+	// Parent rotates 90 degrees around Z, child scales by 2
+	s := math.Sqrt(0.5)
+	doc["nodes"] = []interface{}{
+		map[string]interface{}{"children": []int{1}, "rotation": []float64{0, 0, s, s}},
+		map[string]interface{}{"mesh": 0, "scale": []float64{2, 2, 2}},
+	}
+
+	importer := importers.NewVertexImporter()
+	require.NoError(t, importer.ImportFromGLTF(bytes.NewReader(encodeGLTF(t, doc)), nil))
+
+	vertices := importer.GetVertices()
+	require.Len(t, vertices, 4)
+	assert.InDelta(t, 0, vertices[1].Position[0], 1e-9)
+	assert.InDelta(t, 2, vertices[1].Position[1], 1e-9)
+}
+
+func TestImportFromGLTFErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(doc map[string]interface{})
+	}{
+		{
+			name: "unsupported version",
+			modify: func(doc map[string]interface{}) {
+				doc["asset"] = map[string]string{"version": "1.0"}
+			},
+		},
+		{
+			name: "missing POSITION",
+			modify: func(doc map[string]interface{}) {
+				prim := doc["meshes"].([]interface{})[0].(map[string]interface{})["primitives"].([]interface{})[0]
+				prim.(map[string]interface{})["attributes"] = map[string]int{"TEXCOORD_0": 1}
+			},
+		},
+		{
+			name: "accessor exceeds buffer view",
+			modify: func(doc map[string]interface{}) {
+				doc["accessors"].([]interface{})[0].(map[string]interface{})["count"] = 5
+			},
+		},
+		{
+			name: "buffer shorter than declared",
+			modify: func(doc map[string]interface{}) {
+				doc["buffers"].([]interface{})[0].(map[string]interface{})["byteLength"] = 200
+			},
+		},
+		{
+			name: "negative buffer length",
+			modify: func(doc map[string]interface{}) {
+				doc["buffers"].([]interface{})[0].(map[string]interface{})["byteLength"] = -5
+			},
+		},
+		{
+			name: "negative buffer view length",
+			modify: func(doc map[string]interface{}) {
+				doc["bufferViews"].([]interface{})[0].(map[string]interface{})["byteLength"] = -48
+			},
+		},
+		{
+			name: "buffer view offset overflow",
+			modify: func(doc map[string]interface{}) {
+				view := doc["bufferViews"].([]interface{})[0].(map[string]interface{})
+				view["byteOffset"] = math.MaxInt64 - 10
+				view["byteLength"] = 20
+			},
+		},
+		{
+			name: "negative stride",
+			modify: func(doc map[string]interface{}) {
+				doc["bufferViews"].([]interface{})[0].(map[string]interface{})["byteStride"] = -12
+			},
+		},
+		{
+			name: "huge count",
+			modify: func(doc map[string]interface{}) {
+				doc["accessors"].([]interface{})[0].(map[string]interface{})["count"] = int64(1e18)
+			},
+		},
+		{
+			name: "huge count without buffer view",
+			modify: func(doc map[string]interface{}) {
+				accessor := doc["accessors"].([]interface{})[0].(map[string]interface{})
+				delete(accessor, "bufferView")
+				accessor["count"] = int64(4e12)
+			},
+		},
+		{
+			name: "huge sparse count",
+			modify: func(doc map[string]interface{}) {
+				doc["accessors"].([]interface{})[0].(map[string]interface{})["sparse"] = map[string]interface{}{
+					"count":   int64(1e18),
+					"indices": map[string]interface{}{"bufferView": 2, "componentType": 5123},
+					"values":  map[string]interface{}{"bufferView": 0},
+				}
+			},
+		},
+		{
+			name: "node cycle",
+			modify: func(doc map[string]interface{}) {
+				doc["nodes"] = []interface{}{map[string]interface{}{"children": []int{0}}}
+			},
+		},
+		{
+			// Every node is listed twice as a child of the previous one
+			name: "shared children",
+			modify: func(doc map[string]interface{}) {
+				nodes := make([]interface{}, 22)
+				for i := range nodes {
+					nodes[i] = map[string]interface{}{"children": []int{i + 1, i + 1}}
+				}
+				nodes[len(nodes)-1] = map[string]interface{}{"mesh": 0}
+				doc["nodes"] = nodes
+			},
+		},
+		{
+			name: "required extension",
+			modify: func(doc map[string]interface{}) {
+				doc["extensionsRequired"] = []string{"KHR_draco_mesh_compression"}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := quadGLTF(dataURI(quadBuffer()))
+			tt.modify(doc)
+
+			importer := importers.NewVertexImporter()
+			assert.Error(t, importer.ImportFromGLTF(bytes.NewReader(encodeGLTF(t, doc)), nil))
+		})
+	}
+}
+
+func TestImportFromGLTFMaterial(t *testing.T) {
+	importer := importers.NewMaterialImporter()
+	require.NoError(t, importer.ImportFromGLTF(bytes.NewReader(encodeGLTF(t, quadGLTF(dataURI(quadBuffer())))), nil))
+
+	material, ok := importer.GetMaterial("Painted")
+	require.True(t, ok)
+	require.NotNil(t, material.PBR)
+	assert.Equal(t, [4]float64{0.5, 0.25, 1, 0.8}, material.PBR.BaseColorFactor)
+	assert.Equal(t, "albedo.png", material.PBR.BaseColorTexture)
+	assert.Equal(t, 0.1, material.PBR.MetallicFactor)
+	assert.Equal(t, 1.0, material.PBR.RoughnessFactor)
+	assert.Equal(t, "BLEND", material.PBR.AlphaMode)
+
+	assert.Equal(t, [3]float64{0.5, 0.25, 1}, material.DiffuseColor)
+	assert.Equal(t, "albedo.png", material.DiffuseMap)
+	assert.Equal(t, 0.8, material.Transparency)
+}
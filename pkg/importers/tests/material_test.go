@@ -150,26 +150,5 @@ func TestImportFromFBXMaterial(t *testing.T) {
 }
 
 func createTestFBXMaterial() []byte {
-	// Reuse the FBX header and version from vertex test
-	header := []byte("Kaydara FBX Binary  ")
-	header = append(header, []byte{0x00, 0x1A, 0x00}...)
-	version := []byte{0xE8, 0x1C, 0x00, 0x00}
-
-	// Create material node data
-	materialData := []byte{
-		// Node header
-		0x00, 0x00, 0x02, 0x00, // endOffset
-		0x00, 0x00, 0x00, 0x00, // numProperties
-		0x00, 0x00, 0x00, 0x00, // propertyListLen
-		0x08, // nameLen
-		'M', 'a', 't', 'e', 'r', 'i', 'a', 'l',
-	}
-
-	// Combine all parts
-	data := make([]byte, 0, len(header)+len(version)+len(materialData))
-	data = append(data, header...)
-	data = append(data, version...)
-	data = append(data, materialData...)
-
-	return data
+	return encodeFBX(7400, fbxQuadScene(), false)
 }
@@ -0,0 +1,140 @@
+package importers_test
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+// cubeWithoutNormals strips the normal references from cubeOBJ
+func cubeWithoutNormals() string {
+	return regexp.MustCompile(`//\d`).ReplaceAllString(cubeOBJ, "")
+}
+
+func TestGenerateNormals(t *testing.T) {
+	diagonal := 1 / math.Sqrt(3)
+
+	tests := []struct {
+		name     string
+		obj      string
+		normals  importers.NormalOptions
+		vertices int
+		check    func(t *testing.T, v importers.Vertex)
+	}{
+		{
+			name:     "smooth",
+			obj:      cubeWithoutNormals(),
+			vertices: 8,
+			check: func(t *testing.T, v importers.Vertex) {
+				for i := range v.Normal {
+					assert.InDelta(t, math.Copysign(diagonal, v.Position[i]-0.5), v.Normal[i], 1e-9)
+				}
+			},
+		},
+		{
+			name:     "angle threshold",
+			obj:      cubeWithoutNormals(),
+			normals:  importers.NormalOptions{AngleThreshold: 60},
+			vertices: 24,
+			check:    assertAxisNormal,
+		},
+		{
+			name:     "flat",
+			obj:      cubeWithoutNormals(),
+			normals:  importers.NormalOptions{Flat: true},
+			vertices: 24,
+			check:    assertAxisNormal,
+		},
+		{
+			name:     "smoothing group off",
+			obj:      "s off\n" + cubeWithoutNormals(),
+			vertices: 24,
+			check:    assertAxisNormal,
+		},
+		{
+			name:     "existing normals kept",
+			obj:      cubeOBJ,
+			vertices: 24,
+			check:    assertAxisNormal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			importer := importers.NewVertexImporter()
+			opts := importers.ImportOptions{GenerateNormals: true, Normals: tt.normals}
+			require.NoError(t, importer.Import("obj", strings.NewReader(tt.obj), opts))
+
+			mesh := importer.GetMesh()
+			assert.Len(t, mesh.Vertices, tt.vertices)
+			assert.Len(t, mesh.Indices, 36)
+			for _, vertex := range mesh.Vertices {
+				assert.InDelta(t, 1, math.Sqrt(vertex.Normal[0]*vertex.Normal[0]+vertex.Normal[1]*vertex.Normal[1]+vertex.Normal[2]*vertex.Normal[2]), 1e-9)
+				tt.check(t, vertex)
+			}
+
+			// Polygon corners follow the split vertices
+			for _, polygon := range mesh.Polygons {
+				for _, corner := range polygon.Corners {
+					assert.Less(t, int(corner), len(mesh.Vertices))
+				}
+			}
+		})
+	}
+}
+
+// assertAxisNormal checks that a cube corner has the normal of one of its
+// faces
+func assertAxisNormal(t *testing.T, v importers.Vertex) {
+	axes := 0
+	for i, n := range v.Normal {
+		if n != 0 {
+			axes++
+			assert.Equal(t, math.Copysign(1, v.Position[i]-0.5), n)
+		}
+	}
+	assert.Equal(t, 1, axes)
+}
+
+func TestGenerateTangents(t *testing.T) {
+	quad := "v 0 0 0\nv 1 0 0\nv 1 1 0\nv 0 1 0\nvt 0 0\nvt 1 0\nvt 1 1\nvt 0 1\nf 1/1 2/2 3/3 4/4\n"
+	mirrored := "v 0 0 0\nv 1 0 0\nv 1 1 0\nv 0 1 0\nvt 1 0\nvt 0 0\nvt 0 1\nvt 1 1\nf 1/1 2/2 3/3 4/4\n"
+
+	tests := []struct {
+		name    string
+		obj     string
+		tangent [4]float64
+	}{
+		{name: "aligned", obj: quad, tangent: [4]float64{1, 0, 0, 1}},
+		{name: "mirrored", obj: mirrored, tangent: [4]float64{-1, 0, 0, -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			importer := importers.NewVertexImporter()
+			require.NoError(t, importer.Import("obj", strings.NewReader(tt.obj), importers.ImportOptions{GenerateTangents: true}))
+
+			vertices := importer.GetVertices()
+			require.Len(t, vertices, 4)
+			for _, vertex := range vertices {
+				assert.Equal(t, [3]float64{0, 0, 1}, vertex.Normal)
+				assert.True(t, vertex.HasTangent)
+				for i := range tt.tangent {
+					assert.InDelta(t, tt.tangent[i], vertex.Tangent[i], 1e-9)
+				}
+			}
+		})
+	}
+}
+
+func TestImportUnsupportedFormat(t *testing.T) {
+	importer := importers.NewVertexImporter()
+	err := importer.Import("blend", strings.NewReader(""), importers.ImportOptions{})
+	assert.ErrorIs(t, err, importers.ErrUnsupportedFormat)
+}
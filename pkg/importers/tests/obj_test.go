@@ -0,0 +1,193 @@
+package importers_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+// groupedOBJ has two objects, the second split by a group and two materials
+const groupedOBJ = `mtllib chair.mtl extra.mtl
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+o Seat
+usemtl Wood
+s 1
+f 1 2 3
+f 1 3 4
+o Legs
+g front back
+usemtl Metal
+f 1 2 3
+s off
+usemtl Chrome
+f 1 3 4
+`
+
+const groupedMTL = `newmtl Wood
+Kd 0.5 0.3 0.1
+newmtl Metal
+Kd 0.7 0.7 0.7
+`
+
+func TestImportFromOBJGroups(t *testing.T) {
+	importer := importers.NewVertexImporter()
+	require.NoError(t, importer.ImportFromOBJ(strings.NewReader(groupedOBJ)))
+
+	assert.Equal(t, []string{"chair.mtl", "extra.mtl"}, importer.GetMaterialLibraries())
+	// Every face uses the same four corners
+	assert.Len(t, importer.GetVertices(), 4)
+	assert.Len(t, importer.GetIndices(), 12)
+
+	groups := importer.GetGroups()
+	require.Len(t, groups, 3)
+
+	assert.Equal(t, importers.MeshGroup{
+		Object: "Seat", Material: "Wood", SmoothingGroup: 1, Start: 0, Count: 6, Faces: 2,
+	}, groups[0])
+	assert.Equal(t, "Seat", groups[0].Name())
+
+	assert.Equal(t, "Legs", groups[1].Object)
+	assert.Equal(t, "front back", groups[1].Name())
+	assert.Equal(t, "Metal", groups[1].Material)
+	assert.Equal(t, 1, groups[1].SmoothingGroup)
+	assert.Equal(t, 6, groups[1].Start)
+	assert.Equal(t, 3, groups[1].Count)
+
+	assert.Equal(t, "Chrome", groups[2].Material)
+	assert.Equal(t, 0, groups[2].SmoothingGroup)
+	assert.Equal(t, 9, groups[2].Start)
+}
+
+func TestImportFromOBJResolveMaterials(t *testing.T) {
+	importer := importers.NewVertexImporter()
+	require.NoError(t, importer.ImportFromOBJ(strings.NewReader(groupedOBJ)))
+
+	materials := importers.NewMaterialImporter()
+	require.NoError(t, materials.ImportFromOBJ(strings.NewReader(groupedMTL)))
+
+	resolved, missing := importer.ResolveMaterials(materials)
+	assert.Len(t, resolved, 2)
+	assert.Equal(t, [3]float64{0.5, 0.3, 0.1}, resolved["Wood"].DiffuseColor)
+	assert.Equal(t, []string{"Chrome"}, missing)
+}
+
+func TestImportFromOBJInvalidSmoothingGroup(t *testing.T) {
+	importer := importers.NewVertexImporter()
+	assert.Error(t, importer.ImportFromOBJ(strings.NewReader("s on\n")))
+}
+
+// cubeOBJ has eight corners and one normal per quad face
+const cubeOBJ = `v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+v 0 0 1
+v 1 0 1
+v 1 1 1
+v 0 1 1
+vn 0 0 -1
+vn 0 0 1
+vn 0 -1 0
+vn 0 1 0
+vn -1 0 0
+vn 1 0 0
+f 1//1 4//1 3//1 2//1
+f 5//2 6//2 7//2 8//2
+f 1//3 2//3 6//3 5//3
+f 4//4 8//4 7//4 3//4
+f 1//5 5//5 8//5 4//5
+f 2//6 3//6 7//6 6//6
+`
+
+func TestImportFromOBJWelding(t *testing.T) {
+	importer := importers.NewVertexImporter()
+	require.NoError(t, importer.ImportFromOBJ(strings.NewReader(cubeOBJ)))
+
+	// Corners are only shared within a face since each face has its own normal
+	mesh := importer.GetMesh()
+	assert.Len(t, mesh.Vertices, 24)
+	assert.Len(t, mesh.Indices, 36)
+	assert.Equal(t, 12, mesh.TriangleCount())
+	assert.Equal(t, []uint32{0, 1, 2, 0, 2, 3}, mesh.Indices[:6])
+
+	// Without normals the corners are shared by every face
+	importer = importers.NewVertexImporter()
+	require.NoError(t, importer.ImportFromOBJ(strings.NewReader(cubeWithoutNormals())))
+	assert.Len(t, importer.GetVertices(), 8)
+	assert.Len(t, importer.GetIndices(), 36)
+}
+
+// triangleArea returns the signed area of a triangle in the XY plane
+func triangleArea(vertices []importers.Vertex, a, b, c uint32) float64 {
+	pa, pb, pc := vertices[a].Position, vertices[b].Position, vertices[c].Position
+	return ((pb[0]-pa[0])*(pc[1]-pa[1]) - (pb[1]-pa[1])*(pc[0]-pa[0])) / 2
+}
+
+func TestImportFromOBJTriangulation(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  string
+		area float64
+	}{
+		{
+			name: "convex quad",
+			obj:  "v 0 0 0\nv 2 0 0\nv 2 2 0\nv 0 2 0\nf 1 2 3 4\n",
+			area: 4,
+		},
+		{
+			// An L shape whose reflex corner breaks a fan from the first corner
+			name: "concave L",
+			obj:  "v 0 0 0\nv 2 0 0\nv 2 1 0\nv 1 1 0\nv 1 2 0\nv 0 2 0\nf 3 4 5 6 1 2\n",
+			area: 3,
+		},
+		{
+			name: "concave arrow clockwise",
+			obj:  "v 0 0 0\nv 2 1 0\nv 0 2 0\nv 1 1 0\nf 4 3 2 1\n",
+			area: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			importer := importers.NewVertexImporter()
+			require.NoError(t, importer.ImportFromOBJ(strings.NewReader(tt.obj)))
+
+			mesh := importer.GetMesh()
+			require.Len(t, mesh.Polygons, 1)
+			polygon := mesh.Polygons[0]
+			assert.Equal(t, len(mesh.Vertices), len(polygon.Corners))
+			assert.Equal(t, (len(polygon.Corners)-2)*3, polygon.Count)
+
+			// Every triangle keeps the winding of the polygon and together
+			// they cover its area exactly
+			var total float64
+			for i := 0; i < len(mesh.Indices); i += 3 {
+				area := triangleArea(mesh.Vertices, mesh.Indices[i], mesh.Indices[i+1], mesh.Indices[i+2])
+				assert.Greater(t, area*tt.area, 0.0)
+				total += area
+			}
+			assert.InDelta(t, tt.area, total, 1e-9)
+		})
+	}
+}
+
+func TestImportFromOBJVertexColors(t *testing.T) {
+	importer := importers.NewVertexImporter()
+	require.NoError(t, importer.ImportFromOBJ(strings.NewReader("v 0 0 0 1 0.5 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\n")))
+
+	vertices := importer.GetVertices()
+	require.Len(t, vertices, 3)
+	assert.True(t, vertices[0].HasColor)
+	assert.Equal(t, [4]float64{1, 0.5, 0, 1}, vertices[0].Color)
+	assert.False(t, vertices[1].HasColor)
+
+	err := importers.NewVertexImporter().ImportFromOBJ(strings.NewReader("v 0 0 0 red green blue\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,97 @@
+package importers_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+const pbrMTL = `newmtl Brass
+Pr 0.3
+Pm 1
+Kd 0.8 0.6 0.2
+map_Kd brass.png
+map_Pr brass_rough.png
+map_Pm brass_metal.png
+norm brass_normal.png
+Ke 0.1 0.1 0
+map_Ke glow.png
+d 0.5
+
+newmtl Plastic
+Kd 0.2 0.2 0.9
+Ns 98
+`
+
+func TestImportFromMTLPBR(t *testing.T) {
+	importer := importers.NewMaterialImporter()
+	require.NoError(t, importer.ImportFromOBJ(strings.NewReader(pbrMTL)))
+
+	brass, ok := importer.GetMaterial("Brass")
+	require.True(t, ok)
+	assert.Equal(t, [3]float64{0.1, 0.1, 0}, brass.EmissiveColor)
+	assert.Equal(t, "glow.png", brass.EmissiveMap)
+	assert.Equal(t, "brass_normal.png", brass.NormalMap)
+
+	require.NotNil(t, brass.PBR)
+	assert.Equal(t, 0.3, brass.PBR.RoughnessFactor)
+	assert.Equal(t, 1.0, brass.PBR.MetallicFactor)
+	assert.Equal(t, "brass_rough.png", brass.PBR.RoughnessTexture)
+	assert.Equal(t, "brass_metal.png", brass.PBR.MetallicTexture)
+
+	// Phong statements after the PBR ones still complete the parameters
+	assert.Equal(t, [4]float64{0.8, 0.6, 0.2, 0.5}, brass.PBR.BaseColorFactor)
+	assert.Equal(t, "brass.png", brass.PBR.BaseColorTexture)
+	assert.Equal(t, "brass_normal.png", brass.PBR.NormalTexture)
+	assert.Equal(t, [3]float64{0.1, 0.1, 0}, brass.PBR.EmissiveFactor)
+	assert.Equal(t, "BLEND", brass.PBR.AlphaMode)
+
+	// Materials without PBR statements keep only their Phong fields
+	plastic, ok := importer.GetMaterial("Plastic")
+	require.True(t, ok)
+	assert.Nil(t, plastic.PBR)
+}
+
+func TestMaterialToPBR(t *testing.T) {
+	material := &importers.Material{
+		DiffuseColor: [3]float64{0.2, 0.2, 0.9},
+		Shininess:    98,
+		DiffuseMap:   "plastic.png",
+	}
+
+	pbr := material.ToPBR()
+	assert.Equal(t, [4]float64{0.2, 0.2, 0.9, 1}, pbr.BaseColorFactor)
+	assert.Equal(t, "plastic.png", pbr.BaseColorTexture)
+	assert.Equal(t, 0.0, pbr.MetallicFactor)
+	assert.InDelta(t, 0.376, pbr.RoughnessFactor, 1e-3)
+	assert.Equal(t, "OPAQUE", pbr.AlphaMode)
+
+	// Converting back restores the Phong exponent
+	phong := pbr.ToPhong("Plastic")
+	assert.Equal(t, "Plastic", phong.Name)
+	assert.Equal(t, material.DiffuseColor, phong.DiffuseColor)
+	assert.InDelta(t, 98, phong.Shininess, 1e-9)
+	assert.Equal(t, [3]float64{0.04, 0.04, 0.04}, phong.SpecularColor)
+	assert.Equal(t, 1.0, phong.Transparency)
+	assert.Same(t, pbr, phong.PBR)
+}
+
+func TestPBRToPhongMetal(t *testing.T) {
+	pbr := &importers.PBRMaterial{
+		BaseColorFactor: [4]float64{1, 0.8, 0.4, 0.3},
+		MetallicFactor:  1,
+		RoughnessFactor: 0,
+		AlphaMode:       "OPAQUE",
+	}
+
+	phong := pbr.ToPhong("Gold")
+	assert.Equal(t, [3]float64{1, 0.8, 0.4}, phong.SpecularColor)
+	assert.Equal(t, 1.0, phong.Transparency)
+	// Mirror-like roughness is clamped to keep the exponent finite
+	assert.InDelta(t, importers.RoughnessToShininess(0.01), phong.Shininess, 1e-9)
+	assert.Greater(t, phong.Shininess, 1e6)
+}
@@ -0,0 +1,121 @@
+package importers_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+const asciiQuadPLY = `ply
+format ascii 1.0
+comment exported by scanner
+element vertex 4
+property float x
+property float y
+property float z
+property uchar red
+property uchar green
+property uchar blue
+property float confidence
+element face 1
+property uchar flags
+property list uchar int vertex_indices
+end_header
+0 0 0 255 0 0 0.9
+1 0 0 0 255 0 0.8
+1 1 0 0 0 255 0.7
+0 1 0 255 255 255 0.6
+7 4 0 1 2 3
+`
+
+// encodeBinaryPLY writes a binary point cloud with double positions,
+// float normals and ushort colors
+func encodeBinaryPLY(order binary.ByteOrder, format string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("ply\nformat " + format + " 1.0\n")
+	buf.WriteString("element vertex 2\n")
+	buf.WriteString("property double x\nproperty double y\nproperty double z\n")
+	buf.WriteString("property float nx\nproperty float ny\nproperty float nz\n")
+	buf.WriteString("property ushort red\nproperty ushort green\nproperty ushort blue\nproperty ushort alpha\n")
+	buf.WriteString("element camera 1\nproperty list uchar float pose\n")
+	buf.WriteString("end_header\n")
+
+	for i := 0; i < 2; i++ {
+		binary.Write(&buf, order, [3]float64{float64(i), 2, 3})
+		binary.Write(&buf, order, [3]float32{0, 1, 0})
+		binary.Write(&buf, order, [4]uint16{65535, 0, 0, 65535})
+	}
+	buf.WriteByte(2)
+	binary.Write(&buf, order, [2]float32{1, 2})
+	return buf.Bytes()
+}
+
+func TestImportFromPLY(t *testing.T) {
+	importer := importers.NewVertexImporter()
+	require.NoError(t, importer.ImportFromPLY(strings.NewReader(asciiQuadPLY)))
+
+	assert.Equal(t, importers.PrimitiveTriangles, importer.GetPrimitiveType())
+	assert.Equal(t, []uint32{0, 1, 2, 0, 2, 3}, importer.GetIndices())
+
+	vertices := importer.GetVertices()
+	require.Len(t, vertices, 4)
+	assert.Equal(t, [3]float64{1, 1, 0}, vertices[2].Position)
+	assert.True(t, vertices[2].HasColor)
+	assert.Equal(t, [4]float64{0, 0, 1, 1}, vertices[2].Color)
+}
+
+func TestImportFromPLYBinaryPointCloud(t *testing.T) {
+	for _, tt := range []struct {
+		format string
+		order  binary.ByteOrder
+	}{
+		{"binary_little_endian", binary.LittleEndian},
+		{"binary_big_endian", binary.BigEndian},
+	} {
+		t.Run(tt.format, func(t *testing.T) {
+			importer := importers.NewVertexImporter()
+			require.NoError(t, importer.ImportFromPLY(bytes.NewReader(encodeBinaryPLY(tt.order, tt.format))))
+
+			assert.Equal(t, importers.PrimitivePoints, importer.GetPrimitiveType())
+			assert.Empty(t, importer.GetIndices())
+
+			vertices := importer.GetVertices()
+			require.Len(t, vertices, 2)
+			assert.Equal(t, [3]float64{1, 2, 3}, vertices[1].Position)
+			assert.Equal(t, [3]float64{0, 1, 0}, vertices[1].Normal)
+			assert.Equal(t, [4]float64{1, 0, 0, 1}, vertices[1].Color)
+		})
+	}
+}
+
+func TestImportFromPLYErrors(t *testing.T) {
+	header := "ply\nformat ascii 1.0\nelement vertex 3\nproperty float x\nproperty float y\nproperty float z\n"
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "missing magic", data: "format ascii 1.0\nend_header\n"},
+		{name: "missing end_header", data: header},
+		{name: "unknown format", data: "ply\nformat binary 1.0\nend_header\n"},
+		{name: "unknown type", data: "ply\nformat ascii 1.0\nelement vertex 1\nproperty half x\nend_header\n"},
+		{name: "truncated body", data: header + "end_header\n0 0 0\n1 0 0\n"},
+		{name: "invalid number", data: header + "end_header\n0 0 0\n1 0 0\n1 one 0\n"},
+		{
+			name: "face index out of range",
+			data: header + "element face 1\nproperty list uchar int vertex_indices\nend_header\n0 0 0\n1 0 0\n1 1 0\n3 0 1 5\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			importer := importers.NewVertexImporter()
+			assert.Error(t, importer.ImportFromPLY(strings.NewReader(tt.data)))
+		})
+	}
+}
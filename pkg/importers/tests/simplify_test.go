@@ -0,0 +1,181 @@
+package importers_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+// gridMesh is a flat n by n grid of quads on the unit square. Vertices
+// right of the middle column get their own texture coordinates if seam is
+// set, so the middle column becomes a UV seam.
+func gridMesh(n int, seam bool) *importers.Mesh {
+	mesh := &importers.Mesh{Primitive: importers.PrimitiveTriangles}
+	index := make(map[[3]int]uint32)
+	vertex := func(i, j, side int) uint32 {
+		if !seam {
+			side = 0
+		}
+		key := [3]int{i, j, side}
+		if v, ok := index[key]; ok {
+			return v
+		}
+		x, y := float64(i)/float64(n), float64(j)/float64(n)
+		v := importers.Vertex{
+			Position:  [3]float64{x, y, 0},
+			Normal:    [3]float64{0, 0, 1},
+			TexCoords: [2]float64{x + float64(side), y},
+		}
+		index[key] = uint32(len(mesh.Vertices))
+		mesh.Vertices = append(mesh.Vertices, v)
+		return index[key]
+	}
+
+	for j := 0; j < n; j++ {
+		for i := 0; i < n; i++ {
+			side := 0
+			if 2*i >= n {
+				side = 1
+			}
+			a, b, c, d := vertex(i, j, side), vertex(i+1, j, side), vertex(i+1, j+1, side), vertex(i, j+1, side)
+			mesh.Indices = append(mesh.Indices, a, b, c, a, c, d)
+		}
+	}
+	return mesh
+}
+
+// sphereMesh is a closed latitude-longitude sphere
+func sphereMesh(rings, segments int) *importers.Mesh {
+	mesh := &importers.Mesh{Primitive: importers.PrimitiveTriangles}
+	point := func(ring, segment int) [3]float64 {
+		theta := math.Pi * float64(ring) / float64(rings)
+		phi := 2 * math.Pi * float64(segment%segments) / float64(segments)
+		return [3]float64{math.Sin(theta) * math.Cos(phi), math.Sin(theta) * math.Sin(phi), math.Cos(theta)}
+	}
+	index := make(map[[3]float64]uint32)
+	vertex := func(ring, segment int) uint32 {
+		p := point(ring, segment)
+		if ring == 0 || ring == rings {
+			p = point(ring, 0)
+		}
+		if v, ok := index[p]; ok {
+			return v
+		}
+		index[p] = uint32(len(mesh.Vertices))
+		mesh.Vertices = append(mesh.Vertices, importers.Vertex{Position: p, Normal: p})
+		return index[p]
+	}
+
+	for ring := 0; ring < rings; ring++ {
+		for segment := 0; segment < segments; segment++ {
+			a, b := vertex(ring, segment), vertex(ring+1, segment)
+			c, d := vertex(ring+1, segment+1), vertex(ring, segment+1)
+			if ring > 0 {
+				mesh.Indices = append(mesh.Indices, a, b, d)
+			}
+			if ring < rings-1 {
+				mesh.Indices = append(mesh.Indices, b, c, d)
+			}
+		}
+	}
+	return mesh
+}
+
+func TestSimplifyFlatGrid(t *testing.T) {
+	mesh := gridMesh(8, false)
+	require.Equal(t, 128, mesh.TriangleCount())
+
+	result := mesh.Simplify(importers.SimplifyOptions{TargetTriangles: 2})
+	assert.Equal(t, 2, result.Mesh.TriangleCount())
+	assert.InDelta(t, 0, result.Error, 1e-6)
+	assert.Nil(t, result.Labels)
+
+	// The corners of the border survive and nothing else does
+	stats := result.Mesh.Stats()
+	assert.Equal(t, [3]float64{0, 0, 0}, stats.Bounds.Min)
+	assert.Equal(t, [3]float64{1, 1, 0}, stats.Bounds.Max)
+	assert.Len(t, result.Mesh.Vertices, 4)
+	for _, vertex := range result.Mesh.Vertices {
+		assert.Equal(t, [3]float64{0, 0, 1}, vertex.Normal)
+	}
+
+	// The source mesh is left alone
+	assert.Equal(t, 128, mesh.TriangleCount())
+}
+
+func TestSimplifyKeepsSeamsAndLabelBorders(t *testing.T) {
+	// The two halves only differ in their texture coordinates
+	mesh := gridMesh(8, true)
+	result := mesh.Simplify(importers.SimplifyOptions{TargetTriangles: 4})
+	assert.Equal(t, 4, result.Mesh.TriangleCount())
+	assert.Less(t, result.Error, 0.05)
+	for i := 0; i < len(result.Mesh.Indices); i += 3 {
+		right := result.Mesh.Vertices[result.Mesh.Indices[i]].TexCoords[0] >= 1
+		for _, index := range result.Mesh.Indices[i : i+3] {
+			vertex := result.Mesh.Vertices[index]
+			assert.Equal(t, right, vertex.TexCoords[0] >= 1)
+			if right {
+				assert.GreaterOrEqual(t, vertex.Position[0], 0.5)
+			} else {
+				assert.LessOrEqual(t, vertex.Position[0], 0.5)
+			}
+		}
+	}
+
+	// The same holds for labels such as materials
+	mesh = gridMesh(8, false)
+	labels := make([]int, mesh.TriangleCount())
+	for tri := range labels {
+		if mesh.Vertices[mesh.Indices[tri*3]].Position[0] >= 0.5 && mesh.Vertices[mesh.Indices[tri*3+1]].Position[0] >= 0.5 {
+			labels[tri] = 1
+		}
+	}
+	result = mesh.Simplify(importers.SimplifyOptions{TargetTriangles: 4, Labels: labels})
+	require.Len(t, result.Labels, result.Mesh.TriangleCount())
+	assert.Equal(t, 4, result.Mesh.TriangleCount())
+	for tri, label := range result.Labels {
+		for _, index := range result.Mesh.Indices[tri*3 : tri*3+3] {
+			x := result.Mesh.Vertices[index].Position[0]
+			if label == 1 {
+				assert.GreaterOrEqual(t, x, 0.5)
+			} else {
+				assert.LessOrEqual(t, x, 0.5)
+			}
+		}
+	}
+}
+
+func TestSimplifyClosedMesh(t *testing.T) {
+	mesh := sphereMesh(16, 32)
+	triangles := mesh.TriangleCount()
+
+	previous := 0.0
+	for _, ratio := range []float64{0.5, 0.25, 0.1} {
+		target := int(float64(triangles) * ratio)
+		result := mesh.Simplify(importers.SimplifyOptions{TargetTriangles: target})
+		assert.LessOrEqual(t, result.Mesh.TriangleCount(), target)
+		assert.GreaterOrEqual(t, result.Mesh.TriangleCount(), target-1)
+
+		// The surface stays closed, manifold and consistently wound
+		report := result.Mesh.Validate()
+		assert.True(t, report.Valid)
+		for _, issue := range report.Warnings {
+			assert.NotContains(t, []string{"open_boundary", "non_manifold_edge", "inconsistent_winding", "inverted_winding"}, issue.Code)
+		}
+
+		// Fewer triangles deviate further, but stay close to the sphere
+		assert.Greater(t, result.Error, previous)
+		assert.Less(t, result.Error, 0.2)
+		previous = result.Error
+		for _, vertex := range result.Mesh.Vertices {
+			assert.InDelta(t, 1, math.Sqrt(vertex.Position[0]*vertex.Position[0]+vertex.Position[1]*vertex.Position[1]+vertex.Position[2]*vertex.Position[2]), 1e-9)
+		}
+	}
+
+	points := &importers.Mesh{Vertices: []importers.Vertex{{}}, Primitive: importers.PrimitivePoints}
+	assert.Len(t, points.Simplify(importers.SimplifyOptions{}).Mesh.Vertices, 1)
+}
@@ -0,0 +1,79 @@
+package importers_test
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+func TestMeshStats(t *testing.T) {
+	importer := importers.NewVertexImporter()
+	require.NoError(t, importer.ImportFromOBJ(strings.NewReader("usemtl Paint\n"+cubeOBJ)))
+
+	stats := importer.Stats()
+	assert.Equal(t, 24, stats.Vertices)
+	assert.Equal(t, 12, stats.Triangles)
+	assert.Equal(t, 1, stats.SubMeshes)
+	assert.Equal(t, 1, stats.Materials)
+	assert.Equal(t, importers.BoundingBox{Min: [3]float64{0, 0, 0}, Max: [3]float64{1, 1, 1}}, stats.Bounds)
+	assert.Equal(t, [3]float64{0.5, 0.5, 0.5}, stats.Sphere.Center)
+	assert.InDelta(t, math.Sqrt(3)/2, stats.Sphere.Radius, 1e-9)
+	assert.InDelta(t, 6, stats.SurfaceArea, 1e-9)
+
+	// The cube is closed across its normal seams
+	assert.True(t, stats.Closed)
+	require.NotNil(t, stats.Volume)
+	assert.InDelta(t, 1, *stats.Volume, 1e-9)
+}
+
+func TestMeshStatsGLTFPrimitives(t *testing.T) {
+	// Three primitives drawing the quad, two of them with the same material
+	doc := quadGLTF(dataURI(quadBuffer()))
+	primitive := func(material int) map[string]interface{} {
+		return map[string]interface{}{
+			"attributes": map[string]int{"POSITION": 0},
+			"indices":    2,
+			"material":   material,
+		}
+	}
+	doc["meshes"] = []interface{}{
+		map[string]interface{}{"primitives": []interface{}{primitive(0), primitive(1), primitive(0)}},
+	}
+	doc["materials"] = append(doc["materials"].([]interface{}), map[string]interface{}{"name": "Steel"})
+
+	importer := importers.NewVertexImporter()
+	require.NoError(t, importer.ImportFromGLTF(bytes.NewReader(encodeGLTF(t, doc)), nil))
+
+	stats := importer.Stats()
+	assert.Equal(t, 6, stats.Triangles)
+	assert.Equal(t, 3, stats.SubMeshes)
+	assert.Equal(t, 2, stats.Materials)
+}
+
+func TestMeshStatsOpenMesh(t *testing.T) {
+	importer := importers.NewVertexImporter()
+	require.NoError(t, importer.ImportFromOBJ(strings.NewReader("v 0 0 0\nv 2 0 0\nv 2 2 0\nv 0 2 0\nf 1 2 3 4\n")))
+
+	stats := importer.Stats()
+	assert.Equal(t, 2, stats.Triangles)
+	assert.InDelta(t, 4, stats.SurfaceArea, 1e-9)
+	assert.False(t, stats.Closed)
+	assert.Nil(t, stats.Volume)
+}
+
+func TestMeshStatsPointCloud(t *testing.T) {
+	importer := importers.NewVertexImporter()
+	require.NoError(t, importer.ImportFromPLY(strings.NewReader("ply\nformat ascii 1.0\nelement vertex 2\nproperty float x\nproperty float y\nproperty float z\nend_header\n0 0 0\n2 4 0\n")))
+
+	stats := importer.Stats()
+	assert.Equal(t, 2, stats.Vertices)
+	assert.Equal(t, 0, stats.Triangles)
+	assert.Equal(t, [3]float64{2, 4, 0}, stats.Bounds.Max)
+	assert.Zero(t, stats.SurfaceArea)
+}
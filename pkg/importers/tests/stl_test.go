@@ -0,0 +1,134 @@
+package importers_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+// quadSTLTriangles is a unit quad in the z=0 plane as two facets
+var quadSTLTriangles = [][3][3]float32{
+	{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}},
+	{{0, 0, 0}, {1, 1, 0}, {0, 1, 0}},
+}
+
+// encodeBinarySTL writes a binary STL file with the given header text
+// and a +Z facet normal for every triangle
+func encodeBinarySTL(header string, triangles [][3][3]float32) []byte {
+	var buf bytes.Buffer
+	h := make([]byte, 80)
+	copy(h, header)
+	buf.Write(h)
+	binary.Write(&buf, binary.LittleEndian, uint32(len(triangles)))
+	for _, triangle := range triangles {
+		binary.Write(&buf, binary.LittleEndian, [3]float32{0, 0, 1})
+		binary.Write(&buf, binary.LittleEndian, triangle)
+		binary.Write(&buf, binary.LittleEndian, uint16(0))
+	}
+	return buf.Bytes()
+}
+
+const asciiQuadSTL = `solid quad
+  facet normal 0 0 1
+    outer loop
+      vertex 0 0 0
+      vertex 1 0 0
+      vertex 1 1 0
+    endloop
+  endfacet
+  facet normal 0 0 0
+    outer loop
+      vertex 0 0 0
+      vertex 1 1 0
+      vertex 0 1 0
+    endloop
+  endfacet
+endsolid quad
+`
+
+func TestImportFromSTL(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "ascii", data: []byte(asciiQuadSTL)},
+		{name: "binary", data: encodeBinarySTL("exported by CAD", quadSTLTriangles)},
+		{name: "binary with solid header", data: encodeBinarySTL("solid quad", quadSTLTriangles)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			importer := importers.NewVertexImporter()
+			require.NoError(t, importer.ImportFromSTL(bytes.NewReader(tt.data)))
+
+			// The shared diagonal is welded
+			vertices := importer.GetVertices()
+			require.Len(t, vertices, 4)
+			assert.Equal(t, []uint32{0, 1, 2, 0, 2, 3}, importer.GetIndices())
+			assert.Equal(t, [3]float64{0, 1, 0}, vertices[3].Position)
+
+			// Zero facet normals are computed from the winding
+			for _, vertex := range vertices {
+				assert.Equal(t, [3]float64{0, 0, 1}, vertex.Normal)
+			}
+		})
+	}
+}
+
+func TestImportFromSTLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "empty", data: ""},
+		{name: "missing solid", data: "facet normal 0 0 1\n"},
+		{name: "vertex outside facet", data: "solid x\nvertex 0 0 0\nendsolid x\n"},
+		{name: "short facet", data: "solid x\nfacet normal 0 0 1\nouter loop\nvertex 0 0 0\nendloop\nendfacet\nendsolid x\n"},
+		{name: "invalid number", data: "solid x\nfacet normal 0 0 one\n"},
+		{name: "unterminated facet", data: "solid x\nfacet normal 0 0 1\nouter loop\n"},
+		{name: "truncated binary", data: string(encodeBinarySTL("part", quadSTLTriangles)[:120])},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			importer := importers.NewVertexImporter()
+			assert.Error(t, importer.ImportFromSTL(strings.NewReader(tt.data)))
+		})
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	stl := encodeBinarySTL("solid quad", quadSTLTriangles)
+	glb := quadGLB(t)
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{name: "binary stl", data: stl, want: "stl"},
+		{name: "ascii stl", data: []byte(asciiQuadSTL), want: "stl"},
+		{name: "glb", data: glb, want: "glb"},
+		{name: "binary fbx", data: encodeFBX(7400, fbxQuadScene(), false), want: "fbx"},
+		{name: "ascii fbx", data: []byte(asciiQuadFBX), want: "fbx"},
+		{name: "ply", data: []byte(asciiQuadPLY), want: "ply"},
+		{name: "gltf", data: []byte(`{"asset": {"version": "2.0"}}`), want: "gltf"},
+		{name: "unknown", data: []byte("hello"), want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			head := tt.data
+			if len(head) > 512 {
+				head = head[:512]
+			}
+			assert.Equal(t, tt.want, importers.DetectFormat(head, int64(len(tt.data))))
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package importers_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+const threeMFRels = `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Target="/3D/3dmodel.model" Id="rel0" Type="http://schemas.microsoft.com/3dmanufacturing/2013/01/3dmodel"/>
+</Relationships>`
+
+// threeMFModel has a colored triangle object, an assembly placing it twice
+// and a build item translating the assembly
+const threeMFModel = `<?xml version="1.0" encoding="UTF-8"?>
+<model unit="millimeter" xml:lang="en-US"
+  xmlns="http://schemas.microsoft.com/3dmanufacturing/core/2015/02"
+  xmlns:m="http://schemas.microsoft.com/3dmanufacturing/material/2015/02"
+  xmlns:p="http://schemas.microsoft.com/3dmanufacturing/production/2015/06">
+  <resources>
+    <basematerials id="1">
+      <base name="PLA Red" displaycolor="#FF0000" />
+      <base name="PLA Clear" displaycolor="#FFFFFF80" />
+    </basematerials>
+    <m:colorgroup id="2">
+      <m:color color="#00FF00" />
+      <m:color color="#0000FF" />
+    </m:colorgroup>
+    <object id="3" type="model" pid="1" pindex="0">
+      <mesh>
+        <vertices>
+          <vertex x="0" y="0" z="0" />
+          <vertex x="1" y="0" z="0" />
+          <vertex x="0" y="1" z="0" />
+          <vertex x="1" y="1" z="0" />
+        </vertices>
+        <triangles>
+          <triangle v1="0" v2="1" v3="2" />
+          <triangle v1="1" v2="3" v3="2" pid="2" p1="0" p2="1" p3="1" />
+        </triangles>
+      </mesh>
+    </object>
+    <object id="4" type="model">
+      <components>
+        <component objectid="3" />
+        <component objectid="3" transform="1 0 0 0 1 0 0 0 1 0 0 5" />
+      </components>
+    </object>
+  </resources>
+  <build>
+    <item objectid="4" transform="1 0 0 0 1 0 0 0 1 10 0 0" />
+  </build>
+</model>`
+
+func build3MF(t *testing.T, parts map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestImportFrom3MF(t *testing.T) {
+	data := build3MF(t, map[string]string{
+		"_rels/.rels":      threeMFRels,
+		"3D/3dmodel.model": threeMFModel,
+	})
+
+	importer := importers.NewVertexImporter()
+	require.NoError(t, importer.ImportFrom3MF(bytes.NewReader(data)))
+
+	vertices := importer.GetVertices()
+	indices := importer.GetIndices()
+	// Two instances of two triangles
+	require.Len(t, indices, 12)
+
+	first := vertices[indices[0]]
+	assert.Equal(t, [3]float64{10, 0, 0}, first.Position)
+	assert.True(t, first.HasColor)
+	assert.Equal(t, [4]float64{1, 0, 0, 1}, first.Color)
+
+	// The second instance is raised by the component transform
+	assert.Equal(t, [3]float64{10, 0, 5}, vertices[indices[6]].Position)
+
+	// Per-corner colors from the color group split shared corners
+	assert.Equal(t, [4]float64{0, 1, 0, 1}, vertices[indices[3]].Color)
+	assert.Equal(t, [4]float64{0, 0, 1, 1}, vertices[indices[4]].Color)
+	assert.Len(t, vertices, 12)
+
+	// Triangles are grouped by base material; color group triangles have none
+	assert.Equal(t, []importers.MeshGroup{
+		{Material: "PLA Red", Start: 0, Count: 3, Faces: 1},
+		{Start: 3, Count: 3, Faces: 1},
+		{Material: "PLA Red", Start: 6, Count: 3, Faces: 1},
+		{Start: 9, Count: 3, Faces: 1},
+	}, importer.GetGroups())
+}
+
+func TestImportFrom3MFMaterials(t *testing.T) {
+	data := build3MF(t, map[string]string{
+		"_rels/.rels":      threeMFRels,
+		"3D/3dmodel.model": threeMFModel,
+	})
+
+	importer := importers.NewMaterialImporter()
+	require.NoError(t, importer.ImportFrom3MF(bytes.NewReader(data)))
+
+	red, ok := importer.GetMaterial("PLA Red")
+	require.True(t, ok)
+	assert.Equal(t, [3]float64{1, 0, 0}, red.DiffuseColor)
+	assert.Equal(t, 1.0, red.Transparency)
+
+	clear, ok := importer.GetMaterial("PLA Clear")
+	require.True(t, ok)
+	assert.InDelta(t, 0.5, clear.Transparency, 0.01)
+}
+
+func TestImportFrom3MFProductionPaths(t *testing.T) {
+	root := `<model xmlns="http://schemas.microsoft.com/3dmanufacturing/core/2015/02"
+  xmlns:p="http://schemas.microsoft.com/3dmanufacturing/production/2015/06">
+  <resources>
+    <object id="1"><components><component objectid="7" p:path="/3D/part.model" /></components></object>
+  </resources>
+  <build><item objectid="1" /></build>
+</model>`
+	part := `<model xmlns="http://schemas.microsoft.com/3dmanufacturing/core/2015/02">
+  <resources>
+    <object id="7"><mesh>
+      <vertices><vertex x="0" y="0" z="0" /><vertex x="2" y="0" z="0" /><vertex x="0" y="2" z="0" /></vertices>
+      <triangles><triangle v1="0" v2="1" v3="2" /></triangles>
+    </mesh></object>
+  </resources>
+</model>`
+
+	data := build3MF(t, map[string]string{
+		"_rels/.rels":      threeMFRels,
+		"3D/3dmodel.model": root,
+		"3D/part.model":    part,
+	})
+
+	importer := importers.NewVertexImporter()
+	require.NoError(t, importer.ImportFrom3MF(bytes.NewReader(data)))
+	require.Len(t, importer.GetVertices(), 3)
+	assert.Equal(t, [3]float64{2, 0, 0}, importer.GetVertices()[1].Position)
+	assert.False(t, importer.GetVertices()[1].HasColor)
+}
+
+func TestImportFrom3MFErrors(t *testing.T) {
+	cyclic := `<model xmlns="http://schemas.microsoft.com/3dmanufacturing/core/2015/02">
+  <resources><object id="1"><components><component objectid="1" /></components></object></resources>
+  <build><item objectid="1" /></build>
+</model>`
+	badIndex := `<model xmlns="http://schemas.microsoft.com/3dmanufacturing/core/2015/02">
+  <resources><object id="1"><mesh>
+    <vertices><vertex x="0" y="0" z="0" /></vertices>
+    <triangles><triangle v1="0" v2="1" v3="2" /></triangles>
+  </mesh></object></resources>
+  <build><item objectid="1" /></build>
+</model>`
+
+	// Every object places the previous one twice, giving 2^31 triangles
+	var doubling strings.Builder
+	doubling.WriteString(`<model xmlns="http://schemas.microsoft.com/3dmanufacturing/core/2015/02"><resources>
+  <object id="1"><mesh>
+    <vertices><vertex x="0" y="0" z="0" /><vertex x="1" y="0" z="0" /><vertex x="0" y="1" z="0" /></vertices>
+    <triangles><triangle v1="0" v2="1" v3="2" /></triangles>
+  </mesh></object>`)
+	for id := 2; id <= 32; id++ {
+		fmt.Fprintf(&doubling, `<object id="%d"><components><component objectid="%d" /><component objectid="%d" /></components></object>`, id, id-1, id-1)
+	}
+	doubling.WriteString(`</resources><build><item objectid="32" /></build></model>`)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "not a zip", data: []byte("solid")},
+		{name: "missing model part", data: build3MF(t, map[string]string{"_rels/.rels": threeMFRels})},
+		{name: "cyclic components", data: build3MF(t, map[string]string{"_rels/.rels": threeMFRels, "3D/3dmodel.model": cyclic})},
+		{name: "too many instances", data: build3MF(t, map[string]string{"_rels/.rels": threeMFRels, "3D/3dmodel.model": doubling.String()})},
+		{name: "vertex index out of range", data: build3MF(t, map[string]string{"_rels/.rels": threeMFRels, "3D/3dmodel.model": badIndex})},
+		{name: "invalid xml", data: build3MF(t, map[string]string{"_rels/.rels": threeMFRels, "3D/3dmodel.model": "<model"})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			importer := importers.NewVertexImporter()
+			assert.Error(t, importer.ImportFrom3MF(bytes.NewReader(tt.data)))
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package importers_test
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/3FT-io/3DS/pkg/importers"
+)
+
+// issueCodes returns the codes of the given issues
+func issueCodes(issues []importers.ValidationIssue) []string {
+	codes := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		codes = append(codes, issue.Code)
+	}
+	return codes
+}
+
+func TestValidateOBJ(t *testing.T) {
+	tests := []struct {
+		name     string
+		obj      string
+		warnings []string
+	}{
+		{name: "closed cube", obj: cubeOBJ, warnings: []string{}},
+		{
+			name:     "open quad",
+			obj:      "v 0 0 0\nv 1 0 0\nv 1 1 0\nv 0 1 0\nf 1 2 3 4\n",
+			warnings: []string{"open_boundary"},
+		},
+		{
+			name:     "degenerate and duplicate",
+			obj:      "v 0 0 0\nv 1 0 0\nv 2 0 0\nv 0 1 0\nf 1 2 3\nf 1 2 4\nf 2 4 1\n",
+			warnings: []string{"degenerate_triangle", "duplicate_triangle", "open_boundary"},
+		},
+		{
+			name:     "inconsistent winding",
+			obj:      "v 0 0 0\nv 1 0 0\nv 1 1 0\nv 0 1 0\nf 1 2 3\nf 1 4 3\n",
+			warnings: []string{"inconsistent_winding", "open_boundary"},
+		},
+		{
+			name:     "non-manifold fin",
+			obj:      "v 0 0 0\nv 1 0 0\nv 0 1 0\nv 0 -1 0\nv 0 0 1\nf 1 2 3\nf 2 1 4\nf 1 2 5\n",
+			warnings: []string{"non_manifold_edge", "open_boundary"},
+		},
+		{
+			name:     "unnormalized normal",
+			obj:      "v 0 0 0\nv 1 0 0\nv 0 1 0\nvn 0 0 2\nf 1//1 2//1 3//1\n",
+			warnings: []string{"unnormalized_normal", "open_boundary"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			importer := importers.NewVertexImporter()
+			require.NoError(t, importer.ImportFromOBJ(strings.NewReader(tt.obj)))
+
+			report := importer.Validate()
+			assert.True(t, report.Valid)
+			assert.Empty(t, report.Errors)
+			assert.ElementsMatch(t, tt.warnings, issueCodes(report.Warnings))
+		})
+	}
+}
+
+func TestValidateInvertedAndHoles(t *testing.T) {
+	// Reversing every face turns the cube inside out
+	inverted := strings.NewReplacer("f 1//1 4//1 3//1 2//1", "f 2//1 3//1 4//1 1//1",
+		"f 5//2 6//2 7//2 8//2", "f 8//2 7//2 6//2 5//2",
+		"f 1//3 2//3 6//3 5//3", "f 5//3 6//3 2//3 1//3",
+		"f 4//4 8//4 7//4 3//4", "f 3//4 7//4 8//4 4//4",
+		"f 1//5 5//5 8//5 4//5", "f 4//5 8//5 5//5 1//5",
+		"f 2//6 3//6 7//6 6//6", "f 6//6 7//6 3//6 2//6").Replace(cubeOBJ)
+
+	importer := importers.NewVertexImporter()
+	require.NoError(t, importer.ImportFromOBJ(strings.NewReader(inverted)))
+	assert.Equal(t, []string{"inverted_winding"}, issueCodes(importer.Validate().Warnings))
+
+	// Removing the top and bottom leaves two holes
+	tube := strings.NewReplacer("f 1//1 4//1 3//1 2//1\n", "", "f 5//2 6//2 7//2 8//2\n", "").Replace(cubeOBJ)
+	importer = importers.NewVertexImporter()
+	require.NoError(t, importer.ImportFromOBJ(strings.NewReader(tube)))
+	warnings := importer.Validate().Warnings
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "open_boundary", warnings[0].Code)
+	assert.Equal(t, 2, warnings[0].Count)
+}
+
+func TestValidateErrors(t *testing.T) {
+	mesh := &importers.Mesh{
+		Vertices: []importers.Vertex{
+			{Position: [3]float64{0, 0, 0}},
+			{Position: [3]float64{1, 0, 0}},
+			{Position: [3]float64{math.NaN(), 1, 0}},
+		},
+		Indices: []uint32{0, 1, 2, 0, 1, 7, 0},
+	}
+
+	report := mesh.Validate()
+	assert.False(t, report.Valid)
+	assert.Equal(t, []string{"non_finite_value", "incomplete_triangle", "index_out_of_range"}, issueCodes(report.Errors))
+	assert.Equal(t, []int{2}, report.Errors[0].Vertices)
+	assert.Equal(t, []int{1}, report.Errors[2].Triangles)
+
+	report = importers.ImportErrorReport(errors.New("invalid STL file"))
+	assert.False(t, report.Valid)
+	assert.Equal(t, "import_failed", report.Errors[0].Code)
+}
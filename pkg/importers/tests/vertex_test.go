@@ -140,50 +140,7 @@ func TestImportFromFBX(t *testing.T) {
 
 // Helper function to create a test FBX binary file
 func createTestFBXBinary() []byte {
-	// FBX Binary format header
-	header := []byte("Kaydara FBX Binary  ")
-	header = append(header, []byte{0x00, 0x1A, 0x00}...)
-
-	// FBX version (7400 = 0x1CE8)
-	version := []byte{0xE8, 0x1C, 0x00, 0x00}
-
-	// Simple geometry data with vertices
-	geometryData := []byte{
-		// Node header
-		0x00, 0x00, 0x01, 0x00, // endOffset
-		0x01, 0x00, 0x00, 0x00, // numProperties (1 array)
-		0x48, 0x00, 0x00, 0x00, // propertyListLen (72 bytes - array header + data)
-		0x08, // nameLen
-		'V', 'e', 'r', 't', 'i', 'c', 'e', 's',
-
-		// Property type (array of float64)
-		'D', 0x00, // type code for float64 array
-		0x09, 0x00, 0x00, 0x00, // array length (9 values - 3 vertices * 3 coordinates)
-		0x00, 0x00, 0x00, 0x00, // encoding (0 = raw binary)
-		0x00, 0x00, 0x00, 0x00, // compressed length (0 = uncompressed)
-
-		// Vertex data (3 vertices as float64)
-		// Vertex 1: (0, 0, 0)
-		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-		// Vertex 2: (1, 0, 0)
-		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF0, 0x3F,
-		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-		// Vertex 3: (0, 1, 0)
-		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF0, 0x3F,
-		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-	}
-
-	// Combine all parts
-	data := make([]byte, 0, len(header)+len(version)+len(geometryData))
-	data = append(data, header...)
-	data = append(data, version...)
-	data = append(data, geometryData...)
-
-	return data
+	return encodeFBX(7400, fbxQuadScene(), false)
 }
 
 func TestParseVector3(t *testing.T) {
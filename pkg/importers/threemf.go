@@ -0,0 +1,552 @@
+package importers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	threeMFModelRelType     = "http://schemas.microsoft.com/3dmanufacturing/2013/01/3dmodel"
+	threeMFDefaultModelPath = "3D/3dmodel.model"
+	threeMFMaxPartSize      = 512 << 20
+	threeMFMaxDepth         = 32
+)
+
+// threeMFModel is the XML document of a 3MF model part
+type threeMFModel struct {
+	Unit      string `xml:"unit,attr"`
+	Resources struct {
+		BaseMaterials []threeMFBaseMaterials `xml:"basematerials"`
+		ColorGroups   []threeMFColorGroup    `xml:"colorgroup"`
+		Objects       []threeMFObject        `xml:"object"`
+	} `xml:"resources"`
+	Build struct {
+		Items []threeMFReference `xml:"item"`
+	} `xml:"build"`
+}
+
+type threeMFBaseMaterials struct {
+	ID    int `xml:"id,attr"`
+	Bases []struct {
+		Name         string `xml:"name,attr"`
+		DisplayColor string `xml:"displaycolor,attr"`
+	} `xml:"base"`
+}
+
+type threeMFColorGroup struct {
+	ID     int `xml:"id,attr"`
+	Colors []struct {
+		Color string `xml:"color,attr"`
+	} `xml:"color"`
+}
+
+type threeMFObject struct {
+	ID     int    `xml:"id,attr"`
+	Name   string `xml:"name,attr"`
+	PID    *int   `xml:"pid,attr"`
+	PIndex *int   `xml:"pindex,attr"`
+	Mesh   *struct {
+		Vertices []struct {
+			X float64 `xml:"x,attr"`
+			Y float64 `xml:"y,attr"`
+			Z float64 `xml:"z,attr"`
+		} `xml:"vertices>vertex"`
+		Triangles []threeMFTriangle `xml:"triangles>triangle"`
+	} `xml:"mesh"`
+	Components []threeMFReference `xml:"components>component"`
+}
+
+type threeMFTriangle struct {
+	V1  int  `xml:"v1,attr"`
+	V2  int  `xml:"v2,attr"`
+	V3  int  `xml:"v3,attr"`
+	PID *int `xml:"pid,attr"`
+	P1  *int `xml:"p1,attr"`
+	P2  *int `xml:"p2,attr"`
+	P3  *int `xml:"p3,attr"`
+}
+
+// threeMFReference is a build item or component: an object, optionally in
+// another model part, placed with an affine transform
+type threeMFReference struct {
+	ObjectID  int    `xml:"objectid,attr"`
+	Transform string `xml:"transform,attr"`
+	Path      string `xml:"http://schemas.microsoft.com/3dmanufacturing/production/2015/06 path,attr"`
+}
+
+// threeMFPackage loads the model parts of a 3MF (OPC) package
+type threeMFPackage struct {
+	files    map[string]*zip.File
+	root     string
+	models   map[string]*threeMFModel
+	welder   *vertexWelder
+	visiting map[string]bool
+	// names holds the importer names of the root model's base materials
+	// by group id and index
+	names map[[2]int]string
+}
+
+// newThreeMFPackage opens the zip archive and locates the root model part
+func newThreeMFPackage(data []byte) (*threeMFPackage, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid 3MF package: %w", err)
+	}
+
+	pkg := &threeMFPackage{
+		files:    make(map[string]*zip.File),
+		models:   make(map[string]*threeMFModel),
+		welder:   newVertexWelder(),
+		visiting: make(map[string]bool),
+	}
+	for _, file := range archive.File {
+		pkg.files[strings.TrimPrefix(file.Name, "/")] = file
+	}
+
+	pkg.root, err = pkg.rootModelPath()
+	if err != nil {
+		return nil, err
+	}
+	return pkg, nil
+}
+
+// rootModelPath reads the package relationships for the 3D model part
+func (p *threeMFPackage) rootModelPath() (string, error) {
+	if _, ok := p.files["_rels/.rels"]; !ok {
+		if _, ok := p.files[threeMFDefaultModelPath]; ok {
+			return threeMFDefaultModelPath, nil
+		}
+		return "", errors.New("invalid 3MF package: missing _rels/.rels")
+	}
+
+	data, err := p.readPart("_rels/.rels")
+	if err != nil {
+		return "", err
+	}
+
+	var rels struct {
+		Relationships []struct {
+			Target string `xml:"Target,attr"`
+			Type   string `xml:"Type,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := xml.Unmarshal(data, &rels); err != nil {
+		return "", fmt.Errorf("invalid 3MF relationships: %w", err)
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.Type == threeMFModelRelType {
+			return partName(rel.Target), nil
+		}
+	}
+	return "", errors.New("invalid 3MF package: no 3D model relationship")
+}
+
+// partName converts an absolute part URI into a zip entry name
+func partName(uri string) string {
+	return strings.TrimPrefix(path.Clean("/"+uri), "/")
+}
+
+// readPart reads a zip entry, bounding its decompressed size
+func (p *threeMFPackage) readPart(name string) ([]byte, error) {
+	file, ok := p.files[name]
+	if !ok {
+		return nil, fmt.Errorf("3MF package has no part %q", name)
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open 3MF part %q: %w", name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, threeMFMaxPartSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read 3MF part %q: %w", name, err)
+	}
+	if len(data) > threeMFMaxPartSize {
+		return nil, fmt.Errorf("3MF part %q is too large", name)
+	}
+	return data, nil
+}
+
+// model parses a model part, caching the result
+func (p *threeMFPackage) model(name string) (*threeMFModel, error) {
+	if model, ok := p.models[name]; ok {
+		return model, nil
+	}
+
+	data, err := p.readPart(name)
+	if err != nil {
+		return nil, err
+	}
+
+	model := &threeMFModel{}
+	if err := xml.Unmarshal(data, model); err != nil {
+		return nil, fmt.Errorf("invalid 3MF model %q: %w", name, err)
+	}
+	p.models[name] = model
+	return model, nil
+}
+
+// build instantiates every build item of the root model
+func (p *threeMFPackage) build() error {
+	root, err := p.model(p.root)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range root.Build.Items {
+		if err := p.instantiate(p.root, item, IdentityMatrix(), 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// instantiate adds the triangles of a referenced object, recursing into
+// components with their combined transform
+func (p *threeMFPackage) instantiate(modelPath string, ref threeMFReference, parent Matrix4, depth int) error {
+	if ref.Path != "" {
+		modelPath = partName(ref.Path)
+	}
+
+	local, err := parseThreeMFTransform(ref.Transform)
+	if err != nil {
+		return err
+	}
+	world := parent.Mul(local)
+
+	key := modelPath + "#" + strconv.Itoa(ref.ObjectID)
+	if p.visiting[key] || depth > threeMFMaxDepth {
+		return fmt.Errorf("3MF object %d in %q references itself", ref.ObjectID, modelPath)
+	}
+	p.visiting[key] = true
+	defer delete(p.visiting, key)
+	if err := p.welder.instance(); err != nil {
+		return err
+	}
+
+	model, err := p.model(modelPath)
+	if err != nil {
+		return err
+	}
+
+	var object *threeMFObject
+	for i := range model.Resources.Objects {
+		if model.Resources.Objects[i].ID == ref.ObjectID {
+			object = &model.Resources.Objects[i]
+			break
+		}
+	}
+	if object == nil {
+		return fmt.Errorf("3MF model %q has no object %d", modelPath, ref.ObjectID)
+	}
+
+	if object.Mesh != nil {
+		if err := p.addMesh(model, object, world); err != nil {
+			return fmt.Errorf("3MF object %d: %w", object.ID, err)
+		}
+	}
+	for _, component := range object.Components {
+		// Components without a path refer to objects in the same part
+		if err := p.instantiate(modelPath, component, world, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addMesh transforms and welds an object's triangles, coloring vertices
+// from the triangle or object properties. Triangles are grouped by their
+// base material in order of first use.
+func (p *threeMFPackage) addMesh(model *threeMFModel, object *threeMFObject, world Matrix4) error {
+	positions := make([][3]float64, len(object.Mesh.Vertices))
+	for i, v := range object.Mesh.Vertices {
+		positions[i] = world.TransformPoint([3]float64{v.X, v.Y, v.Z})
+	}
+	flip := world.determinant3() < 0
+
+	names, err := p.materialNames()
+	if err != nil {
+		return err
+	}
+	root, err := p.model(p.root)
+	if err != nil {
+		return err
+	}
+	var materials []string
+	byMaterial := make(map[string][]int)
+	for i, tri := range object.Mesh.Triangles {
+		var material string
+		pid, index := tri.PID, tri.P1
+		if pid == nil {
+			pid = object.PID
+		}
+		if index == nil {
+			index = object.PIndex
+		}
+		// Only the root model's materials are imported
+		if model == root && pid != nil && index != nil {
+			material = names[[2]int{*pid, *index}]
+		}
+		if _, ok := byMaterial[material]; !ok {
+			materials = append(materials, material)
+		}
+		byMaterial[material] = append(byMaterial[material], i)
+	}
+
+	for _, material := range materials {
+		group := MeshGroup{Object: object.Name, Material: material, Start: len(p.welder.indices), Faces: len(byMaterial[material])}
+		if err := p.addTriangles(model, object, byMaterial[material], positions, flip); err != nil {
+			return err
+		}
+		p.welder.addGroup(group)
+	}
+	return nil
+}
+
+// addTriangles welds the given triangles of an object
+func (p *threeMFPackage) addTriangles(model *threeMFModel, object *threeMFObject, triangles []int, positions [][3]float64, flip bool) error {
+	for _, i := range triangles {
+		tri := object.Mesh.Triangles[i]
+		corners := [3]int{tri.V1, tri.V2, tri.V3}
+		for _, c := range corners {
+			if c < 0 || c >= len(positions) {
+				return fmt.Errorf("triangle %d references missing vertex %d", i, c)
+			}
+		}
+
+		colors, err := triangleColors(model, object, tri)
+		if err != nil {
+			return fmt.Errorf("triangle %d: %w", i, err)
+		}
+
+		order := [3]int{0, 1, 2}
+		if flip {
+			order = [3]int{0, 2, 1}
+		}
+		for _, k := range order {
+			vertex := Vertex{Position: positions[corners[k]]}
+			if colors != nil {
+				vertex.Color, vertex.HasColor = colors[k], true
+			}
+			p.welder.indices = append(p.welder.indices, p.welder.add(vertex))
+		}
+	}
+	return nil
+}
+
+// triangleColors resolves the per-corner colors of a triangle, or nil if
+// it has no color property
+func triangleColors(model *threeMFModel, object *threeMFObject, tri threeMFTriangle) ([][4]float64, error) {
+	pid, p1 := tri.PID, tri.P1
+	if pid == nil {
+		pid = object.PID
+	}
+	if p1 == nil {
+		p1 = object.PIndex
+	}
+	if pid == nil || p1 == nil {
+		return nil, nil
+	}
+
+	indices := [3]int{*p1, *p1, *p1}
+	if tri.P2 != nil {
+		indices[1] = *tri.P2
+	}
+	if tri.P3 != nil {
+		indices[2] = *tri.P3
+	}
+
+	lookup := func(index int) (string, bool) { return "", false }
+	for _, group := range model.Resources.BaseMaterials {
+		if group.ID == *pid {
+			bases := group.Bases
+			lookup = func(index int) (string, bool) {
+				if index < 0 || index >= len(bases) {
+					return "", false
+				}
+				return bases[index].DisplayColor, true
+			}
+		}
+	}
+	for _, group := range model.Resources.ColorGroups {
+		if group.ID == *pid {
+			colors := group.Colors
+			lookup = func(index int) (string, bool) {
+				if index < 0 || index >= len(colors) {
+					return "", false
+				}
+				return colors[index].Color, true
+			}
+		}
+	}
+
+	result := make([][4]float64, 3)
+	for k, index := range indices {
+		value, ok := lookup(index)
+		if !ok {
+			// Other property types, such as textures, carry no color
+			if k == 0 {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("property %d of group %d not found", index, *pid)
+		}
+		color, err := parseThreeMFColor(value)
+		if err != nil {
+			return nil, err
+		}
+		result[k] = color
+	}
+	return result, nil
+}
+
+// materialNames returns the importer names of the root model's base
+// materials by group id and index
+func (p *threeMFPackage) materialNames() (map[[2]int]string, error) {
+	if p.names != nil {
+		return p.names, nil
+	}
+	root, err := p.model(p.root)
+	if err != nil {
+		return nil, err
+	}
+
+	p.names = make(map[[2]int]string)
+	used := make(map[string]bool)
+	for _, group := range root.Resources.BaseMaterials {
+		for i, base := range group.Bases {
+			// Names are optional and need not be unique across groups
+			name := base.Name
+			if name == "" || used[name] {
+				name = fmt.Sprintf("material_%d_%d", group.ID, i)
+			}
+			used[name] = true
+			p.names[[2]int{group.ID, i}] = name
+		}
+	}
+	return p.names, nil
+}
+
+// materials converts the base materials of the root model
+func (p *threeMFPackage) materials() ([]*Material, error) {
+	root, err := p.model(p.root)
+	if err != nil {
+		return nil, err
+	}
+	names, err := p.materialNames()
+	if err != nil {
+		return nil, err
+	}
+
+	var materials []*Material
+	for _, group := range root.Resources.BaseMaterials {
+		for i, base := range group.Bases {
+			color, err := parseThreeMFColor(base.DisplayColor)
+			if err != nil {
+				return nil, fmt.Errorf("base material %q: %w", base.Name, err)
+			}
+
+			materials = append(materials, &Material{
+				Name:         names[[2]int{group.ID, i}],
+				DiffuseColor: [3]float64{color[0], color[1], color[2]},
+				Transparency: color[3],
+			})
+		}
+	}
+	return materials, nil
+}
+
+// parseThreeMFTransform parses the 12 values of a 3MF affine transform.
+// 3MF lists the matrix row by row for row vectors, which matches the
+// column-major layout of Matrix4 without its last row.
+func parseThreeMFTransform(transform string) (Matrix4, error) {
+	if strings.TrimSpace(transform) == "" {
+		return IdentityMatrix(), nil
+	}
+
+	fields := strings.Fields(transform)
+	if len(fields) != 12 {
+		return Matrix4{}, fmt.Errorf("invalid 3MF transform %q", transform)
+	}
+
+	m := IdentityMatrix()
+	for i, field := range fields {
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return Matrix4{}, fmt.Errorf("invalid 3MF transform %q", transform)
+		}
+		m[i/3*4+i%3] = value
+	}
+	return m, nil
+}
+
+// parseThreeMFColor parses an sRGB "#RRGGBB" or "#RRGGBBAA" color
+func parseThreeMFColor(value string) ([4]float64, error) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "#") || (len(value) != 7 && len(value) != 9) {
+		return [4]float64{}, fmt.Errorf("invalid 3MF color %q", value)
+	}
+
+	raw, err := hex.DecodeString(value[1:])
+	if err != nil {
+		return [4]float64{}, fmt.Errorf("invalid 3MF color %q", value)
+	}
+
+	color := [4]float64{0, 0, 0, 1}
+	for i, b := range raw {
+		color[i] = float64(b) / 255
+	}
+	return color, nil
+}
+
+// ImportFrom3MF imports the build items of a 3MF package as one welded,
+// indexed mesh with object and component transforms applied
+func (vi *VertexImporter) ImportFrom3MF(reader io.Reader) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read 3MF file: %w", err)
+	}
+
+	pkg, err := newThreeMFPackage(data)
+	if err != nil {
+		return err
+	}
+	if err := pkg.build(); err != nil {
+		return err
+	}
+
+	vi.appendWelded(pkg.welder)
+	return nil
+}
+
+// ImportFrom3MF imports the base materials of a 3MF package
+func (mi *MaterialImporter) ImportFrom3MF(reader io.Reader) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read 3MF file: %w", err)
+	}
+
+	pkg, err := newThreeMFPackage(data)
+	if err != nil {
+		return err
+	}
+
+	materials, err := pkg.materials()
+	if err != nil {
+		return err
+	}
+	for _, material := range materials {
+		mi.materials[material.Name] = material
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package importers
+
+import "math"
+
+// Polygon records the corners of a source face that was triangulated.
+// Start and Count address the triangle indices it produced.
+type Polygon struct {
+	Corners []uint32
+	Start   int
+	Count   int
+}
+
+// triangulate splits a polygon given by vertex indices into triangles,
+// keeping its winding. Convex polygons are fanned; concave ones are ear
+// clipped in the plane of the polygon. Degenerate polygons that have no
+// ear left fall back to a fan over the remaining corners.
+func triangulate(corners []uint32, vertices []Vertex) []uint32 {
+	if len(corners) <= 3 {
+		return append([]uint32(nil), corners...)
+	}
+
+	points, ok := projectPolygon(corners, vertices)
+	if !ok || isConvex(points) {
+		return appendFan(nil, corners)
+	}
+
+	// Walk a ring of remaining corners, clipping one ear at a time
+	remaining := make([]int, len(corners))
+	for i := range remaining {
+		remaining[i] = i
+	}
+	indices := make([]uint32, 0, (len(corners)-2)*3)
+	for len(remaining) > 3 {
+		ear := -1
+		for i := range remaining {
+			if isEar(points, remaining, i) {
+				ear = i
+				break
+			}
+		}
+		if ear < 0 {
+			break
+		}
+
+		n := len(remaining)
+		prev, cur, next := remaining[(ear+n-1)%n], remaining[ear], remaining[(ear+1)%n]
+		indices = append(indices, corners[prev], corners[cur], corners[next])
+		remaining = append(remaining[:ear], remaining[ear+1:]...)
+	}
+
+	rest := make([]uint32, len(remaining))
+	for i, corner := range remaining {
+		rest[i] = corners[corner]
+	}
+	return appendFan(indices, rest)
+}
+
+// appendFan appends the fan triangulation of a polygon to indices
+func appendFan(indices []uint32, corners []uint32) []uint32 {
+	for i := 1; i+1 < len(corners); i++ {
+		indices = append(indices, corners[0], corners[i], corners[i+1])
+	}
+	return indices
+}
+
+// projectPolygon maps the polygon corners onto the coordinate plane most
+// parallel to it, oriented so the polygon winds counter-clockwise. It
+// fails for polygons without area.
+func projectPolygon(corners []uint32, vertices []Vertex) ([][2]float64, bool) {
+	// Newell's method gives a robust normal for non-planar polygons
+	var normal [3]float64
+	for i, corner := range corners {
+		a := vertices[corner].Position
+		b := vertices[corners[(i+1)%len(corners)]].Position
+		normal[0] += (a[1] - b[1]) * (a[2] + b[2])
+		normal[1] += (a[2] - b[2]) * (a[0] + b[0])
+		normal[2] += (a[0] - b[0]) * (a[1] + b[1])
+	}
+	if length3(normal) == 0 {
+		return nil, false
+	}
+
+	// Drop the dominant axis; the remaining two stay right-handed
+	axis := 0
+	for i := 1; i < 3; i++ {
+		if math.Abs(normal[i]) > math.Abs(normal[axis]) {
+			axis = i
+		}
+	}
+	u, v := (axis+1)%3, (axis+2)%3
+	flip := normal[axis] < 0
+
+	points := make([][2]float64, len(corners))
+	for i, corner := range corners {
+		p := vertices[corner].Position
+		points[i] = [2]float64{p[u], p[v]}
+		if flip {
+			points[i][1] = -points[i][1]
+		}
+	}
+	return points, true
+}
+
+// cross2 returns the z component of (b-a) x (c-b)
+func cross2(a, b, c [2]float64) float64 {
+	return (b[0]-a[0])*(c[1]-b[1]) - (b[1]-a[1])*(c[0]-b[0])
+}
+
+// isConvex reports whether a counter-clockwise polygon has no reflex corner
+func isConvex(points [][2]float64) bool {
+	n := len(points)
+	for i := range points {
+		if cross2(points[(i+n-1)%n], points[i], points[(i+1)%n]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isEar reports whether the corner at ring position i of a counter-clockwise
+// polygon is convex and its triangle contains no other remaining corner
+func isEar(points [][2]float64, ring []int, i int) bool {
+	n := len(ring)
+	a, b, c := points[ring[(i+n-1)%n]], points[ring[i]], points[ring[(i+1)%n]]
+	if cross2(a, b, c) <= 0 {
+		return false
+	}
+
+	for j, corner := range ring {
+		if j == i || j == (i+n-1)%n || j == (i+1)%n {
+			continue
+		}
+		p := points[corner]
+		if p == a || p == b || p == c {
+			continue
+		}
+		if cross2(a, b, p) >= 0 && cross2(b, c, p) >= 0 && cross2(c, a, p) >= 0 {
+			return false
+		}
+	}
+	return true
+}
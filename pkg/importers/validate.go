@@ -0,0 +1,280 @@
+package importers
+
+import (
+	"math"
+	"slices"
+)
+
+// maxIssueExamples limits the element indices listed per issue
+const maxIssueExamples = 10
+
+// ValidationIssue is one kind of problem found in a mesh. Vertices and
+// Triangles list the first few affected elements, Files the referenced
+// files of a bundle that are missing.
+type ValidationIssue struct {
+	Code      string   `json:"code"`
+	Message   string   `json:"message"`
+	Count     int      `json:"count"`
+	Vertices  []int    `json:"vertices,omitempty"`
+	Triangles []int    `json:"triangles,omitempty"`
+	Files     []string `json:"files,omitempty"`
+}
+
+// ValidationReport lists the problems found in a mesh. Errors make the
+// mesh unusable as is; warnings flag geometry that renders but may break
+// processing such as 3D printing or simplification.
+type ValidationReport struct {
+	Valid    bool              `json:"valid"`
+	Errors   []ValidationIssue `json:"errors"`
+	Warnings []ValidationIssue `json:"warnings"`
+}
+
+// ImportErrorReport returns the report of a model that failed to import
+func ImportErrorReport(err error) *ValidationReport {
+	return &ValidationReport{
+		Errors: []ValidationIssue{{
+			Code:    "import_failed",
+			Message: err.Error(),
+			Count:   1,
+		}},
+		Warnings: []ValidationIssue{},
+	}
+}
+
+// HasError reports whether the report contains an error with the code
+func (r *ValidationReport) HasError(code string) bool {
+	for _, issue := range r.Errors {
+		if issue.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// AddMissingFiles adds a warning for files referenced by a bundle's model
+// file, such as textures, that the bundle does not contain
+func (r *ValidationReport) AddMissingFiles(files []string) {
+	if len(files) == 0 {
+		return
+	}
+	r.Warnings = append(r.Warnings, ValidationIssue{
+		Code:    "missing_file",
+		Message: "referenced files are missing from the bundle",
+		Count:   len(files),
+		Files:   files,
+	})
+}
+
+// issueCollector accumulates occurrences of one issue
+type issueCollector struct {
+	issue ValidationIssue
+}
+
+func (c *issueCollector) vertex(i int) {
+	c.issue.Count++
+	if len(c.issue.Vertices) < maxIssueExamples {
+		c.issue.Vertices = append(c.issue.Vertices, i)
+	}
+}
+
+func (c *issueCollector) triangle(t int) {
+	c.issue.Count++
+	if len(c.issue.Triangles) < maxIssueExamples {
+		c.issue.Triangles = append(c.issue.Triangles, t)
+	}
+}
+
+// positionEdge is an undirected edge between two positions, stored with
+// the smaller position first
+type positionEdge struct {
+	a, b [3]float64
+}
+
+func newPositionEdge(a, b [3]float64) (positionEdge, bool) {
+	if slices.Compare(a[:], b[:]) > 0 {
+		return positionEdge{b, a}, true
+	}
+	return positionEdge{a, b}, false
+}
+
+// edgeUse records the triangles using an undirected edge and how often it
+// was traversed in each direction
+type edgeUse struct {
+	triangles []int
+	forward   int
+	backward  int
+}
+
+// Validate checks the mesh for non-finite values, broken indices,
+// unnormalized normals, degenerate and duplicate triangles, non-manifold
+// edges, inconsistent or inverted winding and holes. Edges are matched by
+// position so that attribute seams are not reported as holes.
+func (m *Mesh) Validate() *ValidationReport {
+	nonFinite := &issueCollector{ValidationIssue{Code: "non_finite_value", Message: "vertex attributes contain NaN or infinite values"}}
+	unnormalized := &issueCollector{ValidationIssue{Code: "unnormalized_normal", Message: "vertex normals are not unit length"}}
+	incomplete := &issueCollector{ValidationIssue{Code: "incomplete_triangle", Message: "index count is not a multiple of three"}}
+	outOfRange := &issueCollector{ValidationIssue{Code: "index_out_of_range", Message: "triangles reference missing vertices"}}
+	degenerate := &issueCollector{ValidationIssue{Code: "degenerate_triangle", Message: "triangles have zero area"}}
+	duplicate := &issueCollector{ValidationIssue{Code: "duplicate_triangle", Message: "triangles repeat another triangle"}}
+	nonManifold := &issueCollector{ValidationIssue{Code: "non_manifold_edge", Message: "edges are shared by more than two triangles"}}
+	inconsistent := &issueCollector{ValidationIssue{Code: "inconsistent_winding", Message: "neighboring triangles have opposite winding"}}
+	inverted := &issueCollector{ValidationIssue{Code: "inverted_winding", Message: "the closed mesh is inside out"}}
+	holes := &issueCollector{ValidationIssue{Code: "open_boundary", Message: "the mesh has boundary loops (holes)"}}
+
+	finite := make([]bool, len(m.Vertices))
+	for i, vertex := range m.Vertices {
+		finite[i] = isFiniteVertex(vertex)
+		if !finite[i] {
+			nonFinite.vertex(i)
+			continue
+		}
+		if vertex.Normal != ([3]float64{}) && math.Abs(length3(vertex.Normal)-1) > 1e-3 {
+			unnormalized.vertex(i)
+		}
+	}
+
+	if m.Primitive == PrimitiveTriangles {
+		if len(m.Indices)%3 != 0 {
+			incomplete.issue.Count = 1
+		}
+
+		seen := make(map[[3][3]float64]bool)
+		edges := make(map[positionEdge]*edgeUse)
+		var volume float64
+		for t := 0; t < len(m.Indices)/3; t++ {
+			corners := m.Indices[t*3 : t*3+3]
+			usable := true
+			for _, index := range corners {
+				if int(index) >= len(m.Vertices) {
+					outOfRange.triangle(t)
+					usable = false
+					break
+				}
+				usable = usable && finite[index]
+			}
+			if !usable {
+				continue
+			}
+
+			a, b, c := m.trianglePositions(t)
+			longest := max(dot3(sub3(b, a), sub3(b, a)), dot3(sub3(c, b), sub3(c, b)), dot3(sub3(a, c), sub3(a, c)))
+			if length3(cross3(sub3(b, a), sub3(c, a))) <= 1e-12*longest || longest == 0 {
+				degenerate.triangle(t)
+				continue
+			}
+
+			key := [3][3]float64{a, b, c}
+			slices.SortFunc(key[:], func(x, y [3]float64) int { return slices.Compare(x[:], y[:]) })
+			if seen[key] {
+				duplicate.triangle(t)
+				continue
+			}
+			seen[key] = true
+
+			volume += dot3(a, cross3(b, c))
+			for _, edge := range [][2][3]float64{{a, b}, {b, c}, {c, a}} {
+				key, reversed := newPositionEdge(edge[0], edge[1])
+				use, ok := edges[key]
+				if !ok {
+					use = &edgeUse{}
+					edges[key] = use
+				}
+				use.triangles = append(use.triangles, t)
+				if reversed {
+					use.backward++
+				} else {
+					use.forward++
+				}
+			}
+		}
+
+		var boundary []positionEdge
+		for edge, use := range edges {
+			switch {
+			case len(use.triangles) == 1:
+				boundary = append(boundary, edge)
+			case len(use.triangles) > 2:
+				nonManifold.triangle(use.triangles[0])
+			case use.forward != 1:
+				inconsistent.triangle(use.triangles[1])
+			}
+		}
+
+		if loops := countLoops(boundary); loops > 0 {
+			holes.issue.Count = loops
+		}
+		if len(edges) > 0 && len(boundary) == 0 && nonManifold.issue.Count == 0 &&
+			inconsistent.issue.Count == 0 && volume < 0 {
+			inverted.issue.Count = 1
+		}
+	}
+
+	report := &ValidationReport{Errors: []ValidationIssue{}, Warnings: []ValidationIssue{}}
+	for _, c := range []*issueCollector{nonFinite, incomplete, outOfRange} {
+		if c.issue.Count > 0 {
+			report.Errors = append(report.Errors, c.issue)
+		}
+	}
+	for _, c := range []*issueCollector{unnormalized, degenerate, duplicate, nonManifold, inconsistent, inverted, holes} {
+		if c.issue.Count > 0 {
+			report.Warnings = append(report.Warnings, c.issue)
+		}
+	}
+	report.Valid = len(report.Errors) == 0
+	return report
+}
+
+// Validate checks the imported mesh
+func (vi *VertexImporter) Validate() *ValidationReport {
+	return vi.GetMesh().Validate()
+}
+
+// isFiniteVertex reports whether all attributes in use are finite numbers
+func isFiniteVertex(v Vertex) bool {
+	values := append(append(v.Position[:], v.Normal[:]...), v.TexCoords[:]...)
+	if v.HasColor {
+		values = append(values, v.Color[:]...)
+	}
+	if v.HasTangent {
+		values = append(values, v.Tangent[:]...)
+	}
+	for _, value := range values {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// countLoops returns the number of connected boundary edge chains
+func countLoops(edges []positionEdge) int {
+	parent := make(map[[3]float64][3]float64)
+	var find func(p [3]float64) [3]float64
+	find = func(p [3]float64) [3]float64 {
+		root, ok := parent[p]
+		if !ok || root == p {
+			return p
+		}
+		root = find(root)
+		parent[p] = root
+		return root
+	}
+
+	for _, edge := range edges {
+		a, b := find(edge.a), find(edge.b)
+		if _, ok := parent[a]; !ok {
+			parent[a] = a
+		}
+		if a != b {
+			parent[b] = a
+		}
+	}
+
+	loops := 0
+	for p := range parent {
+		if find(p) == p {
+			loops++
+		}
+	}
+	return loops
+}
@@ -2,11 +2,10 @@ package importers
 
 import (
 	"bufio"
-	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
-	"math"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -16,11 +15,64 @@ type Vertex struct {
 	Position  [3]float64
 	Normal    [3]float64
 	TexCoords [2]float64
+	// Color is an optional RGBA color in [0, 1], set when HasColor is true
+	Color    [4]float64
+	HasColor bool
+	// Tangent is an optional unit tangent with the bitangent sign in the
+	// fourth component, set when HasTangent is true
+	Tangent    [4]float64
+	HasTangent bool
+}
+
+// PrimitiveType describes how imported vertices are assembled
+type PrimitiveType int
+
+const (
+	// PrimitiveTriangles is a triangle mesh
+	PrimitiveTriangles PrimitiveType = iota
+	// PrimitivePoints is a point cloud without faces
+	PrimitivePoints
+)
+
+// String returns the name of the primitive type
+func (p PrimitiveType) String() string {
+	switch p {
+	case PrimitivePoints:
+		return "points"
+	default:
+		return "triangles"
+	}
+}
+
+// MeshGroup is a named range of faces sharing an object, group, material
+// and smoothing group. Start and Count address GetIndices.
+type MeshGroup struct {
+	Object         string
+	Group          string
+	Material       string
+	SmoothingGroup int
+	Start          int
+	Count          int
+	Faces          int
+}
+
+// Name returns the group name, or the object name for ungrouped faces
+func (g MeshGroup) Name() string {
+	if g.Group != "" {
+		return g.Group
+	}
+	return g.Object
 }
 
 // VertexImporter handles importing vertices from different 3D model formats
 type VertexImporter struct {
-	vertices []Vertex
+	vertices          []Vertex
+	indices           []uint32
+	primitive         PrimitiveType
+	polygons          []Polygon
+	groups            []MeshGroup
+	smoothing         bool
+	materialLibraries []string
 }
 
 // NewVertexImporter creates a new vertex importer instance
@@ -30,13 +82,32 @@ func NewVertexImporter() *VertexImporter {
 	}
 }
 
-// ImportFromOBJ imports vertices from OBJ format
+// ImportFromOBJ imports vertices from OBJ format. Face corners with the
+// same position, texture coordinate and normal indices share one vertex.
+// Vertex colors given as "v x y z r g b" are imported as well.
 func (vi *VertexImporter) ImportFromOBJ(reader io.Reader) error {
 	scanner := bufio.NewScanner(reader)
 
 	var positions [][3]float64
+	// colors holds the RGBA color of each position, with zero alpha for
+	// positions without one
+	var colors [][4]float64
 	var normals [][3]float64
 	var texCoords [][2]float64
+	welded := make(map[[3]int]uint32)
+
+	// current holds the object, group, material and smoothing state that
+	// the next faces are assigned to
+	var current MeshGroup
+	flush := func() {
+		if current.Faces > 0 {
+			vi.groups = append(vi.groups, current)
+		}
+		current.Start = len(vi.indices)
+		current.Count = 0
+		current.Faces = 0
+	}
+	flush()
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -44,8 +115,9 @@ func (vi *VertexImporter) ImportFromOBJ(reader io.Reader) error {
 			continue
 		}
 
+		// A bare g or usemtl returns to no group or material
 		fields := strings.Fields(line)
-		if len(fields) < 2 {
+		if len(fields) < 2 && fields[0] != "g" && fields[0] != "usemtl" {
 			continue
 		}
 
@@ -60,6 +132,16 @@ func (vi *VertexImporter) ImportFromOBJ(reader io.Reader) error {
 			}
 			positions = append(positions, pos)
 
+			var color [4]float64
+			if len(fields) >= 7 {
+				rgb, err := ParseVector3(fields[4:7])
+				if err != nil {
+					return fmt.Errorf("failed to parse vertex color: %w", err)
+				}
+				color = [4]float64{rgb[0], rgb[1], rgb[2], 1}
+			}
+			colors = append(colors, color)
+
 		case "vn": // Vertex normal
 			if len(fields) < 4 {
 				return errors.New("invalid vertex normal")
@@ -81,16 +163,57 @@ func (vi *VertexImporter) ImportFromOBJ(reader io.Reader) error {
 			texCoords = append(texCoords, tex)
 
 		case "f": // Face
-			if err := vi.processFace(fields[1:], positions, normals, texCoords); err != nil {
+			if err := vi.processFace(fields[1:], positions, colors, normals, texCoords, welded); err != nil {
 				return fmt.Errorf("failed to process face: %w", err)
 			}
+			current.Count = len(vi.indices) - current.Start
+			current.Faces++
+
+		case "o": // Object name
+			flush()
+			current.Object = strings.Join(fields[1:], " ")
+			current.Group = ""
+
+		case "g": // Group names, "default" being no group
+			flush()
+			current.Group = strings.Join(fields[1:], " ")
+			if current.Group == "default" {
+				current.Group = ""
+			}
+
+		case "usemtl": // Material for the following faces
+			flush()
+			current.Material = strings.Join(fields[1:], " ")
+
+		case "s": // Smoothing group
+			vi.smoothing = true
+			group := 0
+			if fields[1] != "off" {
+				n, err := strconv.Atoi(fields[1])
+				if err != nil {
+					return fmt.Errorf("invalid smoothing group: %w", err)
+				}
+				group = n
+			}
+			if group != current.SmoothingGroup {
+				flush()
+				current.SmoothingGroup = group
+			}
+
+		case "mtllib": // Material libraries
+			vi.materialLibraries = append(vi.materialLibraries, fields[1:]...)
 		}
 	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
 
-	return scanner.Err()
+	flush()
+	return nil
 }
 
-// ImportFromFBX imports vertices from FBX format
+// ImportFromFBX imports vertices from binary or ASCII FBX format. Polygons
+// are triangulated and identical polygon vertices are welded.
 func (vi *VertexImporter) ImportFromFBX(reader io.Reader) error {
 	// Read the entire FBX file
 	data, err := io.ReadAll(reader)
@@ -98,70 +221,131 @@ func (vi *VertexImporter) ImportFromFBX(reader io.Reader) error {
 		return fmt.Errorf("failed to read FBX file: %w", err)
 	}
 
-	// Parse FBX binary format
-	vertices, normals, uvs, err := parseFBXBinary(data)
+	doc, err := ParseFBX(data)
 	if err != nil {
-		return fmt.Errorf("failed to parse FBX binary: %w", err)
+		return fmt.Errorf("failed to parse FBX: %w", err)
 	}
 
-	// Process vertices
-	for i := 0; i < len(vertices); i += 3 {
-		vertex := Vertex{
-			Position: [3]float64{
-				vertices[i],
-				vertices[i+1],
-				vertices[i+2],
-			},
-		}
+	return vi.importFBX(doc)
+}
 
-		// Add normal if available
-		if i < len(normals) {
-			vertex.Normal = [3]float64{
-				normals[i],
-				normals[i+1],
-				normals[i+2],
+// importFBX imports the mesh geometries of a parsed FBX document
+func (vi *VertexImporter) importFBX(doc *FBXDocument) error {
+	welder := newVertexWelder()
+	materials := fbxGeometryMaterials(doc)
+	for _, geometry := range doc.Node("Objects").ChildrenNamed("Geometry") {
+		if class, ok := geometry.Property(2); ok {
+			if name, _ := class.String(); name != "Mesh" {
+				continue
 			}
 		}
 
-		// Add UV coordinates if available
-		if i/3*2 < len(uvs) {
-			vertex.TexCoords = [2]float64{
-				uvs[i/3*2],
-				uvs[i/3*2+1],
-			}
+		id, _ := fbxNodeID(geometry)
+		if err := fbxGeometryMesh(geometry, materials[id], welder); err != nil {
+			return fmt.Errorf("invalid FBX geometry %q: %w", fbxNodeName(geometry), err)
 		}
-
-		vi.vertices = append(vi.vertices, vertex)
 	}
 
+	vi.appendWelded(welder)
 	return nil
 }
 
-// Helper function to parse FBX binary format
-func parseFBXBinary(data []byte) (vertices, normals, uvs []float64, err error) {
-	// FBX Binary format magic number "Kaydara FBX Binary  "
-	magic := []byte("Kaydara FBX Binary  ")
-	if len(data) < len(magic) || string(data[:len(magic)]) != string(magic) {
-		return nil, nil, nil, errors.New("invalid FBX binary format")
+// GetMesh returns the imported vertices and index buffer as a mesh
+func (vi *VertexImporter) GetMesh() *Mesh {
+	return &Mesh{
+		Vertices:  vi.vertices,
+		Indices:   vi.indices,
+		Primitive: vi.primitive,
+		Polygons:  vi.polygons,
 	}
+}
+
+// GetVertices returns the vertex buffer of GetMesh. It is kept for callers
+// that predate Mesh; the vertices are shared and need GetIndices to form
+// triangles.
+func (vi *VertexImporter) GetVertices() []Vertex {
+	return vi.vertices
+}
+
+// GetGroups returns the named face ranges of the imported model in file
+// order. In OBJ files a new range starts at every o, g, usemtl or
+// smoothing change; other formats have a range for every glTF primitive,
+// COLLADA primitive element, and FBX geometry or 3MF object and material.
+func (vi *VertexImporter) GetGroups() []MeshGroup {
+	return vi.groups
+}
+
+// GetMaterialLibraries returns the MTL file names referenced by mtllib
+func (vi *VertexImporter) GetMaterialLibraries() []string {
+	return vi.materialLibraries
+}
 
-	// Parse FBX version (located at offset 23)
-	version := binary.LittleEndian.Uint32(data[23:27])
-	if version < 7100 {
-		return nil, nil, nil, fmt.Errorf("unsupported FBX version: %d", version)
+// ResolveMaterials looks up the material of every group in materials,
+// typically imported from the files named by GetMaterialLibraries. It
+// returns the materials found by name and the names that are missing.
+func (vi *VertexImporter) ResolveMaterials(materials *MaterialImporter) (map[string]*Material, []string) {
+	resolved := make(map[string]*Material)
+	var missing []string
+	for _, group := range vi.groups {
+		if group.Material == "" {
+			continue
+		}
+		if _, ok := resolved[group.Material]; ok || slices.Contains(missing, group.Material) {
+			continue
+		}
+		if material, ok := materials.GetMaterial(group.Material); ok {
+			resolved[group.Material] = material
+		} else {
+			missing = append(missing, group.Material)
+		}
 	}
+	return resolved, missing
+}
 
-	// Parse geometry data
-	// This is a simplified implementation - real FBX parsing would need to handle
-	// the full node structure and property types
-	vertices, normals, uvs = extractGeometryData(data[27:])
+// GetIndices returns the triangle index buffer into GetVertices, or nil
+// for point clouds
+func (vi *VertexImporter) GetIndices() []uint32 {
+	return vi.indices
+}
 
-	return vertices, normals, uvs, nil
+// GetPrimitiveType reports whether the imported vertices form triangles
+// or a point cloud
+func (vi *VertexImporter) GetPrimitiveType() PrimitiveType {
+	return vi.primitive
 }
 
-// GetVertices returns the imported vertices
-func (vi *VertexImporter) GetVertices() []Vertex {
-	return vi.vertices
+// appendIndexed adds indexed vertices, offsetting the indices past the
+// vertices imported so far
+func (vi *VertexImporter) appendIndexed(vertices []Vertex, indices []uint32) {
+	base := uint32(len(vi.vertices))
+	vi.vertices = append(vi.vertices, vertices...)
+	for _, index := range indices {
+		vi.indices = append(vi.indices, base+index)
+	}
+}
+
+// appendGroups adds face ranges whose starts are relative to the given
+// index
+func (vi *VertexImporter) appendGroups(groups []MeshGroup, start int) {
+	for _, group := range groups {
+		group.Start += start
+		vi.groups = append(vi.groups, group)
+	}
+}
+
+// appendWelded adds the mesh built by a welder, offsetting its indices,
+// polygons and groups past the data imported so far
+func (vi *VertexImporter) appendWelded(w *vertexWelder) {
+	base, start := uint32(len(vi.vertices)), len(vi.indices)
+	for _, polygon := range w.polygons {
+		for i := range polygon.Corners {
+			polygon.Corners[i] += base
+		}
+		polygon.Start += start
+		vi.polygons = append(vi.polygons, polygon)
+	}
+	vi.appendGroups(w.groups, start)
+	vi.appendIndexed(w.vertices, w.indices)
 }
 
 // Helper functions for vector parsing
@@ -197,13 +381,14 @@ func parseVector2(values []string) ([2]float64, error) {
 	return result, nil
 }
 
-// processFace handles OBJ face definitions and creates vertices
-func (vi *VertexImporter) processFace(faceData []string, positions [][3]float64, normals [][3]float64, texCoords [][2]float64) error {
+// processFace handles OBJ face definitions, welding corners by their
+// position/texcoord/normal index triple and triangulating the face
+func (vi *VertexImporter) processFace(faceData []string, positions [][3]float64, colors [][4]float64, normals [][3]float64, texCoords [][2]float64, welded map[[3]int]uint32) error {
 	if len(faceData) < 3 {
 		return errors.New("face must have at least 3 vertices")
 	}
 
-	// Process each vertex in the face
+	corners := make([]uint32, 0, len(faceData))
 	for _, vertexData := range faceData {
 		// Split vertex data into position/texcoord/normal indices
 		indices := strings.Split(vertexData, "/")
@@ -213,10 +398,7 @@ func (vi *VertexImporter) processFace(faceData []string, positions [][3]float64,
 		if err != nil {
 			return fmt.Errorf("invalid position index: %w", err)
 		}
-
-		vertex := Vertex{
-			Position: positions[posIndex],
-		}
+		key := [3]int{posIndex, -1, -1}
 
 		// Parse texture coordinate index (optional)
 		if len(indices) > 1 && indices[1] != "" {
@@ -224,7 +406,7 @@ func (vi *VertexImporter) processFace(faceData []string, positions [][3]float64,
 			if err != nil {
 				return fmt.Errorf("invalid texture coordinate index: %w", err)
 			}
-			vertex.TexCoords = texCoords[texIndex]
+			key[1] = texIndex
 		}
 
 		// Parse normal index (optional)
@@ -233,12 +415,32 @@ func (vi *VertexImporter) processFace(faceData []string, positions [][3]float64,
 			if err != nil {
 				return fmt.Errorf("invalid normal index: %w", err)
 			}
-			vertex.Normal = normals[normalIndex]
+			key[2] = normalIndex
 		}
 
-		vi.vertices = append(vi.vertices, vertex)
+		index, ok := welded[key]
+		if !ok {
+			vertex := Vertex{Position: positions[key[0]]}
+			if color := colors[key[0]]; color[3] != 0 {
+				vertex.Color = color
+				vertex.HasColor = true
+			}
+			if key[1] >= 0 {
+				vertex.TexCoords = texCoords[key[1]]
+			}
+			if key[2] >= 0 {
+				vertex.Normal = normals[key[2]]
+			}
+			index = uint32(len(vi.vertices))
+			welded[key] = index
+			vi.vertices = append(vi.vertices, vertex)
+		}
+		corners = append(corners, index)
 	}
 
+	triangles := triangulate(corners, vi.vertices)
+	vi.polygons = append(vi.polygons, Polygon{Corners: corners, Start: len(vi.indices), Count: len(triangles)})
+	vi.indices = append(vi.indices, triangles...)
 	return nil
 }
 
@@ -265,82 +467,6 @@ func parseIndex(indexStr string, maxLen int) (int, error) {
 	return index, nil
 }
 
-// extractGeometryData parses FBX binary data to extract geometry information
-func extractGeometryData(data []byte) (vertices, normals, uvs []float64) {
-	// Initialize slices to store the geometry data
-	vertices = make([]float64, 0)
-	normals = make([]float64, 0)
-	uvs = make([]float64, 0)
-
-	// FBX uses a node structure. We need to find the Geometry node
-	// and its child nodes for vertices, normals, and UV coordinates
-	offset := uint32(0)
-	for offset < uint32(len(data)) {
-		// Check if we have enough data left to read a node header
-		if offset+4 > uint32(len(data)) {
-			break
-		}
-
-		// Read node header (endOffset, numProperties, propertyListLen)
-		endOffset := binary.LittleEndian.Uint32(data[offset : offset+4])
-		if endOffset == 0 || endOffset > uint32(len(data)) {
-			break
-		}
-
-		// Skip header
-		offset += 13 // Standard FBX node header size
-
-		// Read node name length
-		nameLen := uint8(data[offset])
-		offset++
-
-		// Read node name
-		if offset+uint32(nameLen) > uint32(len(data)) {
-			break
-		}
-		nodeName := string(data[offset : offset+uint32(nameLen)])
-		offset += uint32(nameLen)
-
-		// Process node based on its name
-		switch nodeName {
-		case "Vertices":
-			vertices = extractFloatArray(data[offset:endOffset])
-		case "Normals":
-			normals = extractFloatArray(data[offset:endOffset])
-		case "UV":
-			uvs = extractFloatArray(data[offset:endOffset])
-		}
-
-		// Move to next node
-		offset = endOffset
-	}
-
-	return
-}
-
-// extractFloatArray reads an array of float64 values from FBX binary data
-func extractFloatArray(data []byte) []float64 {
-	result := make([]float64, 0)
-
-	// First 4 bytes contain the array length
-	if len(data) < 4 {
-		return result
-	}
-
-	arrayLen := binary.LittleEndian.Uint32(data[0:4])
-	offset := uint32(4)
-
-	// Read each float64 value
-	for i := uint32(0); i < arrayLen && offset+8 <= uint32(len(data)); i++ {
-		bits := binary.LittleEndian.Uint64(data[offset : offset+8])
-		value := math.Float64frombits(bits)
-		result = append(result, value)
-		offset += 8
-	}
-
-	return result
-}
-
 // ParseVector3 converts string values to a 3D vector
 func ParseVector3(values []string) ([3]float64, error) {
 	if len(values) < 3 {
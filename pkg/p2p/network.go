@@ -2,6 +2,8 @@ package p2p
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -25,6 +27,13 @@ const (
 	ConnectionTimeout  = 10 * time.Second
 )
 
+var (
+	ErrNotStarted = errors.New("network not started")
+)
+
+// MessageHandler processes a message received from another peer
+type MessageHandler func(ctx context.Context, msg *Message)
+
 type Network struct {
 	cfg          *config.Config
 	host         host.Host
@@ -33,6 +42,7 @@ type Network struct {
 	topic        *pubsub.Topic
 	subscription *pubsub.Subscription
 	peers        map[peer.ID]peer.AddrInfo
+	handler      MessageHandler
 	mu           sync.RWMutex
 }
 
@@ -206,19 +216,54 @@ func (n *Network) handleMessages(ctx context.Context) {
 }
 
 func (n *Network) processMessage(ctx context.Context, msg *pubsub.Message) {
-	// TODO: Implement message processing based on message type
-	// Examples of message types:
-	// - Model announcement
-	// - Chunk request
-	// - Chunk response
-	// - Storage proof
-	// - Node status
+	var message Message
+	if err := json.Unmarshal(msg.Data, &message); err != nil {
+		return
+	}
+	message.From = msg.ReceivedFrom
+
+	n.mu.RLock()
+	handler := n.handler
+	n.mu.RUnlock()
+
+	if handler != nil {
+		handler(ctx, &message)
+	}
+}
+
+// SetMessageHandler registers the handler for messages received over pubsub
+func (n *Network) SetMessageHandler(handler MessageHandler) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.handler = handler
 }
 
 func (n *Network) Broadcast(ctx context.Context, data []byte) error {
+	if n.topic == nil {
+		return ErrNotStarted
+	}
 	return n.topic.Publish(ctx, data)
 }
 
+// Publish wraps a payload in a typed message and broadcasts it
+func (n *Network) Publish(ctx context.Context, msgType MessageType, payload []byte) error {
+	if n.host == nil {
+		return ErrNotStarted
+	}
+
+	data, err := json.Marshal(Message{
+		Type:    msgType,
+		Payload: payload,
+		From:    n.host.ID(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return n.Broadcast(ctx, data)
+}
+
 func (n *Network) SendToPeer(ctx context.Context, peerID peer.ID, data []byte) error {
 	stream, err := n.host.NewStream(ctx, peerID, protocol.ID(ProtocolID))
 	if err != nil {
@@ -272,6 +317,7 @@ const (
 	MessageTypeChunkResponse
 	MessageTypeStorageProof
 	MessageTypeNodeStatus
+	MessageTypeModelMetadata
 )
 
 type Message struct {
@@ -0,0 +1,82 @@
+package textures_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/3FT-io/3DS/pkg/textures"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestInspect(t *testing.T) {
+	// An opaque image is written as RGB, which the decoder widens to RGBA
+	opaque := image.NewNRGBA(image.Rect(0, 0, 8, 4))
+	for i := 3; i < len(opaque.Pix); i += 4 {
+		opaque.Pix[i] = 0xff
+	}
+
+	var jpg bytes.Buffer
+	require.NoError(t, jpeg.Encode(&jpg, image.NewGray(image.Rect(0, 0, 3, 5)), nil))
+
+	tests := []struct {
+		name string
+		data []byte
+		want textures.Info
+	}{
+		{"rgb png", encodePNG(t, opaque), textures.Info{Format: "png", Width: 8, Height: 4, Channels: 3, BitDepth: 8}},
+		{"rgba png", encodePNG(t, image.NewNRGBA(image.Rect(0, 0, 2, 2))), textures.Info{Format: "png", Width: 2, Height: 2, Channels: 4, BitDepth: 8}},
+		{"gray16 png", encodePNG(t, image.NewGray16(image.Rect(0, 0, 1, 1))), textures.Info{Format: "png", Width: 1, Height: 1, Channels: 1, BitDepth: 16}},
+		{"gray jpeg", jpg.Bytes(), textures.Info{Format: "jpeg", Width: 3, Height: 5, Channels: 1, BitDepth: 8}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := textures.Inspect(tt.data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, info)
+		})
+	}
+
+	_, err := textures.Inspect([]byte("GIF89a"))
+	assert.ErrorIs(t, err, textures.ErrUnsupportedFormat)
+}
+
+func TestMipmaps(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 5, 2))
+	for x := 0; x < 5; x++ {
+		img.Set(x, 0, color.RGBA{R: 200, A: 255})
+		img.Set(x, 1, color.RGBA{B: 100, A: 255})
+	}
+
+	levels := textures.Mipmaps(img)
+	var sizes []image.Point
+	for _, level := range levels {
+		sizes = append(sizes, level.Bounds().Size())
+	}
+	assert.Equal(t, []image.Point{{2, 1}, {1, 1}}, sizes)
+	assert.Equal(t, color.RGBA{R: 100, B: 50, A: 255}, levels[len(levels)-1].RGBAAt(0, 0))
+}
+
+func TestDownscaleTransparency(t *testing.T) {
+	// Fully transparent pixels must not darken their opaque neighbours
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+	img.Set(1, 0, color.NRGBA{})
+
+	scaled := textures.Downscale(img, 1, 1)
+	got := color.NRGBAModel.Convert(scaled.At(0, 0)).(color.NRGBA)
+	assert.Equal(t, uint8(128), got.A)
+	assert.GreaterOrEqual(t, got.R, uint8(254))
+}
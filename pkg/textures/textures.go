@@ -0,0 +1,167 @@
+package textures
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+)
+
+const (
+	// jpegQuality is used when encoding downscaled JPEG textures
+	jpegQuality = 90
+	// maxPixels bounds the memory used to decode a texture
+	maxPixels = 1 << 26
+)
+
+// ErrUnsupportedFormat is returned for images other than PNG and JPEG
+var ErrUnsupportedFormat = errors.New("unsupported texture format")
+
+// Info describes a texture image
+type Info struct {
+	// Format is "png" or "jpeg"
+	Format   string `json:"format"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	Channels int    `json:"channels"`
+	// BitDepth is the number of bits per channel
+	BitDepth int `json:"bit_depth"`
+}
+
+// Inspect reads the format, dimensions and channel layout of a PNG or JPEG
+// image without decoding its pixels
+func Inspect(data []byte) (Info, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		if errors.Is(err, image.ErrFormat) {
+			return Info{}, ErrUnsupportedFormat
+		}
+		return Info{}, fmt.Errorf("invalid texture image: %w", err)
+	}
+
+	info := Info{Format: format, Width: config.Width, Height: config.Height, Channels: 3, BitDepth: 8}
+	switch format {
+	case "png":
+		// The decoder widens RGB images to RGBA, so the channels come from
+		// the IHDR chunk, which a successful DecodeConfig has read: bit depth
+		// at offset 24, color type at 25
+		info.BitDepth = int(data[24])
+		switch data[25] {
+		case 0:
+			info.Channels = 1
+		case 4:
+			info.Channels = 2
+		case 6:
+			info.Channels = 4
+		case 3:
+			// Palette indices expand to RGB samples of 8 bits
+			info.BitDepth = 8
+		}
+	case "jpeg":
+		switch config.ColorModel {
+		case color.GrayModel:
+			info.Channels = 1
+		case color.CMYKModel:
+			info.Channels = 4
+		}
+	default:
+		return Info{}, ErrUnsupportedFormat
+	}
+	return info, nil
+}
+
+// Decode decodes a PNG or JPEG image
+func Decode(data []byte) (image.Image, Info, error) {
+	info, err := Inspect(data)
+	if err != nil {
+		return nil, Info{}, err
+	}
+	if info.Width*info.Height > maxPixels {
+		return nil, Info{}, fmt.Errorf("texture image is too large: %dx%d", info.Width, info.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, Info{}, fmt.Errorf("invalid texture image: %w", err)
+	}
+	return img, info, nil
+}
+
+// Encode encodes an image as PNG or JPEG
+func Encode(img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Mipmaps returns the mip chain below img, largest first. Each level halves
+// the dimensions of the one above, rounding down but not below one pixel,
+// down to a 1x1 image.
+func Mipmaps(img image.Image) []*image.RGBA {
+	var levels []*image.RGBA
+	current := toRGBA(img)
+	for current.Bounds().Dx() > 1 || current.Bounds().Dy() > 1 {
+		current = Downscale(current, max(current.Bounds().Dx()/2, 1), max(current.Bounds().Dy()/2, 1))
+		levels = append(levels, current)
+	}
+	return levels
+}
+
+// Downscale shrinks an image to the given size with a box filter: every
+// destination pixel averages the source pixels it covers. Colors are
+// averaged premultiplied by alpha so transparent pixels do not bleed.
+func Downscale(img image.Image, width, height int) *image.RGBA {
+	src := toRGBA(img)
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, max((y+1)*sh/height, y*sh/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sw/width, max((x+1)*sw/width, x*sw/width+1)
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(row[sx*4+c])
+					}
+				}
+			}
+
+			n := (x1 - x0) * (y1 - y0)
+			offset := y*dst.Stride + x*4
+			for c := 0; c < 4; c++ {
+				dst.Pix[offset+c] = uint8((sum[c] + n/2) / n)
+			}
+		}
+	}
+	return dst
+}
+
+// toRGBA converts an image to premultiplied RGBA with its origin at zero
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
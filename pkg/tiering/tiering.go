@@ -0,0 +1,180 @@
+package tiering
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// AccessStats records how often and how recently an item was read
+type AccessStats struct {
+	Count      int       `json:"count"`
+	LastAccess time.Time `json:"last_access"`
+}
+
+// Tracker records read accesses for stored items
+type Tracker struct {
+	mu    sync.Mutex
+	stats map[string]*AccessStats
+	now   func() time.Time
+}
+
+// NewTracker creates a new access tracker instance
+func NewTracker() *Tracker {
+	return &Tracker{
+		stats: make(map[string]*AccessStats),
+		now:   time.Now,
+	}
+}
+
+// Touch records an access to the given item
+func (t *Tracker) Touch(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats, ok := t.stats[key]
+	if !ok {
+		stats = &AccessStats{}
+		t.stats[key] = stats
+	}
+	stats.Count++
+	stats.LastAccess = t.now()
+}
+
+// Seed registers an item without counting an access, so that newly stored
+// items are not considered cold straight away
+func (t *Tracker) Seed(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.stats[key]; !ok {
+		t.stats[key] = &AccessStats{LastAccess: t.now()}
+	}
+}
+
+// Stats returns the access statistics for the given item
+func (t *Tracker) Stats(key string) AccessStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if stats, ok := t.stats[key]; ok {
+		return *stats
+	}
+	return AccessStats{}
+}
+
+// Forget removes all statistics for the given item
+func (t *Tracker) Forget(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.stats, key)
+}
+
+// ResetCounts starts a new counting window while keeping last access times
+func (t *Tracker) ResetCounts() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, stats := range t.stats {
+		stats.Count = 0
+	}
+}
+
+// SetClock overrides the time source, for testing
+func (t *Tracker) SetClock(now func() time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.now = now
+}
+
+// Policy decides when items move between tiers. Tier 0 is the hottest.
+type Policy struct {
+	// PromoteThreshold is the number of accesses within one rebalance
+	// window after which an item moves one tier up
+	PromoteThreshold int
+	// DemoteAfter is the idle time after which an item moves one tier down
+	DemoteAfter time.Duration
+}
+
+// Target returns the tier an item should live in given its current tier
+func (p Policy) Target(current, numTiers int, stats AccessStats, now time.Time) int {
+	if current > 0 && p.PromoteThreshold > 0 && stats.Count >= p.PromoteThreshold {
+		return current - 1
+	}
+	if current < numTiers-1 && p.DemoteAfter > 0 && now.Sub(stats.LastAccess) >= p.DemoteAfter {
+		return current + 1
+	}
+	return current
+}
+
+// CopyPath copies a file or directory tree to dst
+func CopyPath(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	if err := copyPath(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+
+	return nil
+}
+
+func copyPath(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return copyFile(src, dst, info.Mode())
+	}
+
+	if err := os.MkdirAll(dst, info.Mode()); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := copyPath(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// Exists reports whether the given path exists
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}